@@ -6,11 +6,15 @@ import (
 	"os/signal"
 	"syscall"
 
+	"github.com/ilyxenc/rattle/internal/bot"
 	"github.com/ilyxenc/rattle/internal/config"
 	"github.com/ilyxenc/rattle/internal/database"
+	"github.com/ilyxenc/rattle/internal/dispatcher"
 	"github.com/ilyxenc/rattle/internal/docker"
+	"github.com/ilyxenc/rattle/internal/loganalyzer"
 	"github.com/ilyxenc/rattle/internal/logger"
 	"github.com/ilyxenc/rattle/internal/managers"
+	"github.com/ilyxenc/rattle/internal/notify"
 	"github.com/ilyxenc/rattle/internal/scanner"
 	"github.com/ilyxenc/rattle/internal/telegram"
 )
@@ -41,10 +45,16 @@ func main() {
 	// Initialize Telegram client
 	telegram.Init()
 
+	// Build notifiers for Telegram chats and notification channels
+	dispatcher.Init()
+
+	// Start log analyzer background jobs (duplicate suppression)
+	loganalyzer.Init()
+
 	// Log and notify that Rattle has started
 	logger.Log.Infof("🚀 Rattle started in %s mode", config.Cfg.Env)
-	telegram.Notify(telegram.Notification{
-		Type: telegram.NotificationStartedRattle,
+	dispatcher.Notify(notify.Notification{
+		Type: notify.NotificationStartedRattle,
 	})
 
 	// Create context that cancels on interrupt or SIGTERM
@@ -57,15 +67,30 @@ func main() {
 	// Start scanning container logs in the background
 	go manager.StartAll()
 
+	// Answer bot commands like /status and /mute
+	if config.Cfg.Bot.Enabled {
+		if err := bot.Start(ctx, manager); err != nil {
+			logger.Log.Errorf("Failed to start bot commands: %v", err)
+		}
+	}
+
 	// Wait until shutdown signal is received
 	<-ctx.Done()
 
 	// Trigger shutdown
 	manager.StopAll()
 
+	// Save log cursors so scanning resumes from the same place after restart
+	if err := managers.Cursors.Flush(); err != nil {
+		logger.Log.Warnf("Failed to save log cursors: %v", err)
+	}
+
 	// Log and notify that Rattle is shutting down
 	logger.Log.Info("🛑 Shutting down Rattle")
-	telegram.Notify(telegram.Notification{
-		Type: telegram.NotificationShutDownRattle,
+	dispatcher.Notify(notify.Notification{
+		Type: notify.NotificationShutDownRattle,
 	})
+
+	// Send queued messages like email digests
+	dispatcher.Close()
 }
@@ -0,0 +1,294 @@
+package bot
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ilyxenc/rattle/internal/config"
+	"github.com/ilyxenc/rattle/internal/docker"
+	"github.com/ilyxenc/rattle/internal/logger"
+	"github.com/ilyxenc/rattle/internal/telegram"
+)
+
+const (
+	pollTimeout    = 25               // Seconds a getUpdates request waits for updates, below the HTTP client timeout
+	pollMinBackoff = time.Second      // Initial delay after a failed getUpdates
+	pollMaxBackoff = 60 * time.Second // Maximum delay between getUpdates attempts
+	webhookPath    = "/telegram/webhook"
+)
+
+// allowedUpdates are update types the bot receives
+var allowedUpdates = `["message","callback_query"]`
+
+// Scanners provides the state of log scanning to commands
+type Scanners interface {
+	Active() []docker.ContainerInfo           // Containers being scanned
+	Running() ([]docker.ContainerInfo, error) // All running containers
+	// Last `lines` lines of the container output
+	Logs(ctx context.Context, id string, lines int) (string, error)
+}
+
+// Update is an incoming update of the Bot API. Only fields used by Rattle are defined
+type Update struct {
+	UpdateID      int            `json:"update_id"`
+	Message       *Message       `json:"message"`
+	CallbackQuery *CallbackQuery `json:"callback_query"`
+}
+
+// Message is a Telegram message
+type Message struct {
+	MessageID       int    `json:"message_id"`
+	MessageThreadID int    `json:"message_thread_id"`
+	IsTopicMessage  bool   `json:"is_topic_message"`
+	From            *User  `json:"from"`
+	Chat            Chat   `json:"chat"`
+	Text            string `json:"text"`
+}
+
+// CallbackQuery is a press of an inline keyboard button
+type CallbackQuery struct {
+	ID      string   `json:"id"`
+	From    User     `json:"from"`
+	Message *Message `json:"message"` // Message with the button, nil if it's too old
+	Data    string   `json:"data"`
+}
+
+// User is the sender of a message
+type User struct {
+	ID        int64  `json:"id"`
+	Username  string `json:"username"`
+	FirstName string `json:"first_name"`
+}
+
+// Chat is the chat a message was sent to
+type Chat struct {
+	ID   int64  `json:"id"`
+	Type string `json:"type"`
+}
+
+// Bot receives updates from Telegram and answers commands
+type Bot struct {
+	scanners Scanners
+	username string // Username of the bot, commands addressed to other bots are ignored
+	started  time.Time
+}
+
+// Start receives updates in the background until the context is cancelled:
+// via webhook if config.Cfg.Bot.WebhookURL is set, otherwise via long polling.
+// Must be called after telegram.Init
+func Start(ctx context.Context, scanners Scanners) error {
+	var me User
+	if err := telegram.Call(ctx, "getMe", nil, &me); err != nil {
+		return err
+	}
+
+	b := &Bot{
+		scanners: scanners,
+		username: me.Username,
+		started:  time.Now(),
+	}
+
+	if err := b.registerCommands(ctx); err != nil {
+		logger.Log.Warnf("Failed to register bot commands: %v", err)
+	}
+
+	if config.Cfg.Bot.WebhookURL != "" {
+		return b.serveWebhook(ctx)
+	}
+
+	// Telegram doesn't deliver updates via getUpdates while a webhook is set
+	if err := telegram.Call(ctx, "deleteWebhook", nil, nil); err != nil {
+		return err
+	}
+	go b.poll(ctx)
+
+	logger.Log.Infof("Bot @%s is receiving updates via long polling", b.username)
+	return nil
+}
+
+// registerCommands sets the command list shown by Telegram clients
+func (b *Bot) registerCommands(ctx context.Context) error {
+	type botCommand struct {
+		Command     string `json:"command"`
+		Description string `json:"description"`
+	}
+
+	list := make([]botCommand, 0, len(commandList))
+	for _, name := range commandList {
+		list = append(list, botCommand{Command: name, Description: commands[name].description})
+	}
+
+	body, err := json.Marshal(list)
+	if err != nil {
+		return err
+	}
+	return telegram.Call(ctx, "setMyCommands", map[string]string{"commands": string(body)}, nil)
+}
+
+// poll receives updates with getUpdates until the context is cancelled
+func (b *Bot) poll(ctx context.Context) {
+	offset := 0
+	backoff := pollMinBackoff
+
+	for ctx.Err() == nil {
+		var updates []Update
+		err := telegram.Call(ctx, "getUpdates", map[string]string{
+			"offset":          strconv.Itoa(offset),
+			"timeout":         strconv.Itoa(pollTimeout),
+			"allowed_updates": allowedUpdates,
+		}, &updates)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+
+			logger.Log.Warnf("Failed to get Telegram updates, retrying in %s: %v", backoff, err)
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(backoff):
+			}
+			backoff = min(backoff*2, pollMaxBackoff)
+			continue
+		}
+		backoff = pollMinBackoff
+
+		for _, u := range updates {
+			offset = u.UpdateID + 1
+			b.handle(ctx, u)
+		}
+	}
+}
+
+// serveWebhook registers the webhook and serves updates posted by Telegram until the context is cancelled
+func (b *Bot) serveWebhook(ctx context.Context) error {
+	secret := config.Cfg.Bot.WebhookSecret
+	if secret == "" {
+		buf := make([]byte, 32)
+		if _, err := rand.Read(buf); err != nil {
+			return err
+		}
+		secret = hex.EncodeToString(buf)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc(webhookPath, func(w http.ResponseWriter, r *http.Request) {
+		token := r.Header.Get("X-Telegram-Bot-Api-Secret-Token")
+		if r.Method != http.MethodPost || subtle.ConstantTimeCompare([]byte(token), []byte(secret)) != 1 {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+
+		var u Update
+		if err := json.NewDecoder(r.Body).Decode(&u); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		// Answer right away, replies are sent through the send queue
+		w.WriteHeader(http.StatusOK)
+		go b.handle(ctx, u)
+	})
+
+	// Listen before registering the webhook, so Telegram doesn't post to an address nobody serves
+	ln, err := net.Listen("tcp", config.Cfg.Bot.WebhookListen)
+	if err != nil {
+		return err
+	}
+
+	srv := &http.Server{
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	go func() {
+		if err := srv.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			logger.Log.Errorf("Bot webhook server failed: %v", err)
+		}
+	}()
+
+	url := strings.TrimSuffix(config.Cfg.Bot.WebhookURL, "/") + webhookPath
+	err = telegram.Call(ctx, "setWebhook", map[string]string{
+		"url":             url,
+		"secret_token":    secret,
+		"allowed_updates": allowedUpdates,
+	}, nil)
+	if err != nil {
+		// Telegram won't post updates, so don't leave the server running
+		_ = srv.Close()
+		return err
+	}
+
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = srv.Shutdown(shutdownCtx)
+	}()
+
+	logger.Log.Infof("Bot @%s is receiving updates via webhook %s", b.username, url)
+	return nil
+}
+
+// handle answers a single update
+func (b *Bot) handle(ctx context.Context, u Update) {
+	if u.CallbackQuery != nil {
+		b.handleCallback(ctx, u.CallbackQuery)
+		return
+	}
+
+	msg := u.Message
+	if msg == nil || msg.From == nil {
+		return
+	}
+
+	name, args, ok := ParseCommand(msg.Text, b.username)
+	if !ok {
+		return
+	}
+
+	reply := b.run(ctx, name, args, msg.From)
+	if reply == "" {
+		return
+	}
+
+	if err := telegram.Reply(strconv.FormatInt(msg.Chat.ID, 10), msg.threadID(), reply); err != nil {
+		logger.Log.Warnf("Failed to answer /%s in chat %d: %v", name, msg.Chat.ID, err)
+	}
+}
+
+// threadID returns the forum topic of the message, 0 if the chat isn't a forum
+func (m *Message) threadID() int {
+	if m.IsTopicMessage {
+		return m.MessageThreadID
+	}
+	return 0
+}
+
+// ParseCommand splits a message like "/mute@RattleBot api 30m" into the command name and arguments.
+// Returns false if the text isn't a command or is addressed to another bot
+func ParseCommand(text, username string) (name string, args []string, ok bool) {
+	fields := strings.Fields(text)
+	if len(fields) == 0 || !strings.HasPrefix(fields[0], "/") {
+		return "", nil, false
+	}
+
+	name, target, addressed := strings.Cut(strings.TrimPrefix(fields[0], "/"), "@")
+	if addressed && !strings.EqualFold(target, username) {
+		return "", nil, false
+	}
+	if name == "" {
+		return "", nil, false
+	}
+
+	return strings.ToLower(name), fields[1:], true
+}
@@ -0,0 +1,338 @@
+package bot
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ilyxenc/rattle/internal/config"
+	"github.com/ilyxenc/rattle/internal/docker"
+	"github.com/ilyxenc/rattle/internal/logger"
+	"github.com/ilyxenc/rattle/internal/models"
+	"github.com/ilyxenc/rattle/internal/telegram"
+	"go.uber.org/zap"
+)
+
+// fakeAPI is a Bot API stand-in serving queued updates and recording calls
+type fakeAPI struct {
+	mu      sync.Mutex
+	calls   []string      // Called methods in order
+	offsets []string      // Offsets of getUpdates calls
+	updates []Update      // Served by the next getUpdates
+	sent    []url.Values  // Parameters of sendMessage calls
+	notify  chan struct{} // Signalled on every sendMessage
+	fail    string        // Method that fails
+}
+
+var api = &fakeAPI{notify: make(chan struct{}, 100)}
+
+func TestMain(m *testing.M) {
+	srv := httptest.NewServer(api)
+
+	logger.Log = zap.NewNop().Sugar()
+	config.Cfg = &config.Config{
+		BotToken:  "test",
+		BotAPIURL: srv.URL,
+		Env:       "test",
+	}
+	telegram.Init()
+
+	code := m.Run()
+	srv.Close()
+	os.Exit(code)
+}
+
+func (f *fakeAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	_ = r.ParseForm()
+	method := r.URL.Path[strings.LastIndex(r.URL.Path, "/")+1:]
+
+	f.mu.Lock()
+	f.calls = append(f.calls, method)
+	if method == f.fail {
+		f.mu.Unlock()
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`{"ok": false, "description": "Bad Request: bad webhook"}`))
+		return
+	}
+	var result any = true
+	switch method {
+	case "getMe":
+		result = User{ID: 1, Username: "RattleBot"}
+	case "getUpdates":
+		f.offsets = append(f.offsets, r.PostForm.Get("offset"))
+		result = f.updates
+		f.updates = nil
+	case "sendMessage":
+		f.sent = append(f.sent, r.PostForm)
+		f.notify <- struct{}{}
+	}
+	f.mu.Unlock()
+
+	// Long polling without updates
+	if method == "getUpdates" && result.([]Update) == nil {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(50 * time.Millisecond):
+		}
+		result = []Update{}
+	}
+
+	_ = json.NewEncoder(w).Encode(map[string]any{"ok": true, "result": result})
+}
+
+// fakeScanners reports a fixed set of containers
+type fakeScanners struct{}
+
+func (fakeScanners) Active() []docker.ContainerInfo {
+	return []docker.ContainerInfo{{ID: "a1", ShortID: "a1", Name: "api"}}
+}
+
+func (fakeScanners) Running() ([]docker.ContainerInfo, error) {
+	return fakeScanners{}.Active(), nil
+}
+
+func (fakeScanners) Logs(context.Context, string, int) (string, error) {
+	return "line", nil
+}
+
+// withRoles replaces the role lookup with fixed roles by Telegram ID for the test
+func withRoles(t *testing.T, roles map[int64]string) {
+	t.Helper()
+
+	prev := roleOf
+	roleOf = func(telegramID string) string {
+		for id, role := range roles {
+			if fmt.Sprint(id) == telegramID {
+				return role
+			}
+		}
+		return ""
+	}
+	t.Cleanup(func() { roleOf = prev })
+}
+
+const (
+	adminID    = 100
+	userID     = 200
+	strangerID = 300
+)
+
+func TestParseCommand(t *testing.T) {
+	tests := []struct {
+		text string
+		name string
+		args []string
+		ok   bool
+	}{
+		{"/status", "status", []string{}, true},
+		{"/status@RattleBot", "status", []string{}, true},
+		{"/STATUS@rattlebot", "status", []string{}, true},
+		{"  /mute   api   30m ", "mute", []string{"api", "30m"}, true},
+		{"/mute@RattleBot image=nginx 1d", "mute", []string{"image=nginx", "1d"}, true},
+		{"/mute@OtherBot api 30m", "", nil, false},
+		{"/status@", "", nil, false},
+		{"/", "", nil, false},
+		{"/@RattleBot", "", nil, false},
+		{"status", "", nil, false},
+		{"", "", nil, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			name, args, ok := ParseCommand(tt.text, "RattleBot")
+			if ok != tt.ok || name != tt.name {
+				t.Fatalf("ParseCommand(%q) = %q, %v, want %q, %v", tt.text, name, ok, tt.name, tt.ok)
+			}
+			if ok && strings.Join(args, " ") != strings.Join(tt.args, " ") {
+				t.Errorf("args = %q, want %q", args, tt.args)
+			}
+		})
+	}
+}
+
+func TestHasRole(t *testing.T) {
+	tests := []struct {
+		role     string
+		required string
+		want     bool
+	}{
+		{models.RoleAdmin, models.RoleAdmin, true},
+		{models.RoleAdmin, models.RoleUser, true},
+		{models.RoleUser, models.RoleUser, true},
+		{models.RoleUser, models.RoleAdmin, false},
+		{"", models.RoleUser, false},
+		{"", models.RoleAdmin, false},
+		{"guest", models.RoleUser, false},
+	}
+
+	for _, tt := range tests {
+		if got := hasRole(tt.role, tt.required); got != tt.want {
+			t.Errorf("hasRole(%q, %q) = %v, want %v", tt.role, tt.required, got, tt.want)
+		}
+	}
+}
+
+func TestRunChecksRoles(t *testing.T) {
+	withRoles(t, map[int64]string{adminID: models.RoleAdmin, userID: models.RoleUser})
+	b := &Bot{scanners: fakeScanners{}, username: "RattleBot", started: time.Now()}
+
+	tests := []struct {
+		name    string
+		from    int64
+		command string
+		args    []string
+		want    string // Substring of the reply, empty for no reply
+		notWant string
+	}{
+		{"stranger is denied", strangerID, "status", nil, "Not enough rights", ""},
+		{"user reads status", userID, "status", nil, "Rattle status", ""},
+		{"user can't mute", userID, "mute", []string{"api", "1h"}, "Not enough rights", ""},
+		{"user can't change mode", userID, "mode", []string{"whitelist"}, "Not enough rights", ""},
+		{"user reads mode", userID, "mode", nil, "Filtering mode", ""},
+		{"admin lists mutes", adminID, "mute", nil, "Nothing is muted", ""},
+		{"admin rejects bad duration", adminID, "mute", []string{"api", "soon"}, "Invalid duration", ""},
+		{"user help hides admin commands", userID, "help", nil, "/status", "/mute"},
+		{"admin help lists admin commands", adminID, "help", nil, "/mute", ""},
+		{"unknown command is ignored", adminID, "nope", nil, "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reply := b.run(context.Background(), tt.command, tt.args, &User{ID: tt.from})
+			if tt.want == "" && reply != "" {
+				t.Fatalf("reply = %q, want none", reply)
+			}
+			if !strings.Contains(reply, tt.want) {
+				t.Errorf("reply = %q, want it to contain %q", reply, tt.want)
+			}
+			if tt.notWant != "" && strings.Contains(reply, tt.notWant) {
+				t.Errorf("reply = %q, must not contain %q", reply, tt.notWant)
+			}
+		})
+	}
+}
+
+func TestStartPollsAndReplies(t *testing.T) {
+	withRoles(t, map[int64]string{adminID: models.RoleAdmin, userID: models.RoleUser})
+
+	message := func(chatID int64, from int64, text string) *Message {
+		return &Message{MessageID: 1, From: &User{ID: from}, Chat: Chat{ID: chatID, Type: "supergroup"}, Text: text}
+	}
+	topic := message(-101, adminID, "/help@RattleBot")
+	topic.IsTopicMessage, topic.MessageThreadID = true, 5
+
+	api.mu.Lock()
+	api.calls, api.offsets, api.sent = nil, nil, nil
+	api.updates = []Update{
+		{UpdateID: 10, Message: topic},
+		{UpdateID: 11, Message: message(-102, adminID, "/status@OtherBot")},
+		{UpdateID: 12, Message: message(-103, userID, "/mode whitelist")},
+		{UpdateID: 13, Message: message(-104, adminID, "hello")},
+	}
+	api.mu.Unlock()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	if err := Start(ctx, fakeScanners{}); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+
+	for range 2 {
+		select {
+		case <-api.notify:
+		case <-time.After(10 * time.Second):
+			t.Fatal("timed out waiting for replies")
+		}
+	}
+	time.Sleep(200 * time.Millisecond) // Let the bot handle the rest of the updates
+	cancel()
+
+	api.mu.Lock()
+	defer api.mu.Unlock()
+
+	for _, method := range []string{"getMe", "setMyCommands", "deleteWebhook", "getUpdates"} {
+		if !contains(api.calls, method) {
+			t.Errorf("%s wasn't called, calls: %v", method, api.calls)
+		}
+	}
+	if len(api.offsets) < 2 || api.offsets[0] != "0" || api.offsets[1] != "14" {
+		t.Errorf("getUpdates offsets = %v, want 0 then 14", api.offsets)
+	}
+
+	replies := map[string]url.Values{}
+	for _, params := range api.sent {
+		replies[params.Get("chat_id")] = params
+	}
+	if len(replies) != 2 {
+		t.Fatalf("got replies to %d chats, want 2: %v", len(replies), api.sent)
+	}
+
+	help := replies["-101"]
+	if help == nil || !strings.Contains(help.Get("text"), "/mute") || help.Get("message_thread_id") != "5" {
+		t.Errorf("reply to /help = %v, want the admin command list in topic 5", help)
+	}
+	if mode := replies["-103"]; mode == nil || !strings.Contains(mode.Get("text"), "Not enough rights") {
+		t.Errorf("reply to /mode whitelist of a user = %v, want a denial", mode)
+	}
+}
+
+func TestWebhookStopsWhenNotSet(t *testing.T) {
+	// Reserve a free port for the webhook server
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := ln.Addr().String()
+	ln.Close()
+
+	prev := config.Cfg.Bot
+	config.Cfg.Bot = config.Bot{Enabled: true, WebhookURL: "https://rattle.example.com", WebhookListen: addr}
+	api.mu.Lock()
+	api.calls, api.fail = nil, "setWebhook"
+	api.mu.Unlock()
+	t.Cleanup(func() {
+		config.Cfg.Bot = prev
+		api.mu.Lock()
+		api.fail = ""
+		api.mu.Unlock()
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	if err := Start(ctx, fakeScanners{}); err == nil {
+		t.Fatal("Start succeeded while setWebhook failed")
+	}
+
+	// The server is closed, so the address is free again
+	ln, err = net.Listen("tcp", addr)
+	if err != nil {
+		t.Fatalf("webhook server still listens on %s: %v", addr, err)
+	}
+	ln.Close()
+
+	api.mu.Lock()
+	defer api.mu.Unlock()
+	if contains(api.calls, "deleteWebhook") || contains(api.calls, "getUpdates") {
+		t.Errorf("calls = %v, want no long polling in webhook mode", api.calls)
+	}
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,125 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/ilyxenc/rattle/internal/database"
+	"github.com/ilyxenc/rattle/internal/logger"
+	"github.com/ilyxenc/rattle/internal/models"
+	"github.com/ilyxenc/rattle/internal/telegram"
+)
+
+// logsTimeout limits fetching container output for the logs button
+const logsTimeout = 15 * time.Second
+
+// actionRoles are roles required to press alert buttons
+var actionRoles = map[string]string{
+	telegram.ActionMute: models.RoleAdmin,
+	telegram.ActionLogs: models.RoleUser,
+	telegram.ActionAck:  models.RoleUser,
+}
+
+// handleCallback runs the action of a pressed alert button and answers with a short notice
+func (b *Bot) handleCallback(ctx context.Context, q *CallbackQuery) {
+	answer := b.runAction(ctx, q)
+
+	err := telegram.Call(ctx, "answerCallbackQuery", map[string]string{
+		"callback_query_id": q.ID,
+		"text":              answer,
+	}, nil)
+	if err != nil {
+		logger.Log.Warnf("Failed to answer button press of user %d: %v", q.From.ID, err)
+	}
+}
+
+// runAction authorizes the user and runs the action of the button. Returns the notice shown to the user
+func (b *Bot) runAction(ctx context.Context, q *CallbackQuery) string {
+	action, token, ok := telegram.ParseCallback(q.Data)
+	required, known := actionRoles[action]
+	if !ok || !known || q.Message == nil {
+		return "Unknown button"
+	}
+
+	call := caller{TelegramID: strconv.FormatInt(q.From.ID, 10)}
+	call.Role = roleOf(call.TelegramID)
+	if !hasRole(call.Role, required) {
+		return "⛔ Not enough rights"
+	}
+
+	alert, ok := telegram.LookupAlert(token)
+	if !ok {
+		return "⌛ This alert is too old, its buttons don't work anymore"
+	}
+
+	switch action {
+	case telegram.ActionMute:
+		return muteAlert(alert, call)
+	case telegram.ActionLogs:
+		return b.replyLogs(ctx, alert, q.Message)
+	default:
+		return acknowledge(ctx, token, alert, q)
+	}
+}
+
+// muteAlert mutes log events with the fingerprint of the alert for telegram.MuteFor.
+// The mute selects the container of the alert by ID, a name selector would match other containers containing the name
+func muteAlert(alert telegram.Alert, call caller) string {
+	mute := models.Mute{
+		Container:   "id=" + alert.Container.ID,
+		Fingerprint: alert.Fingerprint,
+		Until:       time.Now().Add(telegram.MuteFor),
+		CreatedBy:   call.TelegramID,
+	}
+	if err := database.DB.Create(&mute).Error; err != nil {
+		logger.Log.Errorf("Failed to save mute: %v", err)
+		return "⚠️ Failed to mute"
+	}
+	reloadMutes()
+
+	return fmt.Sprintf("🔇 This alert of %s is muted until %s", alert.Container.Name, mute.Until.Format("15:04"))
+}
+
+// replyLogs sends recent output of the container of the alert next to the alert
+func (b *Bot) replyLogs(ctx context.Context, alert telegram.Alert, msg *Message) string {
+	ctx, cancel := context.WithTimeout(ctx, logsTimeout)
+	defer cancel()
+
+	logs, err := b.scanners.Logs(ctx, alert.Container.ID, telegram.LogLines)
+	if err != nil {
+		logger.Log.Warnf("Failed to get logs of %s: %v", alert.Container.Name, err)
+		return "⚠️ Failed to get logs, the container may be removed"
+	}
+
+	// Sent in the background, the user gets the notice right away
+	go func() {
+		if err := telegram.ReplyLogs(strconv.FormatInt(msg.Chat.ID, 10), msg.threadID(), alert.Container, logs); err != nil {
+			logger.Log.Warnf("Failed to send logs of %s to chat %d: %v", alert.Container.Name, msg.Chat.ID, err)
+		}
+	}()
+	return "📜 Sending logs"
+}
+
+// acknowledge marks the alert as acknowledged by the user
+func acknowledge(ctx context.Context, token string, alert telegram.Alert, q *CallbackQuery) string {
+	if alert.AckedBy != "" {
+		return "Already acknowledged by " + alert.AckedBy
+	}
+
+	by := q.From.FirstName
+	if q.From.Username != "" {
+		by = "@" + q.From.Username
+	}
+
+	acked, err := telegram.Acknowledge(ctx, q.Message.Chat.ID, q.Message.MessageID, token, by)
+	if err != nil {
+		logger.Log.Warnf("Failed to edit acknowledged alert in chat %d: %v", q.Message.Chat.ID, err)
+		return "⚠️ Failed to acknowledge, try again"
+	}
+	if !acked {
+		return "Already acknowledged"
+	}
+	return "✅ Acknowledged"
+}
@@ -0,0 +1,319 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ilyxenc/rattle/internal/config"
+	"github.com/ilyxenc/rattle/internal/database"
+	"github.com/ilyxenc/rattle/internal/logger"
+	"github.com/ilyxenc/rattle/internal/managers"
+	"github.com/ilyxenc/rattle/internal/models"
+	"github.com/ilyxenc/rattle/internal/notify"
+	"github.com/ilyxenc/rattle/internal/telegram"
+)
+
+// maxListLen is the length of command replies with lists, below the message limit
+const maxListLen = 3500
+
+// command is a bot command available to users with `role` or higher
+type command struct {
+	description string
+	role        string // models.RoleUser or models.RoleAdmin
+	run         func(b *Bot, ctx context.Context, args []string, call caller) string
+}
+
+// caller is the user running a command
+type caller struct {
+	TelegramID string
+	Role       string
+}
+
+// commands are available bot commands by name. Filled in init, since /help lists them
+var commands map[string]command
+
+// commandList is the order of commands in /help and the Telegram command menu
+var commandList = []string{"status", "containers", "mute", "unmute", "patterns", "mode", "help"}
+
+func init() {
+	commands = map[string]command{
+		"status":     {"Scanning status", models.RoleUser, cmdStatus},
+		"containers": {"Running containers", models.RoleUser, cmdContainers},
+		"mute":       {"Mute a container: /mute <container> <duration>", models.RoleAdmin, cmdMute},
+		"unmute":     {"Unmute a container: /unmute <container>", models.RoleAdmin, cmdUnmute},
+		"patterns":   {"Log patterns", models.RoleUser, cmdPatterns},
+		"mode":       {"Container filtering mode: /mode [blacklist|whitelist]", models.RoleUser, cmdMode},
+		"help":       {"List of commands", models.RoleUser, cmdHelp},
+		"start":      {"List of commands", models.RoleUser, cmdHelp},
+	}
+}
+
+// run authorizes the user and runs the command. Returns an empty reply for unknown commands
+func (b *Bot) run(ctx context.Context, name string, args []string, from *User) string {
+	cmd, ok := commands[name]
+	if !ok {
+		return ""
+	}
+
+	call := caller{TelegramID: strconv.FormatInt(from.ID, 10)}
+	call.Role = roleOf(call.TelegramID)
+	if !hasRole(call.Role, cmd.role) {
+		return "⛔ Not enough rights"
+	}
+
+	return cmd.run(b, ctx, args, call)
+}
+
+// roleOf looks up the role of a Telegram user, see userRole
+var roleOf = userRole
+
+// userRole returns the role of the Telegram user, or empty if they aren't a Rattle user
+func userRole(telegramID string) string {
+	var role string
+	if err := database.DB.Model(&models.User{}).Where("telegram_id = ?", telegramID).Limit(1).Pluck("role", &role).Error; err != nil {
+		logger.Log.Warnf("Failed to load role of user %s: %v", telegramID, err)
+		return ""
+	}
+	return role
+}
+
+// hasRole reports whether `role` grants access to commands of `required` role. Admins can run every command
+func hasRole(role, required string) bool {
+	switch role {
+	case models.RoleAdmin:
+		return true
+	case models.RoleUser:
+		return required == models.RoleUser
+	default:
+		return false
+	}
+}
+
+func cmdHelp(b *Bot, _ context.Context, _ []string, call caller) string {
+	lines := make([]string, 0, len(commandList))
+	for _, name := range commandList {
+		cmd := commands[name]
+		if hasRole(call.Role, cmd.role) {
+			lines = append(lines, fmt.Sprintf("/%s — %s", name, telegram.Escape(cmd.description)))
+		}
+	}
+	return "🤖 *Rattle commands*\n\n" + strings.Join(lines, "\n")
+}
+
+func cmdStatus(b *Bot, _ context.Context, _ []string, _ caller) string {
+	now := time.Now()
+	queue := telegram.Stats()
+
+	lines := []string{
+		fmt.Sprintf("Environment: `%s`", telegram.Escape(config.Cfg.Env)),
+		fmt.Sprintf("Uptime: %s", telegram.Escape(notify.FormatDuration(now.Sub(b.started).Truncate(time.Second)))),
+		fmt.Sprintf("Scanning: *%d* containers", len(b.scanners.Active())),
+		fmt.Sprintf("Filtering mode: `%s`", telegram.Escape(managers.Mode.Get())),
+		fmt.Sprintf("Muted: *%d*", len(managers.Mutes.Active(now))),
+		fmt.Sprintf("Telegram queue: *%d* messages", queue.Total),
+	}
+	return "📊 *Rattle status*\n\n" + strings.Join(lines, "\n")
+}
+
+func cmdContainers(b *Bot, _ context.Context, _ []string, _ caller) string {
+	running, err := b.scanners.Running()
+	if err != nil {
+		logger.Log.Warnf("Failed to list containers: %v", err)
+		return "⚠️ Failed to list containers"
+	}
+
+	scanned := make(map[string]bool)
+	for _, ci := range b.scanners.Active() {
+		scanned[ci.ID] = true
+	}
+
+	now := time.Now()
+	lines := make([]string, 0, len(running))
+	for _, ci := range running {
+		mark := "⚪️"
+		if scanned[ci.ID] {
+			mark = "🟢"
+		}
+		if managers.Mutes.Muted(ci, "", now) {
+			mark = "🔇"
+		}
+		lines = append(lines, fmt.Sprintf("%s `%s`: %s", mark, ci.ShortID, telegram.Escape(ci.Name)))
+	}
+
+	title := fmt.Sprintf("📦 *%d running containers*", len(running))
+	legend := "\n\n🟢 scanned · ⚪️ not scanned · 🔇 muted"
+	return title + "\n\n" + joinLimited(lines) + legend
+}
+
+func cmdMute(b *Bot, _ context.Context, args []string, call caller) string {
+	if len(args) == 0 {
+		return listMutes()
+	}
+	if len(args) != 2 {
+		return "Usage: `/mute <container> <duration>`, e\\.g\\. `/mute api 30m` or `/mute image=nginx 1d`"
+	}
+
+	d, err := parseDuration(args[1])
+	if err != nil || d <= 0 {
+		return fmt.Sprintf("⚠️ Invalid duration `%s`, use e\\.g\\. `30m`, `2h` or `1d`", telegram.Escape(args[1]))
+	}
+
+	mute := models.Mute{
+		Container: args[0],
+		Until:     time.Now().Add(d),
+		CreatedBy: call.TelegramID,
+	}
+	if err := database.DB.Create(&mute).Error; err != nil {
+		logger.Log.Errorf("Failed to save mute: %v", err)
+		return "⚠️ Failed to mute"
+	}
+	reloadMutes()
+
+	return fmt.Sprintf("🔇 Muted `%s` until %s", telegram.Escape(mute.Container), telegram.Escape(notify.FormatTime(mute.Until)))
+}
+
+func cmdUnmute(b *Bot, _ context.Context, args []string, _ caller) string {
+	if len(args) != 1 {
+		return "Usage: `/unmute <container>`"
+	}
+
+	result := database.DB.Where("container = ? AND until > ?", args[0], time.Now()).Delete(&models.Mute{})
+	if result.Error != nil {
+		logger.Log.Errorf("Failed to delete mute: %v", result.Error)
+		return "⚠️ Failed to unmute"
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Sprintf("`%s` isn't muted", telegram.Escape(args[0]))
+	}
+	reloadMutes()
+
+	return fmt.Sprintf("🔊 Unmuted `%s`", telegram.Escape(args[0]))
+}
+
+// listMutes returns active mutes
+func listMutes() string {
+	mutes := managers.Mutes.Active(time.Now())
+	if len(mutes) == 0 {
+		return "🔊 Nothing is muted"
+	}
+
+	lines := make([]string, 0, len(mutes))
+	for _, m := range mutes {
+		line := fmt.Sprintf("`%s` until %s", telegram.Escape(m.Container), telegram.Escape(notify.FormatTime(m.Until)))
+		if m.Fingerprint != "" {
+			line += " \\(one alert\\)"
+		}
+		lines = append(lines, line)
+	}
+	return "🔇 *Muted*\n\n" + joinLimited(lines)
+}
+
+// reloadMutes applies a changed mute right away instead of on the next watcher poll
+func reloadMutes() {
+	if err := managers.Mutes.Reload(); err != nil {
+		logger.Log.Warnf("Failed to reload mutes: %v", err)
+	}
+}
+
+func cmdPatterns(b *Bot, _ context.Context, _ []string, _ caller) string {
+	var lines []string
+	for _, et := range managers.Logs.KnownEventTypes() {
+		lines = append(lines, fmt.Sprintf("%s *%s*", notify.EventEmoji(et), telegram.Escape(et)))
+		for _, r := range managers.Logs.Include(et) {
+			lines = append(lines, formatPattern(r))
+		}
+	}
+
+	if exclude := managers.Logs.Exclude(); len(exclude) > 0 {
+		lines = append(lines, "🚫 *exclude*")
+		for _, r := range exclude {
+			lines = append(lines, formatPattern(r))
+		}
+	}
+
+	if len(lines) == 0 {
+		return "🔎 No log patterns"
+	}
+	return "🔎 *Log patterns*\n\n" + joinLimited(lines)
+}
+
+// formatPattern returns a pattern with its scope
+func formatPattern(r managers.LogRule) string {
+	line := fmt.Sprintf("• `%s`", telegram.Escape(r.Regex.String()))
+
+	var scope []string
+	if r.Container != "" {
+		scope = append(scope, "container: "+r.Container)
+	}
+	if r.Stream != "" {
+		scope = append(scope, "stream: "+r.Stream)
+	}
+	if r.Field != "" {
+		scope = append(scope, "field: "+r.Field)
+	}
+	if len(scope) > 0 {
+		line += " _" + telegram.Escape(strings.Join(scope, ", ")) + "_"
+	}
+	return line
+}
+
+func cmdMode(b *Bot, _ context.Context, args []string, call caller) string {
+	if len(args) == 0 {
+		return fmt.Sprintf("⚙️ Filtering mode: `%s`", telegram.Escape(managers.Mode.Get()))
+	}
+
+	mode := strings.ToLower(args[0])
+	if mode != models.Blacklist && mode != models.Whitelist {
+		return "Usage: `/mode [blacklist|whitelist]`"
+	}
+	if !hasRole(call.Role, models.RoleAdmin) {
+		return "⛔ Not enough rights"
+	}
+
+	// Update the first (and only) record, or create it
+	var current models.Mode
+	err := database.DB.First(&current).Error
+	if err == nil {
+		err = database.DB.Model(&current).Update("value", mode).Error
+	} else {
+		err = database.DB.Create(&models.Mode{Value: mode}).Error
+	}
+	if err != nil {
+		logger.Log.Errorf("Failed to update filtering mode: %v", err)
+		return "⚠️ Failed to update filtering mode"
+	}
+	if err := managers.Mode.Reload(); err != nil {
+		logger.Log.Warnf("Failed to reload mode: %v", err)
+	}
+
+	return fmt.Sprintf("⚙️ Filtering mode set to `%s`", mode)
+}
+
+// joinLimited joins lines, leaving out those that don't fit into maxListLen
+func joinLimited(lines []string) string {
+	var sb strings.Builder
+	for i, line := range lines {
+		if sb.Len()+len(line) > maxListLen {
+			fmt.Fprintf(&sb, "…and %d more", len(lines)-i)
+			break
+		}
+		sb.WriteString(line)
+		sb.WriteString("\n")
+	}
+	return strings.TrimSuffix(sb.String(), "\n")
+}
+
+// parseDuration parses durations like "30m" or "2h", and days like "1d"
+func parseDuration(s string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil {
+			return 0, err
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+	return time.ParseDuration(s)
+}
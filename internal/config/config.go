@@ -2,6 +2,8 @@ package config
 
 import (
 	"log"
+	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 )
@@ -18,14 +20,65 @@ type Fiber struct {
 	Port int
 }
 
+// Multiline configures grouping of multi-line log events (stack traces) into a single event
+type Multiline struct {
+	Enabled      bool          // Group lines into events; if false, every line is a separate event
+	Presets      []string      // Built-in presets to use: go, python, java, node
+	Indent       bool          // Treat indented lines as a continuation of the previous event
+	MaxLines     int           // Maximum number of lines in a single event
+	FlushTimeout time.Duration // How long to wait for the next line before the pending event is emitted
+}
+
+// Structured configures parsing of JSON and logfmt log lines
+type Structured struct {
+	Enabled  bool     // Parse structured lines and match them by fields instead of the raw line
+	MinLevel string   // Lowest level that produces an event: info, warning, error, critical
+	Fields   []string // Fields shown in notifications next to the message
+}
+
+// Dedup configures suppression of repeated log alerts
+type Dedup struct {
+	Enabled bool          // Send only the first of repeated alerts and a follow-up with the repeat count
+	Window  time.Duration // How long repeats of the same alert are collapsed
+}
+
+// Messages configures Telegram notifications longer than the message limit
+type Messages struct {
+	Overflow        string // "split" into several messages or "truncate" with a "…" marker
+	AttachThreshold int    // Log text longer than this many characters is attached as a .log file, 0 disables
+}
+
+// Bot configures the interactive Telegram bot that answers commands
+type Bot struct {
+	Enabled       bool   // Receive updates and answer commands
+	WebhookURL    string // Public HTTPS URL for Telegram to post updates to, empty for long polling
+	WebhookListen string // Address of the webhook HTTP server, e.g. ":52103"
+	WebhookSecret string // Secret token Telegram sends with webhook requests, random if empty
+}
+
+// Outbox configures durable delivery of notifications
+type Outbox struct {
+	MaxAttempts int           // Attempts per destination before a delivery is marked as failed
+	MaxBackoff  time.Duration // Upper bound of the delay between attempts, which doubles after each failure
+	Retention   time.Duration // How long sent deliveries are kept in the database
+}
+
 // Config holds all environment-based configuration for the application
 type Config struct {
-	BotToken string   // Telegram bot token
-	ChatIDs  []string // Telegram chat IDs to send messages to
-	LogLevel string   // Log level: debug, info, warn, error
-	Env      string   // Application environment: local, dev, prod, etc
-	Postgres Postgres
-	Fiber    Fiber
+	BotToken  string   // Telegram bot token
+	BotAPIURL string   // Telegram Bot API server, e.g. a self-hosted one
+	ChatIDs   []string // Telegram chat IDs to send messages to
+	LogLevel  string   // Log level: debug, info, warn, error
+	Env       string   // Application environment: local, dev, prod, etc
+	Postgres  Postgres
+	Fiber     Fiber
+
+	Multiline  Multiline  // Multi-line event grouping for stack traces
+	Structured Structured // JSON and logfmt log parsing
+	Dedup      Dedup      // Duplicate suppression for log alerts
+	Outbox     Outbox     // Durable notification delivery with retries
+	Messages   Messages   // Long Telegram messages
+	Bot        Bot        // Telegram bot commands
 
 	IncludePatterns map[string][]string // Key = eventType
 	ExcludePatterns []string            // Regex patterns to exclude from log detection
@@ -57,10 +110,11 @@ func Load() {
 
 	// Initialize the global config from required env vars
 	Cfg = &Config{
-		BotToken: getEnv("TELEGRAM_BOT_TOKEN"),
-		ChatIDs:  splitEnv("TELEGRAM_CHAT_IDS"),
-		LogLevel: getEnv("LOG_LEVEL"),
-		Env:      getEnv("APP_ENV"),
+		BotToken:  getEnv("TELEGRAM_BOT_TOKEN"),
+		BotAPIURL: strings.TrimSuffix(getEnvOrDefault("TELEGRAM_API_URL", "https://api.telegram.org"), "/"),
+		ChatIDs:   splitEnv("TELEGRAM_CHAT_IDS"),
+		LogLevel:  getEnv("LOG_LEVEL"),
+		Env:       getEnv("APP_ENV"),
 		Postgres: Postgres{
 			Port:     getEnvAsInt("POSTGRES_PORT"),
 			Host:     getEnv("POSTGRES_HOST"),
@@ -88,5 +142,36 @@ func Load() {
 		Fiber: Fiber{
 			Port: getEnvAsInt("SERVER_PORT"),
 		},
+		Multiline: Multiline{
+			Enabled:      getEnvAsBoolOrDefault("MULTILINE_ENABLED", true),
+			Presets:      splitEnvOrDefault("MULTILINE_PRESETS", []string{"go", "python", "java", "node"}),
+			Indent:       getEnvAsBoolOrDefault("MULTILINE_INDENT", true),
+			MaxLines:     getEnvAsIntOrDefault("MULTILINE_MAX_LINES", 200),
+			FlushTimeout: getEnvAsDurationOrDefault("MULTILINE_FLUSH_TIMEOUT", 500*time.Millisecond),
+		},
+		Dedup: Dedup{
+			Enabled: getEnvAsBoolOrDefault("DEDUP_ENABLED", true),
+			Window:  getEnvAsDurationOrDefault("DEDUP_WINDOW", 5*time.Minute),
+		},
+		Outbox: Outbox{
+			MaxAttempts: getEnvAsIntOrDefault("OUTBOX_MAX_ATTEMPTS", 10),
+			MaxBackoff:  getEnvAsDurationOrDefault("OUTBOX_MAX_BACKOFF", 10*time.Minute),
+			Retention:   getEnvAsDurationOrDefault("OUTBOX_RETENTION", 7*24*time.Hour),
+		},
+		Messages: Messages{
+			Overflow:        getEnvOrDefault("TELEGRAM_MESSAGE_OVERFLOW", "split"),
+			AttachThreshold: getEnvAsIntOrDefault("TELEGRAM_ATTACH_THRESHOLD", 8000),
+		},
+		Bot: Bot{
+			Enabled:       getEnvAsBoolOrDefault("TELEGRAM_BOT_COMMANDS", false),
+			WebhookURL:    getEnvOrDefault("TELEGRAM_WEBHOOK_URL", ""),
+			WebhookListen: getEnvOrDefault("TELEGRAM_WEBHOOK_LISTEN", ":52103"),
+			WebhookSecret: getEnvOrDefault("TELEGRAM_WEBHOOK_SECRET", ""),
+		},
+		Structured: Structured{
+			Enabled:  getEnvAsBoolOrDefault("STRUCTURED_LOGS_ENABLED", false),
+			MinLevel: getEnvOrDefault("STRUCTURED_LOGS_MIN_LEVEL", "warning"),
+			Fields:   splitEnvOrDefault("STRUCTURED_LOGS_FIELDS", []string{"status", "error", "err", "caller", "logger", "trace_id"}),
+		},
 	}
 }
@@ -5,6 +5,7 @@ import (
 	"os"
 	"strconv"
 	"strings"
+	"time"
 )
 
 // getEnv returns the value of an environment variable or panics if it's not set
@@ -44,3 +45,68 @@ func getEnvAsInt(key string) int {
 
 	return value
 }
+
+// getEnvOrDefault returns the value of an environment variable or the fallback if it's not set
+func getEnvOrDefault(key, fallback string) string {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback
+	}
+
+	return value
+}
+
+// splitEnvOrDefault parses comma-separated string into trimmed slice of strings or returns the fallback if it's not set
+func splitEnvOrDefault(key string, fallback []string) []string {
+	parts := splitEnv(key)
+	if len(parts) == 0 {
+		return fallback
+	}
+
+	return parts
+}
+
+// getEnvAsIntOrDefault returns the value of an environment variable as int or the fallback if it's not set
+func getEnvAsIntOrDefault(key string, fallback int) int {
+	valueStr := os.Getenv(key)
+	if valueStr == "" {
+		return fallback
+	}
+
+	value, err := strconv.Atoi(valueStr)
+	if err != nil {
+		log.Panicf("Environment variable is not int: %v", key)
+	}
+
+	return value
+}
+
+// getEnvAsBoolOrDefault returns the value of an environment variable as bool or the fallback if it's not set
+func getEnvAsBoolOrDefault(key string, fallback bool) bool {
+	valueStr := os.Getenv(key)
+	if valueStr == "" {
+		return fallback
+	}
+
+	value, err := strconv.ParseBool(valueStr)
+	if err != nil {
+		log.Panicf("Environment variable is not bool: %v", key)
+	}
+
+	return value
+}
+
+// getEnvAsDurationOrDefault returns the value of an environment variable as duration (e.g. "500ms", "5m") or the fallback if it's not set
+func getEnvAsDurationOrDefault(key string, fallback time.Duration) time.Duration {
+	valueStr := os.Getenv(key)
+	if valueStr == "" {
+		return fallback
+	}
+
+	value, err := time.ParseDuration(valueStr)
+	if err != nil {
+		log.Panicf("Environment variable is not duration: %v", key)
+	}
+
+	return value
+}
@@ -43,7 +43,7 @@ func Connect() error {
 
 func AutoMigrate() error {
 	return DB.AutoMigrate(
-		&models.User{}, &models.LogExclusion{}, &models.Chat{}, &models.Container{}, &models.Mode{},
+		&models.User{}, &models.LogExclusion{}, &models.Chat{}, &models.Container{}, &models.Mode{}, &models.LogCursor{}, &models.ThresholdRule{}, &models.AbsenceRule{}, &models.IdleRule{}, &models.Channel{}, &models.Delivery{}, &models.Route{}, &models.Mute{},
 	)
 }
 
@@ -0,0 +1,292 @@
+package dispatcher
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/ilyxenc/rattle/internal/logger"
+	"github.com/ilyxenc/rattle/internal/managers"
+	"github.com/ilyxenc/rattle/internal/models"
+	"github.com/ilyxenc/rattle/internal/notify"
+	"github.com/ilyxenc/rattle/internal/notify/discord"
+	"github.com/ilyxenc/rattle/internal/notify/email"
+	"github.com/ilyxenc/rattle/internal/notify/gotify"
+	"github.com/ilyxenc/rattle/internal/notify/matrix"
+	"github.com/ilyxenc/rattle/internal/notify/ntfy"
+	"github.com/ilyxenc/rattle/internal/notify/pagerduty"
+	"github.com/ilyxenc/rattle/internal/notify/slack"
+	"github.com/ilyxenc/rattle/internal/notify/webhook"
+	"github.com/ilyxenc/rattle/internal/telegram"
+)
+
+// factories create notifiers for channels stored in DB, by channel type
+var factories = map[string]func(models.Channel) (notify.Notifier, error){
+	models.ChannelTelegram:  telegram.NewChannelNotifier,
+	models.ChannelSlack:     slack.NewChannelNotifier,
+	models.ChannelDiscord:   discord.NewChannelNotifier,
+	models.ChannelEmail:     email.NewChannelNotifier,
+	models.ChannelWebhook:   webhook.NewChannelNotifier,
+	models.ChannelPagerDuty: pagerduty.NewChannelNotifier,
+	models.ChannelMatrix:    matrix.NewChannelNotifier,
+	models.ChannelNtfy:      ntfy.NewChannelNotifier,
+	models.ChannelGotify:    gotify.NewChannelNotifier,
+}
+
+// Dispatcher fans notifications out to all configured destinations through the outbox
+type Dispatcher struct {
+	mu        sync.RWMutex
+	notifiers []notify.Notifier
+	byName    map[string]notify.Notifier // Key = notifier name, used by the outbox worker
+	channels  map[uint]channelNotifier   // Key = channel ID, kept across reloads while the channel is unchanged
+	busy      map[string]bool            // Destinations being delivered by the outbox worker
+	inflight  sync.WaitGroup             // Outbox deliveries in progress
+
+	wake   chan struct{}      // Signals the outbox worker that new deliveries are queued
+	direct chan directSend    // Notifications sent without the outbox while it can't be written
+	cancel context.CancelFunc // Stops the outbox worker
+	done   chan struct{}      // Closed when the outbox worker has stopped
+}
+
+// channelNotifier is the notifier of a notification channel and the settings it was built from
+type channelNotifier struct {
+	version  string // Type, name and config of the channel
+	notifier notify.Notifier
+}
+
+// directQueueSize is the number of notifications waiting to be sent directly, newer ones are dropped when it's full
+const directQueueSize = 100
+
+// directSend is a notification sent without the outbox
+type directSend struct {
+	n         notify.Notification
+	notifiers []notify.Notifier
+}
+
+// dispatcher is the global dispatcher instance
+var dispatcher = &Dispatcher{
+	busy:   make(map[string]bool),
+	wake:   make(chan struct{}, 1),
+	direct: make(chan directSend, directQueueSize),
+}
+
+// Init builds notifiers for configured destinations, rebuilds them when chats or channels change
+// and starts delivering the outbox. Must be called after managers.Init and telegram.Init
+func Init() {
+	Reload()
+
+	managers.AddWatcher("chats", []string{"updated_at", "deleted_at"}, Reload)
+	managers.AddWatcher("channels", []string{"updated_at", "deleted_at"}, Reload)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	dispatcher.cancel = cancel
+	dispatcher.done = make(chan struct{})
+	go dispatcher.runOutbox(ctx)
+	go dispatcher.runDirect()
+}
+
+// Reload rebuilds notifiers from Telegram chats and notification channels.
+// Channels with invalid config or a name already taken by another destination are skipped
+func Reload() {
+	chats := managers.Chats.List()
+	channels := managers.Channels.All()
+
+	notifiers := make([]notify.Notifier, 0, len(chats)+len(channels))
+	byName := make(map[string]notify.Notifier, len(chats)+len(channels))
+	add := func(n notify.Notifier) {
+		if _, ok := byName[n.Name()]; ok {
+			logger.Log.Warnf("Skipping duplicate destination %s", n.Name())
+			return
+		}
+		notifiers = append(notifiers, n)
+		byName[n.Name()] = n
+	}
+
+	for _, chat := range chats {
+		add(telegram.NewChatNotifier(chat))
+	}
+
+	dispatcher.mu.RLock()
+	prev := dispatcher.channels
+	dispatcher.mu.RUnlock()
+
+	built := buildChannels(channels, prev)
+	for _, ch := range channels {
+		if cn, ok := built[ch.ID]; ok {
+			add(cn.notifier)
+		}
+	}
+
+	dispatcher.mu.Lock()
+	old := dispatcher.notifiers
+	dispatcher.notifiers = notifiers
+	dispatcher.byName = byName
+	dispatcher.channels = built
+	dispatcher.mu.Unlock()
+
+	// Replaced notifiers may hold connections or queued messages, notifiers of unchanged channels are still in use
+	go closeNotifiers(unused(old, notifiers))
+
+	logger.Log.Debugf("Dispatcher loaded %d notifiers", len(notifiers))
+}
+
+// Close stops the outbox worker, delivers what is due (e.g. the shutdown notification),
+// then flushes and releases all notifiers. Called on shutdown after the last notification
+func Close() {
+	if dispatcher.cancel != nil {
+		dispatcher.cancel()
+		<-dispatcher.done
+	}
+	dispatcher.deliverDue(time.Now())
+	dispatcher.waitDeliveries()
+	dispatcher.flushDirect()
+
+	dispatcher.mu.Lock()
+	old := dispatcher.notifiers
+	dispatcher.notifiers = nil
+	dispatcher.byName = nil
+	dispatcher.channels = nil
+	dispatcher.mu.Unlock()
+
+	closeNotifiers(old)
+}
+
+// buildChannels creates notifiers for the channels. Notifiers of channels that haven't changed since `prev` are reused.
+// Channels with invalid config are skipped
+func buildChannels(channels []models.Channel, prev map[uint]channelNotifier) map[uint]channelNotifier {
+	built := make(map[uint]channelNotifier, len(channels))
+	for _, ch := range channels {
+		version := channelVersion(ch)
+		if cn, ok := prev[ch.ID]; ok && cn.version == version {
+			built[ch.ID] = cn
+			continue
+		}
+
+		n, err := NewNotifier(ch)
+		if err != nil {
+			logger.Log.Warnf("Skipping notification channel %s: %v", ch.Name, err)
+			continue
+		}
+		built[ch.ID] = channelNotifier{version: version, notifier: n}
+	}
+	return built
+}
+
+// channelVersion identifies the settings of the channel its notifier is built from
+func channelVersion(ch models.Channel) string {
+	config, _ := ch.Config.Value()
+	return fmt.Sprintf("%s|%s|%v", ch.Type, ch.Name, config)
+}
+
+// unused returns notifiers of `old` that aren't in `current`
+func unused(old, current []notify.Notifier) []notify.Notifier {
+	kept := make(map[notify.Notifier]bool, len(current))
+	for _, nt := range current {
+		kept[nt] = true
+	}
+
+	var stale []notify.Notifier
+	for _, nt := range old {
+		if !kept[nt] {
+			stale = append(stale, nt)
+		}
+	}
+	return stale
+}
+
+// closeNotifiers closes notifiers that hold resources or queued messages
+func closeNotifiers(notifiers []notify.Notifier) {
+	for _, nt := range notifiers {
+		c, ok := nt.(io.Closer)
+		if !ok {
+			continue
+		}
+		if err := c.Close(); err != nil {
+			logger.Log.Warnf("Failed to close notifier %s: %v", nt.Name(), err)
+		}
+	}
+}
+
+// NewNotifier creates a notifier for the channel. Returns an error if the type is unknown or the config is invalid
+func NewNotifier(ch models.Channel) (notify.Notifier, error) {
+	factory, ok := factories[ch.Type]
+	if !ok {
+		return nil, fmt.Errorf("unknown channel type: %s", ch.Type)
+	}
+	return factory(ch)
+}
+
+// Notify queues the notification for all destinations. It's delivered by the outbox worker
+func Notify(n notify.Notification) {
+	dispatcher.Notify(n)
+}
+
+// Notify writes a delivery per destination the notification is routed to into the outbox and wakes up the worker.
+// Notifications of muted containers are dropped.
+// If the outbox can't be written, the notification is queued in memory and sent in the background.
+// Never blocks on sending, it's called from scanner goroutines
+func (d *Dispatcher) Notify(n notify.Notification) {
+	if notify.HasContainer(n) && managers.Mutes.Muted(n.Container, n.Fingerprint, time.Now()) {
+		logger.Log.Debugf("Dropping muted %s notification of %s", n.Type, n.Container.Name)
+		return
+	}
+
+	d.mu.RLock()
+	all := d.notifiers
+	d.mu.RUnlock()
+
+	notifiers := routedNotifiers(all, n)
+	if len(notifiers) == 0 {
+		return
+	}
+
+	// Keep the event time, the notification may be delivered much later
+	if n.Time.IsZero() {
+		n.Time = time.Now()
+	}
+
+	if err := enqueue(n, notifiers); err != nil {
+		select {
+		case d.direct <- directSend{n: n, notifiers: notifiers}:
+			logger.Log.Errorf("Failed to queue %s notification, sending directly: %v", n.Type, err)
+		default:
+			logger.Log.Errorf("Failed to queue %s notification, dropping it as too many are waiting to be sent directly: %v", n.Type, err)
+		}
+		return
+	}
+
+	select {
+	case d.wake <- struct{}{}:
+	default: // Worker is already signalled
+	}
+}
+
+// runDirect sends notifications that couldn't be written into the outbox, one at a time
+func (d *Dispatcher) runDirect() {
+	for ds := range d.direct {
+		sendDirect(ds)
+	}
+}
+
+// flushDirect sends notifications still waiting to be sent directly. Called on shutdown
+func (d *Dispatcher) flushDirect() {
+	for {
+		select {
+		case ds := <-d.direct:
+			sendDirect(ds)
+		default:
+			return
+		}
+	}
+}
+
+// sendDirect sends the notification to each of its destinations once, without retries
+func sendDirect(ds directSend) {
+	for _, nt := range ds.notifiers {
+		if err := nt.Send(ds.n); err != nil {
+			logger.Log.Errorf("Failed to send %s notification to %s: %v", ds.n.Type, nt.Name(), err)
+		}
+	}
+}
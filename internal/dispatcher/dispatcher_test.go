@@ -0,0 +1,60 @@
+package dispatcher
+
+import (
+	"os"
+	"testing"
+
+	"github.com/ilyxenc/rattle/internal/logger"
+	"github.com/ilyxenc/rattle/internal/models"
+	"github.com/ilyxenc/rattle/internal/notify"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+func TestMain(m *testing.M) {
+	logger.Log = zap.NewNop().Sugar()
+	os.Exit(m.Run())
+}
+
+func emailChannel(id uint, to string) models.Channel {
+	return models.Channel{
+		Model: gorm.Model{ID: id},
+		Name:  "ops",
+		Type:  models.ChannelEmail,
+		Config: models.ChannelConfig{
+			"host":   "localhost",
+			"from":   "rattle@example.com",
+			"to":     []any{to},
+			"digest": "15m",
+		},
+		Enabled: true,
+	}
+}
+
+func TestBuildChannelsReusesUnchanged(t *testing.T) {
+	first := buildChannels([]models.Channel{emailChannel(1, "a@example.com"), emailChannel(2, "b@example.com")}, nil)
+	if len(first) != 2 {
+		t.Fatalf("built %d notifiers, want 2", len(first))
+	}
+
+	// Channel 1 is unchanged, channel 2 got a new recipient, channel 3 has an invalid config
+	invalid := emailChannel(3, "not an address")
+	second := buildChannels([]models.Channel{emailChannel(1, "a@example.com"), emailChannel(2, "c@example.com"), invalid}, first)
+
+	if second[1].notifier != first[1].notifier {
+		t.Error("notifier of an unchanged channel was rebuilt")
+	}
+	if second[2].notifier == first[2].notifier {
+		t.Error("notifier of a changed channel was reused")
+	}
+	if _, ok := second[3]; ok {
+		t.Error("channel with invalid config got a notifier")
+	}
+
+	old := []notify.Notifier{first[1].notifier, first[2].notifier}
+	current := []notify.Notifier{second[1].notifier, second[2].notifier}
+	stale := unused(old, current)
+	if len(stale) != 1 || stale[0] != first[2].notifier {
+		t.Errorf("unused = %v, want only the replaced notifier of channel 2", stale)
+	}
+}
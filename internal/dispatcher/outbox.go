@@ -0,0 +1,351 @@
+package dispatcher
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ilyxenc/rattle/internal/config"
+	"github.com/ilyxenc/rattle/internal/database"
+	"github.com/ilyxenc/rattle/internal/logger"
+	"github.com/ilyxenc/rattle/internal/models"
+	"github.com/ilyxenc/rattle/internal/notify"
+)
+
+const (
+	outboxPollInterval  = 5 * time.Second // How often the outbox is checked for due deliveries
+	outboxPurgeInterval = time.Hour       // How often old sent deliveries are removed
+	outboxBatchSize     = 100             // Deliveries loaded per query
+	outboxBaseBackoff   = 5 * time.Second // Delay after the first failed attempt
+)
+
+// errNoDestination is recorded for deliveries whose chat or channel was removed or disabled
+var errNoDestination = errors.New("destination is not configured")
+
+// enqueue writes a pending delivery of the notification for every notifier
+func enqueue(n notify.Notification, notifiers []notify.Notifier) error {
+	payload, err := json.Marshal(n)
+	if err != nil {
+		return fmt.Errorf("failed to encode notification: %w", err)
+	}
+
+	now := time.Now()
+	deliveries := make([]models.Delivery, 0, len(notifiers))
+	for _, nt := range notifiers {
+		dl := models.Delivery{
+			Destination:   nt.Name(),
+			Type:          string(n.Type),
+			Payload:       payload,
+			Status:        models.DeliveryPending,
+			NextAttemptAt: now,
+		}
+
+		// Notifications bundled into a digest wait for its period outside of the ordered queue
+		if dg, ok := nt.(notify.Digester); ok {
+			if period := dg.DigestPeriod(n); period > 0 {
+				dl.Status = models.DeliveryDigest
+				dl.NextAttemptAt = now.Add(period)
+			}
+		}
+
+		deliveries = append(deliveries, dl)
+	}
+
+	return database.DB.Create(&deliveries).Error
+}
+
+// runOutbox delivers due deliveries when woken up or polled, until the context is cancelled
+func (d *Dispatcher) runOutbox(ctx context.Context) {
+	defer close(d.done)
+
+	poll := time.NewTicker(outboxPollInterval)
+	defer poll.Stop()
+	purge := time.NewTicker(outboxPurgeInterval)
+	defer purge.Stop()
+
+	// Deliveries left pending by the previous run
+	d.deliverDue(time.Now())
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-d.wake:
+			d.deliverDue(time.Now())
+		case now := <-poll.C:
+			d.deliverDue(now)
+		case now := <-purge.C:
+			purgeSent(now)
+		}
+	}
+}
+
+// deliverDue starts delivering every destination whose oldest pending delivery or a digest is due and that isn't being delivered already.
+// Destinations are served in parallel, so a slow or rate-limited one doesn't hold up the others
+func (d *Dispatcher) deliverDue(now time.Time) {
+	oldest := database.DB.Model(&models.Delivery{}).
+		Select("MIN(id)").
+		Where("status = ?", models.DeliveryPending).
+		Group("destination")
+
+	var destinations []string
+	err := database.DB.Model(&models.Delivery{}).
+		Where("id IN (?) AND next_attempt_at <= ?", oldest, now).
+		Pluck("destination", &destinations).Error
+	if err != nil {
+		logger.Log.Errorf("Failed to load outbox: %v", err)
+		return
+	}
+
+	var digests []string
+	err = database.DB.Model(&models.Delivery{}).
+		Distinct("destination").
+		Where("status = ? AND next_attempt_at <= ?", models.DeliveryDigest, now).
+		Pluck("destination", &digests).Error
+	if err != nil {
+		logger.Log.Errorf("Failed to load outbox digests: %v", err)
+		return
+	}
+	destinations = append(destinations, digests...)
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	for _, name := range destinations {
+		if d.busy[name] {
+			continue
+		}
+		d.busy[name] = true
+		d.inflight.Add(1)
+		go d.drain(name, d.byName[name])
+	}
+}
+
+// drain sends pending deliveries of the destination in the order they were queued.
+// Stops at the first failure or at a delivery that isn't due yet: the destination is likely down,
+// so the rest of its queue waits behind the failed delivery and backs off with it
+func (d *Dispatcher) drain(name string, nt notify.Notifier) {
+	defer func() {
+		d.mu.Lock()
+		delete(d.busy, name)
+		d.mu.Unlock()
+		d.inflight.Done()
+	}()
+
+	sendDigest(name, nt)
+
+	var lastID uint
+	for {
+		var pending []models.Delivery
+		err := database.DB.
+			Where("destination = ? AND status = ? AND id > ?", name, models.DeliveryPending, lastID).
+			Order("id").
+			Limit(outboxBatchSize).
+			Find(&pending).Error
+		if err != nil {
+			logger.Log.Errorf("Failed to load outbox of %s: %v", name, err)
+			return
+		}
+
+		for i := range pending {
+			dl := &pending[i]
+			if dl.NextAttemptAt.After(time.Now()) {
+				return
+			}
+			// A delivery that was given up on no longer holds up the queue
+			if !attempt(nt, dl) && dl.Status == models.DeliveryPending {
+				return
+			}
+		}
+
+		if len(pending) < outboxBatchSize {
+			return
+		}
+		lastID = pending[len(pending)-1].ID
+	}
+}
+
+// waitDeliveries waits until all started deliveries are done
+func (d *Dispatcher) waitDeliveries() {
+	d.inflight.Wait()
+}
+
+// attempt sends the delivery with the notifier and records the result. Returns false if the send failed
+func attempt(nt notify.Notifier, dl *models.Delivery) bool {
+	dl.Attempts++
+
+	var n notify.Notification
+	err := json.Unmarshal(dl.Payload, &n)
+	switch {
+	case err != nil:
+		err = fmt.Errorf("failed to decode notification: %w", err)
+		dl.Attempts = config.Cfg.Outbox.MaxAttempts // Retrying won't help
+	case nt == nil:
+		err = errNoDestination
+		dl.Attempts = config.Cfg.Outbox.MaxAttempts
+	default:
+		err = send(nt, dl, n)
+	}
+
+	now := time.Now()
+	var rae *notify.RetryAfterError
+	switch {
+	case err == nil:
+		dl.Status = models.DeliverySent
+		dl.LastError = ""
+		dl.SentAt = &now
+	case errors.As(err, &rae):
+		// Rate limits aren't failures of the destination, retry exactly when it's allowed
+		dl.Attempts--
+		dl.LastError = err.Error()
+		dl.NextAttemptAt = now.Add(rae.After)
+		logger.Log.Warnf("%s notification to %s is rate limited, retrying at %s", dl.Type, dl.Destination, dl.NextAttemptAt.Format(time.TimeOnly))
+	default:
+		dl.LastError = err.Error()
+		if dl.Attempts >= config.Cfg.Outbox.MaxAttempts {
+			dl.Status = models.DeliveryFailed
+			logger.Log.Errorf("Giving up on %s notification to %s after %d attempts: %v", dl.Type, dl.Destination, dl.Attempts, err)
+		} else {
+			dl.NextAttemptAt = now.Add(backoff(dl.Attempts))
+			logger.Log.Warnf("Failed to send %s notification to %s (attempt %d), retrying at %s: %v",
+				dl.Type, dl.Destination, dl.Attempts, dl.NextAttemptAt.Format(time.TimeOnly), err)
+		}
+	}
+
+	if err := database.DB.Model(dl).
+		Select("status", "attempts", "last_error", "sent_parts", "next_attempt_at", "sent_at").
+		Updates(dl).Error; err != nil {
+		logger.Log.Errorf("Failed to save delivery %d: %v", dl.ID, err)
+	}
+
+	return err == nil
+}
+
+// sendDigest sends the digest deliveries of the destination as one message once the oldest of them is due, and records the result.
+// Deliveries of a destination that no longer bundles notifications (e.g. removed or reconfigured) are queued as usual
+func sendDigest(name string, nt notify.Notifier) {
+	var batch []models.Delivery
+	err := database.DB.
+		Where("destination = ? AND status = ?", name, models.DeliveryDigest).
+		Order("id").
+		Limit(outboxBatchSize).
+		Find(&batch).Error
+	if err != nil {
+		logger.Log.Errorf("Failed to load digest of %s: %v", name, err)
+		return
+	}
+
+	now := time.Now()
+	if len(batch) == 0 || batch[0].NextAttemptAt.After(now) {
+		return
+	}
+
+	dg, ok := nt.(notify.Digester)
+	if !ok {
+		ids := make([]uint, 0, len(batch))
+		for _, dl := range batch {
+			ids = append(ids, dl.ID)
+		}
+		err := database.DB.Model(&models.Delivery{}).
+			Where("id IN ?", ids).
+			Updates(map[string]any{"status": models.DeliveryPending, "next_attempt_at": now}).Error
+		if err != nil {
+			logger.Log.Errorf("Failed to queue digest of %s: %v", name, err)
+		}
+		return
+	}
+
+	attempts := 0
+	ids := make([]uint, 0, len(batch))
+	events := make([]notify.Notification, 0, len(batch))
+	for i := range batch {
+		dl := &batch[i]
+
+		var n notify.Notification
+		if err := json.Unmarshal(dl.Payload, &n); err != nil {
+			// Fails alone, retrying won't help
+			dl.Attempts = config.Cfg.Outbox.MaxAttempts
+			dl.Status = models.DeliveryFailed
+			dl.LastError = fmt.Sprintf("failed to decode notification: %v", err)
+			if err := database.DB.Model(dl).Select("status", "attempts", "last_error").Updates(dl).Error; err != nil {
+				logger.Log.Errorf("Failed to save delivery %d: %v", dl.ID, err)
+			}
+			continue
+		}
+
+		ids = append(ids, dl.ID)
+		events = append(events, n)
+		attempts = max(attempts, dl.Attempts+1)
+	}
+	if len(events) == 0 {
+		return
+	}
+
+	updates := map[string]any{"attempts": attempts}
+	err = dg.SendDigest(events)
+	var rae *notify.RetryAfterError
+	switch {
+	case err == nil:
+		updates["status"] = models.DeliverySent
+		updates["last_error"] = ""
+		updates["sent_at"] = now
+	case errors.As(err, &rae):
+		updates["attempts"] = attempts - 1 // Rate limits aren't failures of the destination
+		updates["last_error"] = err.Error()
+		updates["next_attempt_at"] = now.Add(rae.After)
+		logger.Log.Warnf("Digest of %d notifications to %s is rate limited, retrying in %s", len(events), name, rae.After)
+	default:
+		updates["last_error"] = err.Error()
+		if attempts >= config.Cfg.Outbox.MaxAttempts {
+			updates["status"] = models.DeliveryFailed
+			logger.Log.Errorf("Giving up on digest of %d notifications to %s after %d attempts: %v", len(events), name, attempts, err)
+		} else {
+			next := now.Add(backoff(attempts))
+			updates["next_attempt_at"] = next
+			logger.Log.Warnf("Failed to send digest of %d notifications to %s (attempt %d), retrying at %s: %v",
+				len(events), name, attempts, next.Format(time.TimeOnly), err)
+		}
+	}
+
+	if err := database.DB.Model(&models.Delivery{}).Where("id IN ?", ids).Updates(updates).Error; err != nil {
+		logger.Log.Errorf("Failed to save digest of %s: %v", name, err)
+	}
+}
+
+// send sends the notification of the delivery. Notifiers sending several messages resume after the parts sent by earlier attempts
+func send(nt notify.Notifier, dl *models.Delivery, n notify.Notification) error {
+	n.Delivery = dl.ID
+	ps, ok := nt.(notify.PartSender)
+	if !ok {
+		return nt.Send(n)
+	}
+
+	sent, err := ps.SendParts(n, dl.SentParts)
+	dl.SentParts = sent
+	return err
+}
+
+// backoff returns the delay before the next attempt: doubled after each failure, capped by config
+func backoff(attempts int) time.Duration {
+	delay := outboxBaseBackoff
+	for i := 1; i < attempts && delay < config.Cfg.Outbox.MaxBackoff; i++ {
+		delay *= 2
+	}
+	return min(delay, config.Cfg.Outbox.MaxBackoff)
+}
+
+// purgeSent removes sent deliveries older than the retention period
+func purgeSent(now time.Time) {
+	result := database.DB.Unscoped().
+		Where("status = ? AND sent_at < ?", models.DeliverySent, now.Add(-config.Cfg.Outbox.Retention)).
+		Delete(&models.Delivery{})
+	if result.Error != nil {
+		logger.Log.Warnf("Failed to purge sent deliveries: %v", result.Error)
+		return
+	}
+	if result.RowsAffected > 0 {
+		logger.Log.Debugf("Purged %d sent deliveries", result.RowsAffected)
+	}
+}
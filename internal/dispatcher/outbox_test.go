@@ -0,0 +1,56 @@
+package dispatcher
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/ilyxenc/rattle/internal/models"
+	"github.com/ilyxenc/rattle/internal/notify"
+)
+
+// partNotifier sends notifications as `parts` messages and fails the part `failAt` once
+type partNotifier struct {
+	parts  int
+	failAt int
+	sent   []int // Parts sent, in order
+	seen   uint  // Delivery of the last notification
+}
+
+func (p *partNotifier) Name() string { return "parts" }
+
+func (p *partNotifier) Send(n notify.Notification) error {
+	_, err := p.SendParts(n, 0)
+	return err
+}
+
+func (p *partNotifier) SendParts(n notify.Notification, from int) (int, error) {
+	p.seen = n.Delivery
+	for i := from; i < p.parts; i++ {
+		if i == p.failAt {
+			p.failAt = -1
+			return i, errors.New("part failed")
+		}
+		p.sent = append(p.sent, i)
+	}
+	return p.parts, nil
+}
+
+func TestSendResumesParts(t *testing.T) {
+	nt := &partNotifier{parts: 3, failAt: 1}
+	dl := &models.Delivery{}
+	dl.ID = 7
+	n := notify.Notification{Type: notify.NotificationLogEvent}
+
+	if err := send(nt, dl, n); err == nil || dl.SentParts != 1 {
+		t.Fatalf("first attempt: err = %v, sent parts = %d, want an error after 1 part", err, dl.SentParts)
+	}
+	if err := send(nt, dl, n); err != nil || dl.SentParts != 3 {
+		t.Fatalf("retry: err = %v, sent parts = %d, want all 3", err, dl.SentParts)
+	}
+	if len(nt.sent) != 3 || nt.sent[0] != 0 || nt.sent[1] != 1 || nt.sent[2] != 2 {
+		t.Errorf("sent parts %v, want each part once", nt.sent)
+	}
+	if nt.seen != dl.ID {
+		t.Errorf("notification delivery = %d, want %d", nt.seen, dl.ID)
+	}
+}
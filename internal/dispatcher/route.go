@@ -0,0 +1,62 @@
+package dispatcher
+
+import (
+	"github.com/ilyxenc/rattle/internal/docker"
+	"github.com/ilyxenc/rattle/internal/managers"
+	"github.com/ilyxenc/rattle/internal/models"
+	"github.com/ilyxenc/rattle/internal/notify"
+)
+
+// routedNotifiers returns the notifiers the notification is routed to
+func routedNotifiers(all []notify.Notifier, n notify.Notification) []notify.Notifier {
+	notifiers := make([]notify.Notifier, 0, len(all))
+	for _, nt := range all {
+		if routed(nt.Name(), n) {
+			notifiers = append(notifiers, nt)
+		}
+	}
+	return notifiers
+}
+
+// routed reports whether the notification goes to the destination: it has no routes or one of them matches
+func routed(destination string, n notify.Notification) bool {
+	routes := managers.Routes.For(destination)
+	if len(routes) == 0 {
+		return true
+	}
+
+	for _, r := range routes {
+		if matchRoute(r, n) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchRoute checks the notification against every condition of the route.
+// Non-log notifications are matched by their closest event type, as for push priorities.
+// Container conditions never match notifications without a container (e.g. Rattle startup)
+func matchRoute(r models.Route, n notify.Notification) bool {
+	severity := notify.Severity(n)
+
+	if r.NotificationType != "" && r.NotificationType != string(n.Type) {
+		return false
+	}
+	if r.EventType != "" && r.EventType != severity {
+		return false
+	}
+	if r.MinSeverity != "" && models.EventTypeSeverity[severity] < models.EventTypeSeverity[r.MinSeverity] {
+		return false
+	}
+
+	if r.Container == "" && r.Project == "" {
+		return true
+	}
+	if !notify.HasContainer(n) {
+		return false
+	}
+	if r.Project != "" && !docker.MatchSelector(n.Container, "project="+r.Project) {
+		return false
+	}
+	return docker.MatchSelector(n.Container, r.Container)
+}
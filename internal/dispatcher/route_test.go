@@ -0,0 +1,129 @@
+package dispatcher
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/ilyxenc/rattle/internal/docker"
+	"github.com/ilyxenc/rattle/internal/managers"
+	"github.com/ilyxenc/rattle/internal/models"
+	"github.com/ilyxenc/rattle/internal/notify"
+)
+
+var (
+	payments = docker.ContainerInfo{ID: "p1", Name: "payments-api", Image: "payments:2", Labels: map[string]string{"com.docker.compose.project": "payments"}}
+	web      = docker.ContainerInfo{ID: "w1", Name: "web", Image: "nginx:1.27"}
+)
+
+func logEvent(c docker.ContainerInfo, eventType string) notify.Notification {
+	return notify.Notification{Type: notify.NotificationLogEvent, EventType: eventType, Container: c}
+}
+
+func TestMatchRoute(t *testing.T) {
+	tests := []struct {
+		name  string
+		route models.Route
+		n     notify.Notification
+		want  bool
+	}{
+		{"empty route matches everything", models.Route{}, notify.Notification{Type: notify.NotificationStartedRattle}, true},
+		{"event type", models.Route{EventType: models.EventTypeError}, logEvent(web, models.EventTypeError), true},
+		{"other event type", models.Route{EventType: models.EventTypeError}, logEvent(web, models.EventTypeWarning), false},
+		{"min severity below", models.Route{MinSeverity: models.EventTypeError}, logEvent(web, models.EventTypeWarning), false},
+		{"min severity equal", models.Route{MinSeverity: models.EventTypeError}, logEvent(web, models.EventTypeError), true},
+		{"min severity above", models.Route{MinSeverity: models.EventTypeError}, logEvent(web, models.EventTypeCritical), true},
+		{"non-log notification by its severity", models.Route{MinSeverity: models.EventTypeError}, notify.Notification{Type: notify.NotificationContainerStopWithError, Container: web}, true},
+		{"container start is below warning", models.Route{MinSeverity: models.EventTypeWarning}, notify.Notification{Type: notify.NotificationContainerStart, Container: web}, false},
+		{"notification type", models.Route{NotificationType: "container_start"}, notify.Notification{Type: notify.NotificationContainerStart, Container: web}, true},
+		{"other notification type", models.Route{NotificationType: "container_start"}, logEvent(web, models.EventTypeError), false},
+		{"container name", models.Route{Container: "payments"}, logEvent(payments, models.EventTypeError), true},
+		{"other container name", models.Route{Container: "payments"}, logEvent(web, models.EventTypeError), false},
+		{"container image", models.Route{Container: "image=nginx"}, logEvent(web, models.EventTypeError), true},
+		{"project", models.Route{Project: "payments"}, logEvent(payments, models.EventTypeError), true},
+		{"other project", models.Route{Project: "payments"}, logEvent(web, models.EventTypeError), false},
+		{"container condition without a container", models.Route{Container: "web"}, notify.Notification{Type: notify.NotificationStartedRattle}, false},
+		{"all conditions", models.Route{Project: "payments", Container: "image=payments", MinSeverity: models.EventTypeError}, logEvent(payments, models.EventTypeCritical), true},
+		{"one condition fails", models.Route{Project: "payments", Container: "image=payments", MinSeverity: models.EventTypeError}, logEvent(payments, models.EventTypeInfo), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := matchRoute(tt.route, tt.n); got != tt.want {
+				t.Errorf("matchRoute(%+v) = %v, want %v", tt.route, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRouted(t *testing.T) {
+	managers.Routes.Load([]models.Route{
+		{Destination: "slack:payments", Project: "payments"},
+		{Destination: "pagerduty:oncall", MinSeverity: models.EventTypeCritical},
+		{Destination: "pagerduty:oncall", NotificationType: string(notify.NotificationContainerStopWithError)},
+	})
+	t.Cleanup(func() { managers.Routes.Load(nil) })
+
+	tests := []struct {
+		name        string
+		destination string
+		n           notify.Notification
+		want        bool
+	}{
+		{"destination without routes gets everything", "telegram:-100", logEvent(web, models.EventTypeInfo), true},
+		{"matching route", "slack:payments", logEvent(payments, models.EventTypeError), true},
+		{"no matching route", "slack:payments", logEvent(web, models.EventTypeError), false},
+		{"first of several routes", "pagerduty:oncall", logEvent(web, models.EventTypeCritical), true},
+		{"second of several routes", "pagerduty:oncall", notify.Notification{Type: notify.NotificationContainerStopWithError, Container: web}, true},
+		{"none of several routes", "pagerduty:oncall", logEvent(web, models.EventTypeError), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := routed(tt.destination, tt.n); got != tt.want {
+				t.Errorf("routed(%s) = %v, want %v", tt.destination, got, tt.want)
+			}
+		})
+	}
+}
+
+// namedNotifier is a notifier that only has a name
+type namedNotifier string
+
+func (nn namedNotifier) Name() string                   { return string(nn) }
+func (nn namedNotifier) Send(notify.Notification) error { return nil }
+
+func TestRoutedNotifiers(t *testing.T) {
+	managers.Routes.Load([]models.Route{
+		{Destination: "slack:payments", Project: "payments"},
+		{Destination: "pagerduty:oncall", MinSeverity: models.EventTypeCritical},
+	})
+	t.Cleanup(func() { managers.Routes.Load(nil) })
+
+	all := []notify.Notifier{namedNotifier("telegram:-100"), namedNotifier("slack:payments"), namedNotifier("pagerduty:oncall")}
+
+	names := func(ns []notify.Notifier) []string {
+		out := make([]string, 0, len(ns))
+		for _, nt := range ns {
+			out = append(out, nt.Name())
+		}
+		return out
+	}
+
+	tests := []struct {
+		name string
+		n    notify.Notification
+		want []string
+	}{
+		{"no route matches, only destinations without routes", logEvent(web, models.EventTypeError), []string{"telegram:-100"}},
+		{"one route matches", logEvent(payments, models.EventTypeError), []string{"telegram:-100", "slack:payments"}},
+		{"several routes match", logEvent(payments, models.EventTypeCritical), []string{"telegram:-100", "slack:payments", "pagerduty:oncall"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := names(routedNotifiers(all, tt.n)); !slices.Equal(got, tt.want) {
+				t.Errorf("routed to %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
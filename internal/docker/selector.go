@@ -0,0 +1,60 @@
+package docker
+
+import (
+	"strings"
+)
+
+// composeProjectLabel is the label Docker Compose sets to the project name
+const composeProjectLabel = "com.docker.compose.project"
+
+// MatchSelector checks if the container matches the selector.
+//
+// Selector is a comma-separated list of `key=value` terms, all of which must match (case-insensitive):
+//   - name=api — container name contains the value
+//   - image=nginx — image contains the value
+//   - id=e133aff5 — container ID starts with the value
+//   - label=env=prod — some `key=value` label contains the value
+//   - project=shop — Docker Compose project equals the value
+//
+// A term without a key is treated as `name`. An empty selector matches any container
+func MatchSelector(ci ContainerInfo, selector string) bool {
+	for _, term := range strings.Split(selector, ",") {
+		term = strings.ToLower(strings.TrimSpace(term))
+		if term == "" {
+			continue
+		}
+
+		key, value, ok := strings.Cut(term, "=")
+		if !ok {
+			key, value = "name", term
+		}
+
+		if !matchTerm(ci, key, value) {
+			return false
+		}
+	}
+	return true
+}
+
+// matchTerm checks a single selector term against the container
+func matchTerm(ci ContainerInfo, key, value string) bool {
+	switch key {
+	case "name":
+		return strings.Contains(strings.ToLower(ci.Name), value)
+	case "image":
+		return strings.Contains(strings.ToLower(ci.Image), value)
+	case "id":
+		return strings.HasPrefix(strings.ToLower(ci.ID), value)
+	case "label":
+		for k, v := range ci.Labels {
+			if strings.Contains(strings.ToLower(k+"="+v), value) {
+				return true
+			}
+		}
+		return false
+	case "project":
+		return strings.ToLower(ci.Labels[composeProjectLabel]) == value
+	default:
+		return false
+	}
+}
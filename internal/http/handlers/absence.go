@@ -0,0 +1,157 @@
+package handlers
+
+import (
+	"regexp"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"github.com/ilyxenc/rattle/internal/database"
+	"github.com/ilyxenc/rattle/internal/models"
+)
+
+func CreateAbsence(c *fiber.Ctx) error {
+	input := new(createAbsenceInput)
+
+	if err := c.BodyParser(&input); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(Res{
+			Message: "Invalid request body",
+		})
+	}
+
+	vldt := validator.New()
+	if err := vldt.Struct(input); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(Res{
+			Message: "Validation failed",
+		})
+	}
+
+	if _, err := regexp.Compile(input.Pattern); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(Res{
+			Message: "Invalid regex pattern",
+		})
+	}
+
+	db := database.DB
+
+	rule := models.AbsenceRule{
+		Pattern:    input.Pattern,
+		EventType:  input.EventType,
+		Container:  input.Container,
+		MaxSilence: input.MaxSilence,
+	}
+
+	if err := db.Create(&rule).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(Res{
+			Message: "Failed to create absence rule",
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(Res{
+		Message: "Absence rule created",
+		Data:    rule,
+	})
+}
+
+func ListAbsences(c *fiber.Ctx) error {
+	db := database.DB
+	var rules []models.AbsenceRule
+
+	if err := db.Order("created_at DESC").Find(&rules).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(Res{
+			Message: "Failed to retrieve absence rules",
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(Res{
+		Message: "List of absence rules",
+		Data:    rules,
+	})
+}
+
+func UpdateAbsence(c *fiber.Ctx) error {
+	id := c.Params("id")
+
+	input := new(updateAbsenceInput)
+	if err := c.BodyParser(&input); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(Res{
+			Message: "Invalid request body",
+		})
+	}
+
+	vldt := validator.New()
+	if err := vldt.Struct(input); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(Res{
+			Message: "Validation failed",
+		})
+	}
+
+	if input.Pattern != nil {
+		if _, err := regexp.Compile(*input.Pattern); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(Res{
+				Message: "Invalid regex pattern",
+			})
+		}
+	}
+
+	updates := map[string]interface{}{}
+	if input.Pattern != nil {
+		updates["pattern"] = *input.Pattern
+	}
+	if input.EventType != nil {
+		updates["event_type"] = *input.EventType
+	}
+	if input.Container != nil {
+		updates["container"] = *input.Container
+	}
+	if input.MaxSilence != nil {
+		updates["max_silence"] = *input.MaxSilence
+	}
+
+	if len(updates) == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(Res{
+			Message: "No valid fields provided for update",
+		})
+	}
+
+	db := database.DB
+
+	result := db.Model(&models.AbsenceRule{}).Where("id = ?", id).Updates(updates)
+
+	if result.Error != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(Res{
+			Message: "Failed to update absence rule",
+		})
+	}
+	if result.RowsAffected == 0 {
+		return c.Status(fiber.StatusNotFound).JSON(Res{
+			Message: "Absence rule not found",
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(Res{
+		Message: "Absence rule updated",
+	})
+}
+
+func DeleteAbsence(c *fiber.Ctx) error {
+	id := c.Params("id")
+
+	db := database.DB
+
+	result := db.Delete(&models.AbsenceRule{}, "id = ?", id)
+
+	if result.Error != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(Res{
+			Message: "Failed to delete absence rule",
+		})
+	}
+	if result.RowsAffected == 0 {
+		return c.Status(fiber.StatusNotFound).JSON(Res{
+			Message: "Absence rule not found",
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(Res{
+		Message: "Absence rule deleted",
+	})
+}
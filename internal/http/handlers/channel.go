@@ -0,0 +1,181 @@
+package handlers
+
+import (
+	"errors"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"github.com/ilyxenc/rattle/internal/database"
+	"github.com/ilyxenc/rattle/internal/dispatcher"
+	"github.com/ilyxenc/rattle/internal/models"
+	"gorm.io/gorm"
+)
+
+func CreateChannel(c *fiber.Ctx) error {
+	input := new(createChannelInput)
+
+	if err := c.BodyParser(&input); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(Res{
+			Message: "Invalid request body",
+		})
+	}
+
+	vldt := validator.New()
+	if err := vldt.Struct(input); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(Res{
+			Message: "Validation failed",
+		})
+	}
+
+	channel := models.Channel{
+		Name:    input.Name,
+		Type:    input.Type,
+		Config:  input.Config,
+		Enabled: input.Enabled == nil || *input.Enabled, // Enabled by default
+	}
+
+	// Make sure the backend accepts the config
+	if _, err := dispatcher.NewNotifier(channel); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(Res{
+			Message: "Invalid channel config: " + err.Error(),
+		})
+	}
+
+	db := database.DB
+
+	if err := db.Create(&channel).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(Res{
+			Message: "Failed to create channel",
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(Res{
+		Message: "Channel created",
+		Data:    channel.Redacted(),
+	})
+}
+
+func ListChannels(c *fiber.Ctx) error {
+	db := database.DB
+	var channels []models.Channel
+
+	if err := db.Order("created_at DESC").Find(&channels).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(Res{
+			Message: "Failed to retrieve channels",
+		})
+	}
+
+	// Configs hold credentials, which aren't shown to anyone
+	for i := range channels {
+		channels[i] = channels[i].Redacted()
+	}
+
+	return c.Status(fiber.StatusOK).JSON(Res{
+		Message: "List of channels",
+		Data:    channels,
+	})
+}
+
+func UpdateChannel(c *fiber.Ctx) error {
+	id := c.Params("id")
+
+	input := new(updateChannelInput)
+	if err := c.BodyParser(&input); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(Res{
+			Message: "Invalid request body",
+		})
+	}
+
+	vldt := validator.New()
+	if err := vldt.Struct(input); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(Res{
+			Message: "Validation failed",
+		})
+	}
+
+	db := database.DB
+
+	var channel models.Channel
+	if err := db.First(&channel, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(Res{
+				Message: "Channel not found",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(Res{
+			Message: "Failed to update channel",
+		})
+	}
+
+	destination := channel.Destination()
+
+	if input.Name != nil {
+		channel.Name = *input.Name
+	}
+	if input.Type != nil {
+		channel.Type = *input.Type
+	}
+	if input.Config != nil {
+		input.Config.RestoreSecrets(channel.Config)
+		channel.Config = input.Config
+	}
+	if input.Enabled != nil {
+		channel.Enabled = *input.Enabled
+	}
+
+	// Make sure the backend accepts the updated config
+	if _, err := dispatcher.NewNotifier(channel); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(Res{
+			Message: "Invalid channel config: " + err.Error(),
+		})
+	}
+
+	// Routes refer to the channel by its destination name, renaming it would leave them behind
+	if channel.Destination() != destination {
+		var routes int64
+		if err := db.Model(&models.Route{}).Where("destination = ?", destination).Count(&routes).Error; err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(Res{
+				Message: "Failed to update channel",
+			})
+		}
+		if routes > 0 {
+			return c.Status(fiber.StatusConflict).JSON(Res{
+				Message: "Channel has routes, move them to the new name first",
+			})
+		}
+	}
+
+	if err := db.Save(&channel).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(Res{
+			Message: "Failed to update channel",
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(Res{
+		Message: "Channel updated",
+		Data:    channel.Redacted(),
+	})
+}
+
+func DeleteChannel(c *fiber.Ctx) error {
+	id := c.Params("id")
+
+	db := database.DB
+
+	result := db.Delete(&models.Channel{}, "id = ?", id)
+
+	if result.Error != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(Res{
+			Message: "Failed to delete channel",
+		})
+	}
+	if result.RowsAffected == 0 {
+		return c.Status(fiber.StatusNotFound).JSON(Res{
+			Message: "Channel not found",
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(Res{
+		Message: "Channel deleted",
+	})
+}
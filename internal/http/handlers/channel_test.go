@@ -0,0 +1,59 @@
+package handlers
+
+import (
+	"database/sql/driver"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestUpdateChannelRename(t *testing.T) {
+	tests := []struct {
+		name   string
+		body   string
+		routes int64 // Routes of the stored destination "slack:ops"
+		want   int
+	}{
+		{"rename without routes", `{"name": "payments"}`, 0, http.StatusOK},
+		{"rename with routes", `{"name": "payments"}`, 2, http.StatusConflict},
+		{"type change with routes", `{"type": "discord", "config": {"webhook_url": "https://discord.com/api/webhooks/1/x"}}`, 2, http.StatusConflict},
+		{"other changes keep routes", `{"enabled": false}`, 2, http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := useFakeDB(t, func(query string, args []any) fakeResult {
+				switch {
+				case strings.HasPrefix(query, `SELECT * FROM "channels"`):
+					return fakeResult{
+						columns: []string{"id", "name", "type", "config", "enabled"},
+						rows:    [][]driver.Value{{int64(3), "ops", "slack", `{"webhook_url": "https://hooks.slack.com/services/T/B/x"}`, true}},
+					}
+				case strings.HasPrefix(query, "SELECT count(*)"):
+					return fakeResult{columns: []string{"count"}, rows: [][]driver.Value{{tt.routes}}}
+				case strings.HasPrefix(query, "UPDATE"):
+					return fakeResult{affected: 1}
+				}
+				return fakeResult{}
+			})
+
+			status, res := call(t, http.MethodPut, "/channel/:id", "/channel/3", tt.body, UpdateChannel)
+			if status != tt.want {
+				t.Fatalf("status = %d (%s), want %d", status, res.Message, tt.want)
+			}
+
+			counts := db.ran("SELECT count(*)")
+			if strings.Contains(tt.name, "other changes") && len(counts) != 0 {
+				t.Errorf("routes were looked up although the destination didn't change")
+			}
+			for _, c := range counts {
+				if !strings.Contains(c.query, `"routes"`) || !containsArg(c.args, "slack:ops") {
+					t.Errorf("routes lookup %q %v, want routes of slack:ops", c.query, c.args)
+				}
+			}
+			if saved := len(db.ran("UPDATE")) > 0; saved != (tt.want == http.StatusOK) {
+				t.Errorf("channel saved = %v with status %d", saved, status)
+			}
+		})
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"github.com/gofiber/fiber/v2"
 	"github.com/ilyxenc/rattle/internal/database"
 	"github.com/ilyxenc/rattle/internal/models"
+	"github.com/ilyxenc/rattle/internal/telegram"
 )
 
 func CreateChat(c *fiber.Ctx) error {
@@ -23,11 +24,19 @@ func CreateChat(c *fiber.Ctx) error {
 		})
 	}
 
+	if err := telegram.ValidateTopics(input.Topics); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(Res{
+			Message: "Invalid topics: " + err.Error(),
+		})
+	}
+
 	db := database.DB
 
 	chat := models.Chat{
-		ChatID: input.ChatID,
-		Send:   input.Send,
+		ChatID:          input.ChatID,
+		Send:            input.Send,
+		MessageThreadID: input.MessageThreadID,
+		Topics:          input.Topics,
 	}
 
 	if err := db.Create(&chat).Error; err != nil {
@@ -75,9 +84,32 @@ func UpdateChat(c *fiber.Ctx) error {
 		})
 	}
 
+	if err := telegram.ValidateTopics(input.Topics); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(Res{
+			Message: "Invalid topics: " + err.Error(),
+		})
+	}
+
+	updates := map[string]interface{}{}
+	if input.Send != nil {
+		updates["send"] = *input.Send
+	}
+	if input.MessageThreadID != nil {
+		updates["message_thread_id"] = *input.MessageThreadID
+	}
+	if input.Topics != nil {
+		updates["topics"] = input.Topics
+	}
+
+	if len(updates) == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(Res{
+			Message: "No valid fields provided for update",
+		})
+	}
+
 	db := database.DB
 
-	result := db.Model(&models.Chat{}).Where("id = ?", id).Update("send", input.Send)
+	result := db.Model(&models.Chat{}).Where("id = ?", id).Updates(updates)
 
 	if result.Error != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(Res{
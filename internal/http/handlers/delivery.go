@@ -0,0 +1,138 @@
+package handlers
+
+import (
+	"errors"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"github.com/ilyxenc/rattle/internal/database"
+	"github.com/ilyxenc/rattle/internal/models"
+	"gorm.io/gorm"
+)
+
+// defaultDeliveriesLimit is the number of deliveries listed when no limit is given
+const defaultDeliveriesLimit = 100
+
+func ListDeliveries(c *fiber.Ctx) error {
+	input := new(listDeliveriesInput)
+
+	if err := c.QueryParser(input); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(Res{
+			Message: "Invalid query parameters",
+		})
+	}
+
+	vldt := validator.New()
+	if err := vldt.Struct(input); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(Res{
+			Message: "Validation failed",
+		})
+	}
+
+	limit := input.Limit
+	if limit == 0 {
+		limit = defaultDeliveriesLimit
+	}
+
+	db := database.DB.Order("id DESC").Limit(limit)
+	if input.Status != "" {
+		db = db.Where("status = ?", input.Status)
+	}
+	if input.Destination != "" {
+		db = db.Where("destination = ?", input.Destination)
+	}
+
+	var deliveries []models.Delivery
+	if err := db.Find(&deliveries).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(Res{
+			Message: "Failed to retrieve deliveries",
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(Res{
+		Message: "List of deliveries",
+		Data:    deliveries,
+	})
+}
+
+func ResendDelivery(c *fiber.Ctx) error {
+	id := c.Params("id")
+
+	db := database.DB
+
+	var delivery models.Delivery
+	if err := db.First(&delivery, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(Res{
+				Message: "Delivery not found",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(Res{
+			Message: "Failed to retrieve delivery",
+		})
+	}
+
+	// Pending and digest deliveries are still to be sent, sent ones must not be sent twice
+	if delivery.Status != models.DeliveryFailed {
+		return c.Status(fiber.StatusConflict).JSON(Res{
+			Message: "Only failed deliveries can be resent",
+		})
+	}
+
+	result := db.Model(&models.Delivery{}).Where("id = ? AND status = ?", id, models.DeliveryFailed).Updates(resendUpdates())
+	if result.Error != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(Res{
+			Message: "Failed to resend delivery",
+		})
+	}
+	if result.RowsAffected == 0 {
+		return c.Status(fiber.StatusConflict).JSON(Res{
+			Message: "Only failed deliveries can be resent",
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(Res{
+		Message: "Delivery queued",
+	})
+}
+
+func ResendFailedDeliveries(c *fiber.Ctx) error {
+	input := new(resendDeliveriesInput)
+
+	// Body is optional
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(input); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(Res{
+				Message: "Invalid request body",
+			})
+		}
+	}
+
+	db := database.DB.Model(&models.Delivery{}).Where("status = ?", models.DeliveryFailed)
+	if input.Destination != "" {
+		db = db.Where("destination = ?", input.Destination)
+	}
+
+	result := db.Updates(resendUpdates())
+	if result.Error != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(Res{
+			Message: "Failed to resend deliveries",
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(Res{
+		Message: "Deliveries queued",
+		Data:    fiber.Map{"count": result.RowsAffected},
+	})
+}
+
+// resendUpdates resets a delivery so the dispatcher outbox sends it again from the first part on the next check
+func resendUpdates() map[string]any {
+	return map[string]any{
+		"status":          models.DeliveryPending,
+		"attempts":        0,
+		"sent_parts":      0,
+		"next_attempt_at": time.Now(),
+	}
+}
@@ -0,0 +1,98 @@
+package handlers
+
+import (
+	"database/sql/driver"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/ilyxenc/rattle/internal/models"
+)
+
+func TestResendDelivery(t *testing.T) {
+	tests := []struct {
+		name   string
+		status string // Status of the stored delivery, empty if there is none
+		want   int
+	}{
+		{"failed is queued again", models.DeliveryFailed, http.StatusOK},
+		{"sent isn't sent twice", models.DeliverySent, http.StatusConflict},
+		{"pending is already queued", models.DeliveryPending, http.StatusConflict},
+		{"digest stays in the digest", models.DeliveryDigest, http.StatusConflict},
+		{"missing", "", http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := useFakeDB(t, func(query string, args []any) fakeResult {
+				switch {
+				case strings.HasPrefix(query, "SELECT") && tt.status != "":
+					return fakeResult{
+						columns: []string{"id", "status", "sent_parts", "attempts"},
+						rows:    [][]driver.Value{{int64(7), tt.status, int64(2), int64(10)}},
+					}
+				case strings.HasPrefix(query, "UPDATE"):
+					return fakeResult{affected: 1}
+				}
+				return fakeResult{}
+			})
+
+			status, res := call(t, http.MethodPost, "/delivery/:id/resend", "/delivery/7/resend", "", ResendDelivery)
+			if status != tt.want {
+				t.Fatalf("status = %d (%s), want %d", status, res.Message, tt.want)
+			}
+
+			updates := db.ran("UPDATE")
+			if tt.want != http.StatusOK {
+				if len(updates) != 0 {
+					t.Errorf("delivery was updated: %+v", updates)
+				}
+				return
+			}
+
+			if len(updates) != 1 {
+				t.Fatalf("ran %d updates, want 1", len(updates))
+			}
+			u := updates[0]
+			for _, column := range []string{`"attempts"=`, `"sent_parts"=`, `"status"=`, `"next_attempt_at"=`} {
+				if !strings.Contains(u.query, column) {
+					t.Errorf("update %q doesn't reset %s", u.query, column)
+				}
+			}
+			if !strings.Contains(u.query, "status = ") || !containsArg(u.args, models.DeliveryFailed) {
+				t.Errorf("update %q %v isn't limited to failed deliveries", u.query, u.args)
+			}
+		})
+	}
+}
+
+func TestResendFailedDeliveries(t *testing.T) {
+	db := useFakeDB(t, func(string, []any) fakeResult { return fakeResult{affected: 3} })
+
+	status, res := call(t, http.MethodPost, "/delivery/resend-failed", "/delivery/resend-failed", `{"destination": "slack:ops"}`, ResendFailedDeliveries)
+	if status != http.StatusOK {
+		t.Fatalf("status = %d (%s), want 200", status, res.Message)
+	}
+	if data, _ := res.Data.(map[string]any); data["count"] != float64(3) {
+		t.Errorf("data = %v, want count 3", res.Data)
+	}
+
+	updates := db.ran("UPDATE")
+	if len(updates) != 1 {
+		t.Fatalf("ran %d updates, want 1", len(updates))
+	}
+	u := updates[0]
+	if !strings.Contains(u.query, `"sent_parts"=`) || !containsArg(u.args, models.DeliveryFailed) || !containsArg(u.args, "slack:ops") {
+		t.Errorf("update %q %v, want failed deliveries of slack:ops reset from the first part", u.query, u.args)
+	}
+}
+
+// containsArg reports whether the statement arguments hold the value
+func containsArg(args []any, value any) bool {
+	for _, a := range args {
+		if a == value {
+			return true
+		}
+	}
+	return false
+}
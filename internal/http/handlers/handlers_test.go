@@ -0,0 +1,196 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/ilyxenc/rattle/internal/database"
+	"github.com/ilyxenc/rattle/internal/logger"
+	"go.uber.org/zap"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+)
+
+func TestMain(m *testing.M) {
+	logger.Log = zap.NewNop().Sugar()
+	sql.Register("fakedb", fakeDriver{})
+	os.Exit(m.Run())
+}
+
+// fakeResult is the answer of the fake database to a statement
+type fakeResult struct {
+	columns  []string
+	rows     [][]driver.Value
+	affected int64
+}
+
+// statement is a statement run against the fake database
+type statement struct {
+	query string
+	args  []any
+}
+
+// fakeDB answers statements of the handlers with `answer` and records them, so handlers are tested without Postgres
+type fakeDB struct {
+	mu         sync.Mutex
+	statements []statement
+	answer     func(query string, args []any) fakeResult
+}
+
+var (
+	dbsMu sync.Mutex
+	dbs   = map[string]*fakeDB{} // Fake databases by DSN
+)
+
+// useFakeDB points database.DB to a fake database answering with `answer` for the test
+func useFakeDB(t *testing.T, answer func(query string, args []any) fakeResult) *fakeDB {
+	t.Helper()
+
+	fdb := &fakeDB{answer: answer}
+	dbsMu.Lock()
+	dbs[t.Name()] = fdb
+	dbsMu.Unlock()
+
+	conn, err := sql.Open("fakedb", t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: conn}), &gorm.Config{
+		SkipDefaultTransaction: true,
+		Logger:                 gormlogger.Default.LogMode(gormlogger.Silent),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	prev := database.DB
+	database.DB = db
+	t.Cleanup(func() {
+		database.DB = prev
+		conn.Close()
+	})
+	return fdb
+}
+
+// run records the statement and returns the answer to it
+func (f *fakeDB) run(query string, named []driver.NamedValue) fakeResult {
+	args := make([]any, len(named))
+	for i, a := range named {
+		args[i] = a.Value
+	}
+
+	f.mu.Lock()
+	f.statements = append(f.statements, statement{query: query, args: args})
+	f.mu.Unlock()
+
+	if f.answer == nil {
+		return fakeResult{}
+	}
+	return f.answer(query, args)
+}
+
+// ran returns the recorded statements starting with the prefix, e.g. "UPDATE"
+func (f *fakeDB) ran(prefix string) []statement {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var found []statement
+	for _, s := range f.statements {
+		if strings.HasPrefix(s.query, prefix) {
+			found = append(found, s)
+		}
+	}
+	return found
+}
+
+type fakeDriver struct{}
+
+func (fakeDriver) Open(dsn string) (driver.Conn, error) {
+	dbsMu.Lock()
+	defer dbsMu.Unlock()
+
+	fdb, ok := dbs[dsn]
+	if !ok {
+		return nil, errors.New("unknown fake database")
+	}
+	return &fakeConn{db: fdb}, nil
+}
+
+type fakeConn struct {
+	db *fakeDB
+}
+
+func (c *fakeConn) Prepare(string) (driver.Stmt, error) {
+	return nil, errors.New("prepared statements are not supported")
+}
+
+func (c *fakeConn) Close() error { return nil }
+
+func (c *fakeConn) Begin() (driver.Tx, error) { return fakeTx{}, nil }
+
+func (c *fakeConn) ExecContext(_ context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	return driver.RowsAffected(c.db.run(query, args).affected), nil
+}
+
+func (c *fakeConn) QueryContext(_ context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	res := c.db.run(query, args)
+	return &fakeRows{columns: res.columns, rows: res.rows}, nil
+}
+
+type fakeTx struct{}
+
+func (fakeTx) Commit() error   { return nil }
+func (fakeTx) Rollback() error { return nil }
+
+type fakeRows struct {
+	columns []string
+	rows    [][]driver.Value
+}
+
+func (r *fakeRows) Columns() []string { return r.columns }
+
+func (r *fakeRows) Close() error { return nil }
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if len(r.rows) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.rows[0])
+	r.rows = r.rows[1:]
+	return nil
+}
+
+// call sends the request to the handler and returns the status and the decoded response
+func call(t *testing.T, method, route, path, body string, handler fiber.Handler) (int, Res) {
+	t.Helper()
+
+	app := fiber.New()
+	app.Add(method, route, handler)
+
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp, err := app.Test(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	var res Res
+	if err := json.NewDecoder(resp.Body).Decode(&res); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	return resp.StatusCode, res
+}
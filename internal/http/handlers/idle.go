@@ -0,0 +1,133 @@
+package handlers
+
+import (
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"github.com/ilyxenc/rattle/internal/database"
+	"github.com/ilyxenc/rattle/internal/models"
+)
+
+func CreateIdle(c *fiber.Ctx) error {
+	input := new(createIdleInput)
+
+	if err := c.BodyParser(&input); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(Res{
+			Message: "Invalid request body",
+		})
+	}
+
+	vldt := validator.New()
+	if err := vldt.Struct(input); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(Res{
+			Message: "Validation failed",
+		})
+	}
+
+	db := database.DB
+
+	rule := models.IdleRule{
+		Container: input.Container,
+		Timeout:   input.Timeout,
+	}
+
+	if err := db.Create(&rule).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(Res{
+			Message: "Failed to create idle rule",
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(Res{
+		Message: "Idle rule created",
+		Data:    rule,
+	})
+}
+
+func ListIdles(c *fiber.Ctx) error {
+	db := database.DB
+	var rules []models.IdleRule
+
+	if err := db.Order("created_at DESC").Find(&rules).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(Res{
+			Message: "Failed to retrieve idle rules",
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(Res{
+		Message: "List of idle rules",
+		Data:    rules,
+	})
+}
+
+func UpdateIdle(c *fiber.Ctx) error {
+	id := c.Params("id")
+
+	input := new(updateIdleInput)
+	if err := c.BodyParser(&input); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(Res{
+			Message: "Invalid request body",
+		})
+	}
+
+	vldt := validator.New()
+	if err := vldt.Struct(input); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(Res{
+			Message: "Validation failed",
+		})
+	}
+
+	updates := map[string]interface{}{}
+	if input.Container != nil {
+		updates["container"] = *input.Container
+	}
+	if input.Timeout != nil {
+		updates["timeout"] = *input.Timeout
+	}
+
+	if len(updates) == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(Res{
+			Message: "No valid fields provided for update",
+		})
+	}
+
+	db := database.DB
+
+	result := db.Model(&models.IdleRule{}).Where("id = ?", id).Updates(updates)
+
+	if result.Error != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(Res{
+			Message: "Failed to update idle rule",
+		})
+	}
+	if result.RowsAffected == 0 {
+		return c.Status(fiber.StatusNotFound).JSON(Res{
+			Message: "Idle rule not found",
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(Res{
+		Message: "Idle rule updated",
+	})
+}
+
+func DeleteIdle(c *fiber.Ctx) error {
+	id := c.Params("id")
+
+	db := database.DB
+
+	result := db.Delete(&models.IdleRule{}, "id = ?", id)
+
+	if result.Error != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(Res{
+			Message: "Failed to delete idle rule",
+		})
+	}
+	if result.RowsAffected == 0 {
+		return c.Status(fiber.StatusNotFound).JSON(Res{
+			Message: "Idle rule not found",
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(Res{
+		Message: "Idle rule deleted",
+	})
+}
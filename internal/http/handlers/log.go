@@ -31,6 +31,10 @@ func CreateLog(c *fiber.Ctx) error {
 		Pattern:   input.Pattern,
 		MatchType: input.MatchType,
 		EventType: input.EventType,
+		Stream:    input.Stream,
+		Container: input.Container,
+		Field:     input.Field,
+		Priority:  input.Priority,
 	}
 
 	if err := db.Create(&log).Error; err != nil {
@@ -96,6 +100,18 @@ func UpdateLog(c *fiber.Ctx) error {
 	if input.EventType != nil {
 		updates["event_type"] = *input.EventType
 	}
+	if input.Stream != nil {
+		updates["stream"] = *input.Stream
+	}
+	if input.Container != nil {
+		updates["container"] = *input.Container
+	}
+	if input.Field != nil {
+		updates["field"] = *input.Field
+	}
+	if input.Priority != nil {
+		updates["priority"] = *input.Priority
+	}
 
 	if len(updates) == 0 {
 		return c.Status(fiber.StatusBadRequest).JSON(Res{
@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"database/sql/driver"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestLogStreamValidated(t *testing.T) {
+	tests := []struct {
+		name    string
+		handler func(t *testing.T, body string) int
+		body    string
+		want    int
+	}{
+		{"create stdout", createLog, `{"pattern": "panic", "match_type": "include", "event_type": "error", "stream": "stdout"}`, http.StatusCreated},
+		{"create any stream", createLog, `{"pattern": "panic", "match_type": "include", "event_type": "error"}`, http.StatusCreated},
+		{"create unknown stream", createLog, `{"pattern": "panic", "match_type": "include", "event_type": "error", "stream": "both"}`, http.StatusBadRequest},
+		{"create uppercase stream", createLog, `{"pattern": "panic", "match_type": "include", "event_type": "error", "stream": "STDERR"}`, http.StatusBadRequest},
+		{"update stderr", updateLog, `{"stream": "stderr"}`, http.StatusOK},
+		{"update clears stream", updateLog, `{"stream": ""}`, http.StatusOK},
+		{"update unknown stream", updateLog, `{"stream": "syslog"}`, http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if status := tt.handler(t, tt.body); status != tt.want {
+				t.Errorf("status = %d, want %d", status, tt.want)
+			}
+		})
+	}
+}
+
+// createLog calls CreateLog with the body against a fake DB and returns the status
+func createLog(t *testing.T, body string) int {
+	t.Helper()
+
+	db := useFakeDB(t, func(query string, args []any) fakeResult {
+		if strings.HasPrefix(query, "INSERT") {
+			return fakeResult{columns: []string{"id"}, rows: [][]driver.Value{{int64(1)}}}
+		}
+		return fakeResult{}
+	})
+
+	status, res := call(t, http.MethodPost, "/log", "/log", body, CreateLog)
+	if saved := len(db.ran("INSERT")) > 0; saved != (status == http.StatusCreated) {
+		t.Errorf("log saved = %v with status %d (%s)", saved, status, res.Message)
+	}
+	return status
+}
+
+// updateLog calls UpdateLog with the body against a fake DB and returns the status
+func updateLog(t *testing.T, body string) int {
+	t.Helper()
+
+	db := useFakeDB(t, func(query string, args []any) fakeResult {
+		if strings.HasPrefix(query, "UPDATE") {
+			return fakeResult{affected: 1}
+		}
+		return fakeResult{}
+	})
+
+	status, res := call(t, http.MethodPut, "/log/:id", "/log/1", body, UpdateLog)
+	if saved := len(db.ran("UPDATE")) > 0; saved != (status == http.StatusOK) {
+		t.Errorf("log saved = %v with status %d (%s)", saved, status, res.Message)
+	}
+	return status
+}
@@ -1,5 +1,9 @@
 package handlers
 
+import (
+	"github.com/ilyxenc/rattle/internal/models"
+)
+
 type Res struct {
 	Message string `json:"message"`
 	Data    any    `json:"data,omitempty"`
@@ -16,12 +20,30 @@ type createUserInput struct {
 }
 
 type createChatInput struct {
-	ChatID string `json:"chat_id" validate:"required"`
-	Send   bool   `json:"send"`
+	ChatID          string            `json:"chat_id" validate:"required"`
+	Send            bool              `json:"send"`
+	MessageThreadID int               `json:"message_thread_id" validate:"min=0"`
+	Topics          models.ChatTopics `json:"topics"`
 }
 
 type updateChatInput struct {
-	Send bool `json:"send"`
+	Send            *bool             `json:"send"`
+	MessageThreadID *int              `json:"message_thread_id" validate:"omitempty,min=0"`
+	Topics          models.ChatTopics `json:"topics"`
+}
+
+type createChannelInput struct {
+	Name    string               `json:"name" validate:"required,min=1"`
+	Type    string               `json:"type" validate:"required"`
+	Config  models.ChannelConfig `json:"config"`
+	Enabled *bool                `json:"enabled"`
+}
+
+type updateChannelInput struct {
+	Name    *string              `json:"name" validate:"omitempty,min=1"`
+	Type    *string              `json:"type"`
+	Config  models.ChannelConfig `json:"config"`
+	Enabled *bool                `json:"enabled"`
 }
 
 type saveContainerInput struct {
@@ -42,14 +64,92 @@ type createLogInput struct {
 	Pattern   string `json:"pattern" validate:"required,min=1"`
 	MatchType string `json:"match_type" validate:"required,oneof=include exclude"`
 	EventType string `json:"event_type" validate:"required,oneof=error info warning success critical"`
+	Stream    string `json:"stream" validate:"omitempty,oneof=stdout stderr"`
+	Container string `json:"container"`
+	Field     string `json:"field"`
+	Priority  int    `json:"priority"`
 }
 
 type updateLogInput struct {
 	Pattern   *string `json:"pattern" validate:"omitempty,min=1"`
 	MatchType *string `json:"match_type" validate:"omitempty,oneof=include exclude"`
 	EventType *string `json:"event_type" validate:"omitempty,oneof=error info warning success critical"`
+	Stream    *string `json:"stream" validate:"omitempty,oneof='' stdout stderr"`
+	Container *string `json:"container"`
+	Field     *string `json:"field"`
+	Priority  *int    `json:"priority"`
+}
+
+type createThresholdInput struct {
+	Pattern   string `json:"pattern" validate:"required,min=1"`
+	EventType string `json:"event_type" validate:"required,oneof=error info warning success critical"`
+	Stream    string `json:"stream" validate:"omitempty,oneof=stdout stderr"`
+	Container string `json:"container"`
+	Count     int    `json:"count" validate:"required,min=1"`
+	Window    int    `json:"window" validate:"required,min=1"`
+}
+
+type updateThresholdInput struct {
+	Pattern   *string `json:"pattern" validate:"omitempty,min=1"`
+	EventType *string `json:"event_type" validate:"omitempty,oneof=error info warning success critical"`
+	Stream    *string `json:"stream" validate:"omitempty,oneof='' stdout stderr"`
+	Container *string `json:"container"`
+	Count     *int    `json:"count" validate:"omitempty,min=1"`
+	Window    *int    `json:"window" validate:"omitempty,min=1"`
+}
+
+type createAbsenceInput struct {
+	Pattern    string `json:"pattern" validate:"required,min=1"`
+	EventType  string `json:"event_type" validate:"required,oneof=error info warning success critical"`
+	Container  string `json:"container"`
+	MaxSilence int    `json:"max_silence" validate:"required,min=1"`
+}
+
+type updateAbsenceInput struct {
+	Pattern    *string `json:"pattern" validate:"omitempty,min=1"`
+	EventType  *string `json:"event_type" validate:"omitempty,oneof=error info warning success critical"`
+	Container  *string `json:"container"`
+	MaxSilence *int    `json:"max_silence" validate:"omitempty,min=1"`
+}
+
+type createIdleInput struct {
+	Container string `json:"container"`
+	Timeout   int    `json:"timeout" validate:"required,min=1"`
+}
+
+type updateIdleInput struct {
+	Container *string `json:"container"`
+	Timeout   *int    `json:"timeout" validate:"omitempty,min=1"`
+}
+
+type createRouteInput struct {
+	Destination      string `json:"destination" validate:"required,min=1"`
+	EventType        string `json:"event_type" validate:"omitempty,oneof=error info warning success critical"`
+	MinSeverity      string `json:"min_severity" validate:"omitempty,oneof=error info warning success critical"`
+	NotificationType string `json:"notification_type"`
+	Container        string `json:"container"`
+	Project          string `json:"project"`
+}
+
+type updateRouteInput struct {
+	Destination      *string `json:"destination" validate:"omitempty,min=1"`
+	EventType        *string `json:"event_type" validate:"omitempty,oneof='' error info warning success critical"`
+	MinSeverity      *string `json:"min_severity" validate:"omitempty,oneof='' error info warning success critical"`
+	NotificationType *string `json:"notification_type"`
+	Container        *string `json:"container"`
+	Project          *string `json:"project"`
 }
 
 type updateModeInput struct {
 	Value string `json:"value" validate:"required,oneof=blacklist whitelist"`
 }
+
+type listDeliveriesInput struct {
+	Status      string `query:"status" validate:"omitempty,oneof=pending sent failed digest"`
+	Destination string `query:"destination"`
+	Limit       int    `query:"limit" validate:"omitempty,min=1,max=500"`
+}
+
+type resendDeliveriesInput struct {
+	Destination string `json:"destination"` // Resend failed deliveries of this destination only, all if empty
+}
@@ -0,0 +1,162 @@
+package handlers
+
+import (
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"github.com/ilyxenc/rattle/internal/database"
+	"github.com/ilyxenc/rattle/internal/models"
+	"github.com/ilyxenc/rattle/internal/notify"
+)
+
+func CreateRoute(c *fiber.Ctx) error {
+	input := new(createRouteInput)
+
+	if err := c.BodyParser(&input); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(Res{
+			Message: "Invalid request body",
+		})
+	}
+
+	vldt := validator.New()
+	if err := vldt.Struct(input); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(Res{
+			Message: "Validation failed",
+		})
+	}
+
+	if input.NotificationType != "" && !notify.NotificationType(input.NotificationType).Valid() {
+		return c.Status(fiber.StatusBadRequest).JSON(Res{
+			Message: "Unknown notification type",
+		})
+	}
+
+	db := database.DB
+
+	route := models.Route{
+		Destination:      input.Destination,
+		EventType:        input.EventType,
+		MinSeverity:      input.MinSeverity,
+		NotificationType: input.NotificationType,
+		Container:        input.Container,
+		Project:          input.Project,
+	}
+
+	if err := db.Create(&route).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(Res{
+			Message: "Failed to create route",
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(Res{
+		Message: "Route created",
+		Data:    route,
+	})
+}
+
+func ListRoutes(c *fiber.Ctx) error {
+	db := database.DB
+	var routes []models.Route
+
+	if err := db.Order("created_at DESC").Find(&routes).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(Res{
+			Message: "Failed to retrieve routes",
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(Res{
+		Message: "List of routes",
+		Data:    routes,
+	})
+}
+
+func UpdateRoute(c *fiber.Ctx) error {
+	id := c.Params("id")
+
+	input := new(updateRouteInput)
+	if err := c.BodyParser(&input); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(Res{
+			Message: "Invalid request body",
+		})
+	}
+
+	vldt := validator.New()
+	if err := vldt.Struct(input); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(Res{
+			Message: "Validation failed",
+		})
+	}
+
+	if input.NotificationType != nil && *input.NotificationType != "" && !notify.NotificationType(*input.NotificationType).Valid() {
+		return c.Status(fiber.StatusBadRequest).JSON(Res{
+			Message: "Unknown notification type",
+		})
+	}
+
+	updates := map[string]interface{}{}
+	if input.Destination != nil {
+		updates["destination"] = *input.Destination
+	}
+	if input.EventType != nil {
+		updates["event_type"] = *input.EventType
+	}
+	if input.MinSeverity != nil {
+		updates["min_severity"] = *input.MinSeverity
+	}
+	if input.NotificationType != nil {
+		updates["notification_type"] = *input.NotificationType
+	}
+	if input.Container != nil {
+		updates["container"] = *input.Container
+	}
+	if input.Project != nil {
+		updates["project"] = *input.Project
+	}
+
+	if len(updates) == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(Res{
+			Message: "No valid fields provided for update",
+		})
+	}
+
+	db := database.DB
+
+	result := db.Model(&models.Route{}).Where("id = ?", id).Updates(updates)
+
+	if result.Error != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(Res{
+			Message: "Failed to update route",
+		})
+	}
+	if result.RowsAffected == 0 {
+		return c.Status(fiber.StatusNotFound).JSON(Res{
+			Message: "Route not found",
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(Res{
+		Message: "Route updated",
+	})
+}
+
+func DeleteRoute(c *fiber.Ctx) error {
+	id := c.Params("id")
+
+	db := database.DB
+
+	result := db.Delete(&models.Route{}, "id = ?", id)
+
+	if result.Error != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(Res{
+			Message: "Failed to delete route",
+		})
+	}
+	if result.RowsAffected == 0 {
+		return c.Status(fiber.StatusNotFound).JSON(Res{
+			Message: "Route not found",
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(Res{
+		Message: "Route deleted",
+	})
+}
@@ -0,0 +1,165 @@
+package handlers
+
+import (
+	"regexp"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"github.com/ilyxenc/rattle/internal/database"
+	"github.com/ilyxenc/rattle/internal/models"
+)
+
+func CreateThreshold(c *fiber.Ctx) error {
+	input := new(createThresholdInput)
+
+	if err := c.BodyParser(&input); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(Res{
+			Message: "Invalid request body",
+		})
+	}
+
+	vldt := validator.New()
+	if err := vldt.Struct(input); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(Res{
+			Message: "Validation failed",
+		})
+	}
+
+	if _, err := regexp.Compile(input.Pattern); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(Res{
+			Message: "Invalid regex pattern",
+		})
+	}
+
+	db := database.DB
+
+	rule := models.ThresholdRule{
+		Pattern:   input.Pattern,
+		EventType: input.EventType,
+		Stream:    input.Stream,
+		Container: input.Container,
+		Count:     input.Count,
+		Window:    input.Window,
+	}
+
+	if err := db.Create(&rule).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(Res{
+			Message: "Failed to create threshold rule",
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(Res{
+		Message: "Threshold rule created",
+		Data:    rule,
+	})
+}
+
+func ListThresholds(c *fiber.Ctx) error {
+	db := database.DB
+	var rules []models.ThresholdRule
+
+	if err := db.Order("created_at DESC").Find(&rules).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(Res{
+			Message: "Failed to retrieve threshold rules",
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(Res{
+		Message: "List of threshold rules",
+		Data:    rules,
+	})
+}
+
+func UpdateThreshold(c *fiber.Ctx) error {
+	id := c.Params("id")
+
+	input := new(updateThresholdInput)
+	if err := c.BodyParser(&input); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(Res{
+			Message: "Invalid request body",
+		})
+	}
+
+	vldt := validator.New()
+	if err := vldt.Struct(input); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(Res{
+			Message: "Validation failed",
+		})
+	}
+
+	if input.Pattern != nil {
+		if _, err := regexp.Compile(*input.Pattern); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(Res{
+				Message: "Invalid regex pattern",
+			})
+		}
+	}
+
+	updates := map[string]interface{}{}
+	if input.Pattern != nil {
+		updates["pattern"] = *input.Pattern
+	}
+	if input.EventType != nil {
+		updates["event_type"] = *input.EventType
+	}
+	if input.Stream != nil {
+		updates["stream"] = *input.Stream
+	}
+	if input.Container != nil {
+		updates["container"] = *input.Container
+	}
+	if input.Count != nil {
+		updates["count"] = *input.Count
+	}
+	if input.Window != nil {
+		updates["window"] = *input.Window
+	}
+
+	if len(updates) == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(Res{
+			Message: "No valid fields provided for update",
+		})
+	}
+
+	db := database.DB
+
+	result := db.Model(&models.ThresholdRule{}).Where("id = ?", id).Updates(updates)
+
+	if result.Error != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(Res{
+			Message: "Failed to update threshold rule",
+		})
+	}
+	if result.RowsAffected == 0 {
+		return c.Status(fiber.StatusNotFound).JSON(Res{
+			Message: "Threshold rule not found",
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(Res{
+		Message: "Threshold rule updated",
+	})
+}
+
+func DeleteThreshold(c *fiber.Ctx) error {
+	id := c.Params("id")
+
+	db := database.DB
+
+	result := db.Delete(&models.ThresholdRule{}, "id = ?", id)
+
+	if result.Error != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(Res{
+			Message: "Failed to delete threshold rule",
+		})
+	}
+	if result.RowsAffected == 0 {
+		return c.Status(fiber.StatusNotFound).JSON(Res{
+			Message: "Threshold rule not found",
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(Res{
+		Message: "Threshold rule deleted",
+	})
+}
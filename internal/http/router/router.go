@@ -27,6 +27,23 @@ func SetupRoutes(app *fiber.App) {
 	chat.Patch("/:id", mw.Protected(), mw.LocatedTelegramId(), mw.LocatedUserRole("admin"), handlers.UpdateChat)
 	chat.Delete("/:id", mw.Protected(), mw.LocatedTelegramId(), mw.LocatedUserRole("admin"), handlers.DeleteChat)
 
+	channel := api.Group("/channel")
+	channel.Post("/new", mw.Protected(), mw.LocatedTelegramId(), mw.LocatedUserRole("admin"), handlers.CreateChannel)
+	channel.Get("/list", mw.Protected(), handlers.ListChannels)
+	channel.Patch("/:id", mw.Protected(), mw.LocatedTelegramId(), mw.LocatedUserRole("admin"), handlers.UpdateChannel)
+	channel.Delete("/:id", mw.Protected(), mw.LocatedTelegramId(), mw.LocatedUserRole("admin"), handlers.DeleteChannel)
+
+	route := api.Group("/route")
+	route.Post("/new", mw.Protected(), mw.LocatedTelegramId(), mw.LocatedUserRole("admin"), handlers.CreateRoute)
+	route.Get("/list", mw.Protected(), handlers.ListRoutes)
+	route.Patch("/:id", mw.Protected(), mw.LocatedTelegramId(), mw.LocatedUserRole("admin"), handlers.UpdateRoute)
+	route.Delete("/:id", mw.Protected(), mw.LocatedTelegramId(), mw.LocatedUserRole("admin"), handlers.DeleteRoute)
+
+	delivery := api.Group("/delivery")
+	delivery.Get("/list", mw.Protected(), handlers.ListDeliveries)
+	delivery.Post("/resend-failed", mw.Protected(), mw.LocatedTelegramId(), mw.LocatedUserRole("admin"), handlers.ResendFailedDeliveries)
+	delivery.Post("/:id/resend", mw.Protected(), mw.LocatedTelegramId(), mw.LocatedUserRole("admin"), handlers.ResendDelivery)
+
 	container := api.Group("/container")
 	container.Post("/new", mw.Protected(), mw.LocatedTelegramId(), mw.LocatedUserRole("admin"), handlers.CreateContainer)
 	container.Get("/list", mw.Protected(), handlers.ListContainers)
@@ -40,6 +57,24 @@ func SetupRoutes(app *fiber.App) {
 	log.Patch("/:id", mw.Protected(), mw.LocatedTelegramId(), mw.LocatedUserRole("admin"), handlers.UpdateLog)
 	log.Delete("/:id", mw.Protected(), mw.LocatedTelegramId(), mw.LocatedUserRole("admin"), handlers.DeleteLog)
 
+	threshold := api.Group("/threshold")
+	threshold.Post("/new", mw.Protected(), mw.LocatedTelegramId(), mw.LocatedUserRole("admin"), handlers.CreateThreshold)
+	threshold.Get("/list", mw.Protected(), handlers.ListThresholds)
+	threshold.Patch("/:id", mw.Protected(), mw.LocatedTelegramId(), mw.LocatedUserRole("admin"), handlers.UpdateThreshold)
+	threshold.Delete("/:id", mw.Protected(), mw.LocatedTelegramId(), mw.LocatedUserRole("admin"), handlers.DeleteThreshold)
+
+	absence := api.Group("/absence")
+	absence.Post("/new", mw.Protected(), mw.LocatedTelegramId(), mw.LocatedUserRole("admin"), handlers.CreateAbsence)
+	absence.Get("/list", mw.Protected(), handlers.ListAbsences)
+	absence.Patch("/:id", mw.Protected(), mw.LocatedTelegramId(), mw.LocatedUserRole("admin"), handlers.UpdateAbsence)
+	absence.Delete("/:id", mw.Protected(), mw.LocatedTelegramId(), mw.LocatedUserRole("admin"), handlers.DeleteAbsence)
+
+	idle := api.Group("/idle")
+	idle.Post("/new", mw.Protected(), mw.LocatedTelegramId(), mw.LocatedUserRole("admin"), handlers.CreateIdle)
+	idle.Get("/list", mw.Protected(), handlers.ListIdles)
+	idle.Patch("/:id", mw.Protected(), mw.LocatedTelegramId(), mw.LocatedUserRole("admin"), handlers.UpdateIdle)
+	idle.Delete("/:id", mw.Protected(), mw.LocatedTelegramId(), mw.LocatedUserRole("admin"), handlers.DeleteIdle)
+
 	mode := api.Group("/mode")
 	mode.Get("/", mw.Protected(), handlers.GetFilteringMode)
 	mode.Patch("/", mw.Protected(), mw.LocatedTelegramId(), mw.LocatedUserRole("admin"), handlers.UpdateFilteringMode)
@@ -0,0 +1,87 @@
+package loganalyzer
+
+import (
+	"sync"
+	"time"
+
+	"github.com/ilyxenc/rattle/internal/notify"
+)
+
+// burst tracks repeats of a single fingerprint within the dedup window
+type burst struct {
+	started time.Time           // When the first occurrence was sent
+	repeats int                 // Occurrences suppressed after the first one
+	last    notify.Notification // Latest suppressed occurrence, used for the follow-up
+}
+
+// Deduper sends the first occurrence of each fingerprint right away and collapses
+// repeats within the window into a single "repeated N times" follow-up
+type Deduper struct {
+	mu     sync.Mutex
+	window time.Duration
+	bursts map[string]*burst // Key = fingerprint
+	notify func(notify.Notification)
+}
+
+// NewDeduper creates a deduper with the given window that sends notifications with `notify`
+func NewDeduper(window time.Duration, notify func(notify.Notification)) *Deduper {
+	return &Deduper{
+		window: window,
+		bursts: make(map[string]*burst),
+		notify: notify,
+	}
+}
+
+// Observe records an occurrence of the notification fingerprint.
+// Returns true if it's the first occurrence in the window and should be sent now
+func (d *Deduper) Observe(n notify.Notification) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	b, ok := d.bursts[n.Fingerprint]
+	if !ok {
+		d.bursts[n.Fingerprint] = &burst{started: time.Now()}
+		return true
+	}
+
+	b.repeats++
+	b.last = n
+	return false
+}
+
+// Flush closes bursts whose window is over and sends follow-ups for those with repeats
+func (d *Deduper) Flush(now time.Time) {
+	d.mu.Lock()
+	followUps := make([]notify.Notification, 0)
+	for fp, b := range d.bursts {
+		if now.Sub(b.started) < d.window {
+			continue
+		}
+
+		if b.repeats > 0 {
+			n := b.last
+			n.Type = notify.NotificationLogRepeated
+			n.Count = b.repeats
+			n.Window = d.window
+			followUps = append(followUps, n)
+		}
+		delete(d.bursts, fp)
+	}
+	d.mu.Unlock()
+
+	// Send outside of the lock so Observe is never blocked by slow delivery
+	for _, n := range followUps {
+		d.notify(n)
+	}
+}
+
+// Start periodically flushes finished bursts
+func (d *Deduper) Start(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+
+	go func() {
+		for now := range ticker.C {
+			d.Flush(now)
+		}
+	}()
+}
@@ -0,0 +1,55 @@
+package loganalyzer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ilyxenc/rattle/internal/notify"
+)
+
+func TestDeduper(t *testing.T) {
+	var sent []notify.Notification
+	d := NewDeduper(time.Minute, func(n notify.Notification) { sent = append(sent, n) })
+
+	occurrence := func(fp, details string) notify.Notification {
+		return notify.Notification{Type: notify.NotificationLogEvent, Fingerprint: fp, Details: details}
+	}
+
+	if !d.Observe(occurrence("a", "a 1")) {
+		t.Fatal("first occurrence wasn't sent")
+	}
+	if !d.Observe(occurrence("b", "b 1")) {
+		t.Fatal("first occurrence of another fingerprint wasn't sent")
+	}
+	for _, details := range []string{"a 2", "a 3"} {
+		if d.Observe(occurrence("a", details)) {
+			t.Fatalf("repeat %q within the window was sent", details)
+		}
+	}
+
+	// Bursts are kept until the window is over
+	d.Flush(time.Now())
+	if len(sent) != 0 {
+		t.Fatalf("sent %d follow-ups within the window", len(sent))
+	}
+
+	d.Flush(time.Now().Add(time.Minute))
+	if len(sent) != 1 {
+		t.Fatalf("sent %d follow-ups, want 1 for the fingerprint with repeats", len(sent))
+	}
+	f := sent[0]
+	if f.Type != notify.NotificationLogRepeated || f.Count != 2 || f.Window != time.Minute || f.Details != "a 3" || f.Fingerprint != "a" {
+		t.Errorf("follow-up = %+v, want 2 repeats of the latest occurrence", f)
+	}
+
+	// Finished bursts are evicted, so the next occurrence opens a new window
+	d.mu.Lock()
+	left := len(d.bursts)
+	d.mu.Unlock()
+	if left != 0 {
+		t.Errorf("%d bursts left after the window, want none", left)
+	}
+	if !d.Observe(occurrence("a", "a 4")) {
+		t.Error("occurrence after the window wasn't sent")
+	}
+}
@@ -0,0 +1,61 @@
+package loganalyzer
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"regexp"
+)
+
+// normalizer replaces matches of the pattern in a log line
+type normalizer struct {
+	re      *regexp.Regexp
+	replace func(match string) string // Returns the replacement of the match
+}
+
+// placeholder replaces every match with the text
+func placeholder(text string) func(string) string {
+	return func(string) string { return text }
+}
+
+// normalizers replace variable parts of a log line with placeholders, applied in order
+var normalizers = []normalizer{
+	// 2024-06-14T07:10:38.276Z, 2024-06-14 07:10:38,247, 2024/06/14 07:10:38
+	{regexp.MustCompile(`\d{4}[-/]\d{2}[-/]\d{2}[T ]\d{2}:\d{2}:\d{2}([.,]\d+)?(Z|[+-]\d{2}:?\d{2})?`), placeholder("<ts>")},
+	// 14/Jun/2024:07:10:38 +0000 (nginx), 07:10:38.276
+	{regexp.MustCompile(`\d{2}/\w{3}/\d{4}:\d{2}:\d{2}:\d{2}( [+-]\d{4})?`), placeholder("<ts>")},
+	{regexp.MustCompile(`\b\d{2}:\d{2}:\d{2}([.,]\d+)?\b`), placeholder("<ts>")},
+	// 123e4567-e89b-12d3-a456-426614174000
+	{regexp.MustCompile(`(?i)\b[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}\b`), placeholder("<uuid>")},
+	// 192.168.0.1:5432, ::1, fe80::1ff:fe23:4567:890a
+	{regexp.MustCompile(`\b\d{1,3}(\.\d{1,3}){3}(:\d+)?\b`), placeholder("<ip>")},
+	{regexp.MustCompile(`(?i)\b[0-9a-f]{1,4}(::?[0-9a-f]{1,4}){2,7}\b|::1\b`), placeholder("<ip>")},
+	// 0xc000012345, e133aff529d6
+	{regexp.MustCompile(`(?i)\b0x[0-9a-f]+\b`), placeholder("<hex>")},
+	{regexp.MustCompile(`(?i)\b[0-9a-f]*\d[0-9a-f]*\b`), hexOrNumber},
+	// Any other number
+	{regexp.MustCompile(`\d+(\.\d+)?`), placeholder("<n>")},
+}
+
+// NormalizeLine replaces timestamps, UUIDs, IPs, hex IDs and numbers with placeholders,
+// so lines that differ only by these parts look the same
+func NormalizeLine(line string) string {
+	for _, n := range normalizers {
+		line = n.re.ReplaceAllStringFunc(line, n.replace)
+	}
+	return line
+}
+
+// hexOrNumber replaces a word of hex digits: long ones (8+) are IDs, short ones are kept for the number normalizer
+func hexOrNumber(s string) string {
+	if len(s) >= 8 {
+		return "<hex>"
+	}
+	return s
+}
+
+// Fingerprint returns a short stable identifier of a log event in a container.
+// Events with the same normalized line and event type in the same container share a fingerprint
+func Fingerprint(containerID, eventType, line string) string {
+	sum := sha1.Sum([]byte(containerID + "\x00" + eventType + "\x00" + NormalizeLine(line)))
+	return hex.EncodeToString(sum[:8])
+}
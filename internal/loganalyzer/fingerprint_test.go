@@ -0,0 +1,61 @@
+package loganalyzer
+
+import (
+	"testing"
+
+	"github.com/ilyxenc/rattle/internal/models"
+)
+
+func TestNormalizeLine(t *testing.T) {
+	tests := []struct {
+		name string
+		line string
+		want string
+	}{
+		{"iso timestamp", "2024-06-14T07:10:38.276Z ERROR db down", "<ts> ERROR db down"},
+		{"python timestamp", "2024-06-14 07:10:38,247 - ERROR - db down", "<ts> - ERROR - db down"},
+		{"timestamp with offset", "2024/06/14 07:10:38+02:00 failed", "<ts> failed"},
+		{"nginx timestamp", `[14/Jun/2024:07:10:38 +0000] "GET / HTTP/1.1" 502`, `[<ts>] "GET / HTTP/<n>" <n>`},
+		{"time of day", "07:10:38.276 request failed", "<ts> request failed"},
+		{"uuid", "order 123e4567-e89b-12d3-a456-426614174000 failed", "order <uuid> failed"},
+		{"upper case uuid", "order 123E4567-E89B-12D3-A456-426614174000 failed", "order <uuid> failed"},
+		{"ipv4 with port", "dial tcp 192.168.0.1:5432: connection refused", "dial tcp <ip>: connection refused"},
+		{"ipv6", "dial tcp [fe80::1ff:fe23:4567:890a]:443 failed", "dial tcp [<ip>]:<n> failed"},
+		{"ipv6 loopback", "dial tcp [::1]:80 failed", "dial tcp [<ip>]:<n> failed"},
+		{"hex pointer", "nil pointer at 0xc000012345", "nil pointer at <hex>"},
+		{"container id", "container e133aff529d6 exited", "container <hex> exited"},
+		{"short hex word is a number", "retry 3a1 failed", "retry <n>a<n> failed"},
+		{"numbers", "took 1.25s for 42 rows", "took <n>s for <n> rows"},
+		{"words with digits kept", "http2 stream error", "http<n> stream error"},
+		{"no variable parts", "connection refused", "connection refused"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := NormalizeLine(tt.line); got != tt.want {
+				t.Errorf("NormalizeLine(%q) = %q, want %q", tt.line, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFingerprint(t *testing.T) {
+	base := Fingerprint("c1", models.EventTypeError, "2024-06-14T07:10:38Z request 42 failed for 10.0.0.1")
+
+	if got := Fingerprint("c1", models.EventTypeError, "2024-06-15T08:00:00Z request 7 failed for 10.0.0.2"); got != base {
+		t.Errorf("lines differing by variable parts have different fingerprints: %s and %s", got, base)
+	}
+	if len(base) != 16 {
+		t.Errorf("fingerprint %q has %d characters, want 16", base, len(base))
+	}
+
+	for name, fp := range map[string]string{
+		"container":  Fingerprint("c2", models.EventTypeError, "2024-06-14T07:10:38Z request 42 failed for 10.0.0.1"),
+		"event type": Fingerprint("c1", models.EventTypeWarning, "2024-06-14T07:10:38Z request 42 failed for 10.0.0.1"),
+		"text":       Fingerprint("c1", models.EventTypeError, "2024-06-14T07:10:38Z request 42 timed out for 10.0.0.1"),
+	} {
+		if fp == base {
+			t.Errorf("another %s has the same fingerprint", name)
+		}
+	}
+}
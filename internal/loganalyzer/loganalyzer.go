@@ -1,22 +1,75 @@
 package loganalyzer
 
 import (
+	"time"
+
+	"github.com/ilyxenc/rattle/internal/config"
+	"github.com/ilyxenc/rattle/internal/dispatcher"
 	"github.com/ilyxenc/rattle/internal/docker"
-	"github.com/ilyxenc/rattle/internal/telegram"
+	"github.com/ilyxenc/rattle/internal/managers"
+	"github.com/ilyxenc/rattle/internal/notify"
 )
 
-// AnalyzeLogLine checks if the given log line matches known error patterns.
+// dedup suppresses repeated alerts, nil if disabled
+var dedup *Deduper
+
+// Init starts duplicate suppression of log alerts if it's enabled and keeps threshold counters in line with the rules
+func Init() {
+	// Threshold counters of removed rules are dropped whenever the rules are reloaded
+	managers.Thresholds.OnLoad(thresholds.Prune)
+
+	window := config.Cfg.Dedup.Window
+	if !config.Cfg.Dedup.Enabled || window <= 0 {
+		return
+	}
+
+	dedup = NewDeduper(window, dispatcher.Notify)
+	dedup.Start(max(min(window/10, 10*time.Second), time.Second)) // Check finished bursts often enough to keep follow-ups on time
+}
+
+// AnalyzeLogLine checks if the given log entry matches known error patterns.
 // If it does, a notification is sent via Telegram
-func AnalyzeLogLine(c docker.ContainerInfo, line string) {
-	eventType := DetectEventType(line)
+func AnalyzeLogLine(c docker.ContainerInfo, e LogEntry) {
+	if config.Cfg.Structured.Enabled && e.Structured == nil {
+		if sl, ok := ParseStructured(e.Text); ok {
+			e.Structured = sl
+		}
+	}
+
+	// Threshold rules alert only after repeated matches, independently of include patterns
+	for _, n := range thresholds.Observe(c, e) {
+		dispatcher.Notify(n)
+	}
+
+	eventType := DetectEventType(c, e)
 	if eventType == "" {
 		return
 	}
 
-	telegram.Notify(telegram.Notification{
-		Type:      telegram.NotificationLogEvent,
-		EventType: eventType,
-		Details:   line,
-		Container: c,
-	})
+	n := notify.Notification{
+		Type:        notify.NotificationLogEvent,
+		EventType:   eventType,
+		Details:     e.Text,
+		Stream:      e.Stream,
+		Time:        e.Time,
+		Container:   c,
+		Fingerprint: Fingerprint(c.ID, eventType, e.Text),
+	}
+
+	// Show the message with selected fields instead of the raw structured line
+	if e.Structured != nil && e.Structured.Message != "" {
+		n.Details = e.Structured.Message
+		for _, key := range config.Cfg.Structured.Fields {
+			if value, ok := e.Structured.Fields[key]; ok && value != "" {
+				n.Fields = append(n.Fields, notify.Field{Key: key, Value: value})
+			}
+		}
+	}
+
+	// Repeats are collapsed into a follow-up sent by the deduper
+	if dedup != nil && !dedup.Observe(n) {
+		return
+	}
+
+	dispatcher.Notify(n)
 }
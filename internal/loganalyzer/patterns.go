@@ -1,54 +1,62 @@
 package loganalyzer
 
 import (
-	"strings"
-
+	"github.com/ilyxenc/rattle/internal/config"
+	"github.com/ilyxenc/rattle/internal/docker"
 	"github.com/ilyxenc/rattle/internal/managers"
 )
 
-// IsLogError returns true if the provided log line matches any known error pattern
-func IsLogError(line string) bool {
-	line = strings.TrimSpace(line)
-
-	// Check exclusion patterns first
-	for _, re := range managers.Logs.Exclude() {
-		if re.MatchString(line) {
-			return false
-		}
-	}
-
-	// Check inclusion patterns for "error" type
-	for _, re := range managers.Logs.Include("error") {
-		if re.MatchString(line) {
-			return true
-		}
-	}
-
-	return false
-}
-
-// DetectEventType returns the matching event type for the given line, or empty string if it matches nothing or is excluded
-func DetectEventType(line string) string {
-	if line == "" {
+// DetectEventType returns the matching event type for the given entry, or empty string if it matches nothing or is excluded.
+// Only rules scoped to the container and stream of the entry are used. If several rules match,
+// the one with the highest priority wins, then the most severe event type (critical > error > warning > info > success).
+//
+// For structured entries with a level raw line patterns are not used for inclusion, so `"level":"info","msg":"error budget ok"`
+// is not an error. Field rules are checked first, then the event type is derived from the level field.
+// Structured entries without a level are matched like plain lines
+func DetectEventType(c docker.ContainerInfo, e LogEntry) string {
+	if e.Text == "" {
 		return ""
 	}
 
 	// First check if line is excluded
-	for _, re := range managers.Logs.Exclude() {
-		if re.MatchString(line) {
+	for _, r := range managers.Logs.Exclude() {
+		if r.Applies(c, e.Stream) && ruleMatches(r, e) {
 			return ""
 		}
 	}
 
-	// Now check which event type matches
-	for _, eventType := range managers.Logs.KnownEventTypes() {
-		for _, re := range managers.Logs.Include(eventType) {
-			if re.MatchString(line) {
-				return eventType
-			}
+	// The level decides the event type of the structured line instead of raw line patterns
+	leveled := e.Structured != nil && e.Structured.Level != ""
+
+	// Now check which event type matches, the first matching rule in precedence order wins
+	for _, r := range managers.Logs.IncludeOrdered() {
+		if leveled && r.Field == "" {
+			continue
+		}
+		if r.Applies(c, e.Stream) && ruleMatches(r, e) {
+			return r.EventType
 		}
 	}
 
+	// Fall back to the level of the structured line
+	if leveled {
+		return e.Structured.EventType(config.Cfg.Structured.MinLevel)
+	}
+
 	// No match
 	return ""
 }
+
+// ruleMatches checks the rule pattern against the raw line or, for field rules, against the field of the structured line
+func ruleMatches(r managers.LogRule, e LogEntry) bool {
+	if r.Field == "" {
+		return r.Regex.MatchString(e.Text)
+	}
+
+	if e.Structured == nil {
+		return false
+	}
+
+	value, ok := e.Structured.Fields[r.Field]
+	return ok && r.Regex.MatchString(value)
+}
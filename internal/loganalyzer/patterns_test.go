@@ -0,0 +1,114 @@
+package loganalyzer
+
+import (
+	"testing"
+
+	"github.com/ilyxenc/rattle/internal/config"
+	"github.com/ilyxenc/rattle/internal/docker"
+	"github.com/ilyxenc/rattle/internal/managers"
+	"github.com/ilyxenc/rattle/internal/models"
+	"gorm.io/gorm"
+)
+
+func TestDetectEventTypePrecedence(t *testing.T) {
+	rule := func(id uint, eventType, regex string, priority int, stream, container string) models.LogExclusion {
+		return models.LogExclusion{
+			Model:     gorm.Model{ID: id},
+			Pattern:   regex,
+			MatchType: models.MatchTypeInclude,
+			EventType: eventType,
+			Priority:  priority,
+			Stream:    stream,
+			Container: container,
+		}
+	}
+
+	managers.Logs.Load([]models.LogExclusion{
+		rule(1, models.EventTypeError, `timeout`, 0, "", ""),
+		rule(2, models.EventTypeWarning, `timeout`, 0, "", ""),
+		rule(3, models.EventTypeInfo, `timeout`, 0, models.StreamStdout, ""), // Applies, but loses to error on severity
+		rule(4, models.EventTypeInfo, `retry`, 10, "", ""),
+		rule(5, models.EventTypeCritical, `retry`, 0, "", ""),
+		rule(6, models.EventTypeSuccess, `deploy`, 5, models.StreamStderr, ""),
+		rule(7, models.EventTypeWarning, `deploy`, 0, "", ""),
+		rule(8, models.EventTypeCritical, `disk`, 0, "", "image=postgres"),
+		rule(9, models.EventTypeWarning, `disk`, 0, "", ""),
+		rule(10, models.EventTypeError, `oom`, 1, "", ""),
+		rule(11, models.EventTypeCritical, `oom`, 1, "", ""),
+		{Model: gorm.Model{ID: 12}, Pattern: `healthcheck`, MatchType: models.MatchTypeExclude},
+	})
+	t.Cleanup(func() { managers.Logs.Load(nil) })
+
+	api := docker.ContainerInfo{ID: "1", Name: "api", Image: "api:latest"}
+	db := docker.ContainerInfo{ID: "2", Name: "db", Image: "postgres:16"}
+
+	tests := []struct {
+		name   string
+		ci     docker.ContainerInfo
+		stream string
+		text   string
+		want   string
+	}{
+		{"equal priority picks the most severe type", api, models.StreamStdout, "request timeout", models.EventTypeError},
+		{"priority beats severity", api, models.StreamStdout, "will retry", models.EventTypeInfo},
+		{"priority tie picks the most severe type", api, models.StreamStdout, "oom killed", models.EventTypeCritical},
+		{"stream scoped rule applies to its stream", api, models.StreamStderr, "deploy done", models.EventTypeSuccess},
+		{"stream scoped rule is skipped on another stream", api, models.StreamStdout, "deploy done", models.EventTypeWarning},
+		{"container scoped rule applies to its container", db, models.StreamStdout, "disk full", models.EventTypeCritical},
+		{"container scoped rule is skipped for other containers", api, models.StreamStdout, "disk full", models.EventTypeWarning},
+		{"exclusion wins", api, models.StreamStdout, "healthcheck timeout", ""},
+		{"no match", api, models.StreamStdout, "all good", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := DetectEventType(tt.ci, LogEntry{Text: tt.text, Stream: tt.stream})
+			if got != tt.want {
+				t.Errorf("DetectEventType(%s, %s, %q) = %q, want %q", tt.ci.Name, tt.stream, tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDetectEventTypeStructured(t *testing.T) {
+	prev := config.Cfg
+	config.Cfg = &config.Config{Structured: config.Structured{Enabled: true, MinLevel: models.EventTypeWarning}}
+	t.Cleanup(func() { config.Cfg = prev })
+
+	managers.Logs.Load([]models.LogExclusion{
+		{Model: gorm.Model{ID: 1}, Pattern: `(?i)\bpanic\b`, MatchType: models.MatchTypeInclude, EventType: models.EventTypeError},
+		{Model: gorm.Model{ID: 2}, Pattern: `^5\d\d$`, Field: "status", MatchType: models.MatchTypeInclude, EventType: models.EventTypeCritical},
+	})
+	t.Cleanup(func() { managers.Logs.Load(nil) })
+
+	api := docker.ContainerInfo{ID: "1", Name: "api"}
+
+	tests := []struct {
+		name string
+		line string
+		want string
+	}{
+		{"level decides over raw patterns", `{"level":"info","msg":"recovered from panic"}`, ""},
+		{"level maps to event type", `{"level":"error","msg":"db down"}`, models.EventTypeError},
+		{"level below min level", `level=info msg="request done"`, ""},
+		{"field rule wins over level", `{"level":"info","msg":"request done","status":"503"}`, models.EventTypeCritical},
+		{"field rule applies without level", `{"msg":"request done","status":"502"}`, models.EventTypeCritical},
+		{"raw patterns apply without level", `{"msg":"panic: nil map"}`, models.EventTypeError},
+		{"debug level decides over raw patterns", `{"level":"debug","msg":"panic: nil map"}`, ""},
+		{"no level and no match", `{"msg":"all good"}`, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sl, ok := ParseStructured(tt.line)
+			if !ok {
+				t.Fatalf("ParseStructured(%q) failed", tt.line)
+			}
+
+			got := DetectEventType(api, LogEntry{Text: tt.line, Stream: models.StreamStdout, Structured: sl})
+			if got != tt.want {
+				t.Errorf("DetectEventType(%q) = %q, want %q", tt.line, got, tt.want)
+			}
+		})
+	}
+}
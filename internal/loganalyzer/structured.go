@@ -0,0 +1,208 @@
+package loganalyzer
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/ilyxenc/rattle/internal/models"
+)
+
+// Structured log formats
+const (
+	FormatJSON   = "json"
+	FormatLogfmt = "logfmt"
+)
+
+// levelKeys are field names commonly used for the log level, in order of preference
+var levelKeys = []string{"level", "lvl", "severity", "levelname", "log.level", "loglevel"}
+
+// messageKeys are field names commonly used for the log message, in order of preference
+var messageKeys = []string{"msg", "message", "@message", "event", "text"}
+
+// StructuredLog is a log line parsed from JSON or logfmt
+type StructuredLog struct {
+	Format  string            // FormatJSON / FormatLogfmt
+	Level   string            // Level value in lower case, empty if the line has no level
+	Message string            // Message value, empty if the line has no message
+	Fields  map[string]string // All top-level fields; nested values are kept as JSON
+}
+
+// ParseStructured detects and parses a JSON or logfmt log line
+func ParseStructured(line string) (*StructuredLog, bool) {
+	line = strings.TrimSpace(line)
+
+	var fields map[string]string
+	var format string
+
+	if strings.HasPrefix(line, "{") && strings.HasSuffix(line, "}") {
+		f, ok := parseJSONFields(line)
+		if !ok {
+			return nil, false
+		}
+		fields, format = f, FormatJSON
+	} else {
+		f, ok := parseLogfmtFields(line)
+		if !ok {
+			return nil, false
+		}
+		fields, format = f, FormatLogfmt
+	}
+
+	return &StructuredLog{
+		Format:  format,
+		Level:   strings.ToLower(firstField(fields, levelKeys)),
+		Message: firstField(fields, messageKeys),
+		Fields:  fields,
+	}, true
+}
+
+// EventType maps the log level to an event type, or returns empty string if the level is unknown or below `minLevel`
+func (sl *StructuredLog) EventType(minLevel string) string {
+	eventType := levelToEventType(sl.Level)
+	if eventType == "" || models.EventTypeSeverity[eventType] < models.EventTypeSeverity[strings.ToLower(minLevel)] {
+		return ""
+	}
+	return eventType
+}
+
+// levelToEventType maps common level names and numeric levels (pino, bunyan) to event types
+func levelToEventType(level string) string {
+	switch level {
+	case "fatal", "panic", "crit", "critical", "emerg", "emergency", "alert", "dpanic":
+		return models.EventTypeCritical
+	case "error", "err", "eror":
+		return models.EventTypeError
+	case "warn", "warning", "wrn":
+		return models.EventTypeWarning
+	case "info", "inf", "notice", "information":
+		return models.EventTypeInfo
+	}
+
+	// Numeric levels: 60 fatal, 50 error, 40 warn, 30 info
+	if n, err := strconv.Atoi(level); err == nil {
+		switch {
+		case n >= 60:
+			return models.EventTypeCritical
+		case n >= 50:
+			return models.EventTypeError
+		case n >= 40:
+			return models.EventTypeWarning
+		case n >= 30:
+			return models.EventTypeInfo
+		}
+	}
+
+	return ""
+}
+
+// parseJSONFields parses a JSON object and converts its top-level values to strings
+func parseJSONFields(line string) (map[string]string, bool) {
+	var raw map[string]any
+	if err := json.Unmarshal([]byte(line), &raw); err != nil {
+		return nil, false
+	}
+
+	fields := make(map[string]string, len(raw))
+	for k, v := range raw {
+		switch val := v.(type) {
+		case string:
+			fields[k] = val
+		case nil:
+			fields[k] = ""
+		case float64, bool:
+			fields[k] = fmt.Sprint(val)
+		default:
+			b, _ := json.Marshal(val)
+			fields[k] = string(b)
+		}
+
+		// Flatten one level of nesting for keys like {"log":{"level":"error"}}
+		if obj, ok := v.(map[string]any); ok {
+			for nk, nv := range obj {
+				if s, ok := nv.(string); ok {
+					fields[k+"."+nk] = s
+				}
+			}
+		}
+	}
+
+	return fields, true
+}
+
+// parseLogfmtFields parses a logfmt line (key=value key2="quoted value").
+// The line is considered logfmt only if it consists of at least two pairs and has a level or message key
+func parseLogfmtFields(line string) (map[string]string, bool) {
+	fields := make(map[string]string)
+
+	for i := 0; i < len(line); {
+		// Skip spaces between pairs
+		for i < len(line) && line[i] == ' ' {
+			i++
+		}
+		if i >= len(line) {
+			break
+		}
+
+		// Key runs until '=' or space
+		start := i
+		for i < len(line) && line[i] != '=' && line[i] != ' ' {
+			i++
+		}
+		key := line[start:i]
+		if key == "" || i >= len(line) || line[i] != '=' {
+			return nil, false // Not a key=value pair
+		}
+		i++ // Skip '='
+
+		// Value is quoted or runs until space
+		var value string
+		if i < len(line) && line[i] == '"' {
+			end := i + 1
+			for end < len(line) && line[end] != '"' {
+				if line[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			if end >= len(line) {
+				return nil, false // Unterminated quote
+			}
+
+			unquoted, err := strconv.Unquote(line[i : end+1])
+			if err != nil {
+				unquoted = line[i+1 : end]
+			}
+			value = unquoted
+			i = end + 1
+		} else {
+			start = i
+			for i < len(line) && line[i] != ' ' {
+				i++
+			}
+			value = line[start:i]
+		}
+
+		fields[key] = value
+	}
+
+	if len(fields) < 2 {
+		return nil, false
+	}
+	if firstField(fields, levelKeys) == "" && firstField(fields, messageKeys) == "" {
+		return nil, false
+	}
+
+	return fields, true
+}
+
+// firstField returns the value of the first key present in the fields
+func firstField(fields map[string]string, keys []string) string {
+	for _, k := range keys {
+		if v, ok := fields[k]; ok && v != "" {
+			return v
+		}
+	}
+	return ""
+}
@@ -0,0 +1,169 @@
+package loganalyzer
+
+import (
+	"maps"
+	"testing"
+
+	"github.com/ilyxenc/rattle/internal/models"
+)
+
+func TestParseStructured(t *testing.T) {
+	tests := []struct {
+		name    string
+		line    string
+		ok      bool
+		format  string
+		level   string
+		message string
+		fields  map[string]string // Expected fields, only checked if set
+	}{
+		{
+			name:    "json",
+			line:    `{"level":"ERROR","msg":"db down","status":503,"retry":true,"err":null}`,
+			ok:      true,
+			format:  FormatJSON,
+			level:   "error",
+			message: "db down",
+			fields:  map[string]string{"level": "ERROR", "msg": "db down", "status": "503", "retry": "true", "err": ""},
+		},
+		{
+			name:    "json nested level",
+			line:    `  {"log":{"level":"warn"},"message":"slow"}  `,
+			ok:      true,
+			format:  FormatJSON,
+			level:   "warn",
+			message: "slow",
+			fields:  map[string]string{"log": `{"level":"warn"}`, "log.level": "warn", "message": "slow"},
+		},
+		{
+			name:    "json numeric level",
+			line:    `{"level":50,"msg":"failed"}`,
+			ok:      true,
+			format:  FormatJSON,
+			level:   "50",
+			message: "failed",
+		},
+		{
+			name:    "json without level",
+			line:    `{"msg":"panic: nil map"}`,
+			ok:      true,
+			format:  FormatJSON,
+			message: "panic: nil map",
+		},
+		{
+			name: "invalid json",
+			line: `{"level":"error"`,
+		},
+		{
+			name:    "logfmt",
+			line:    `ts=2025-06-01T10:00:00Z lvl=info msg=started port=8080`,
+			ok:      true,
+			format:  FormatLogfmt,
+			level:   "info",
+			message: "started",
+			fields:  map[string]string{"ts": "2025-06-01T10:00:00Z", "lvl": "info", "msg": "started", "port": "8080"},
+		},
+		{
+			name:    "logfmt quoted values",
+			line:    `level=error msg="connection \"db\" refused" caller=db.go:12 empty=""`,
+			ok:      true,
+			format:  FormatLogfmt,
+			level:   "error",
+			message: `connection "db" refused`,
+			fields:  map[string]string{"level": "error", "msg": `connection "db" refused`, "caller": "db.go:12", "empty": ""},
+		},
+		{
+			name: "logfmt unterminated quote",
+			line: `level=error msg="connection refused`,
+		},
+		{
+			name: "logfmt without level or message",
+			line: `a=1 b=2`,
+		},
+		{
+			name: "single pair",
+			line: `level=error`,
+		},
+		{
+			name: "plain text",
+			line: `ERROR connection refused`,
+		},
+		{
+			name: "text with an equals sign",
+			line: `retrying with timeout=5s`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sl, ok := ParseStructured(tt.line)
+			if ok != tt.ok {
+				t.Fatalf("ParseStructured(%q) ok = %v, want %v", tt.line, ok, tt.ok)
+			}
+			if !ok {
+				return
+			}
+
+			if sl.Format != tt.format || sl.Level != tt.level || sl.Message != tt.message {
+				t.Errorf("parsed %s level %q message %q, want %s level %q message %q",
+					sl.Format, sl.Level, sl.Message, tt.format, tt.level, tt.message)
+			}
+			if tt.fields != nil && !maps.Equal(sl.Fields, tt.fields) {
+				t.Errorf("fields = %v, want %v", sl.Fields, tt.fields)
+			}
+		})
+	}
+}
+
+func TestLevelToEventType(t *testing.T) {
+	tests := map[string]string{
+		"fatal":   models.EventTypeCritical,
+		"panic":   models.EventTypeCritical,
+		"dpanic":  models.EventTypeCritical,
+		"crit":    models.EventTypeCritical,
+		"error":   models.EventTypeError,
+		"err":     models.EventTypeError,
+		"eror":    models.EventTypeError,
+		"warn":    models.EventTypeWarning,
+		"warning": models.EventTypeWarning,
+		"info":    models.EventTypeInfo,
+		"notice":  models.EventTypeInfo,
+		"debug":   "",
+		"trace":   "",
+		"":        "",
+		"60":      models.EventTypeCritical,
+		"50":      models.EventTypeError,
+		"40":      models.EventTypeWarning,
+		"30":      models.EventTypeInfo,
+		"20":      "",
+	}
+
+	for level, want := range tests {
+		if got := levelToEventType(level); got != want {
+			t.Errorf("levelToEventType(%q) = %q, want %q", level, got, want)
+		}
+	}
+}
+
+func TestStructuredEventTypeMinLevel(t *testing.T) {
+	tests := []struct {
+		level    string
+		minLevel string
+		want     string
+	}{
+		{"error", "warning", models.EventTypeError},
+		{"warn", "warning", models.EventTypeWarning},
+		{"info", "warning", ""},
+		{"info", "INFO", models.EventTypeInfo},
+		{"fatal", "critical", models.EventTypeCritical},
+		{"error", "critical", ""},
+		{"debug", "info", ""},
+	}
+
+	for _, tt := range tests {
+		sl := &StructuredLog{Level: tt.level}
+		if got := sl.EventType(tt.minLevel); got != tt.want {
+			t.Errorf("EventType(%q) of level %q = %q, want %q", tt.minLevel, tt.level, got, tt.want)
+		}
+	}
+}
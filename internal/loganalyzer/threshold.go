@@ -0,0 +1,125 @@
+package loganalyzer
+
+import (
+	"sync"
+	"time"
+
+	"github.com/ilyxenc/rattle/internal/docker"
+	"github.com/ilyxenc/rattle/internal/managers"
+	"github.com/ilyxenc/rattle/internal/notify"
+)
+
+// ThresholdCounter counts matches of threshold rules per container in a sliding window
+type ThresholdCounter struct {
+	mu      sync.Mutex
+	matches map[uint]map[string][]time.Time // Key = rule ID, then container ID, value = times of matches within the window
+	counted map[uint]string                 // Key = rule ID, value = signature of the rule the matches were counted for
+}
+
+// thresholds is the global sliding window counter
+var thresholds = NewThresholdCounter()
+
+// NewThresholdCounter creates an empty counter
+func NewThresholdCounter() *ThresholdCounter {
+	return &ThresholdCounter{
+		matches: make(map[uint]map[string][]time.Time),
+		counted: make(map[uint]string),
+	}
+}
+
+// Observe counts the entry for every matching threshold rule and returns notifications
+// for rules that reached their count within the window. The counter of a fired rule is reset
+func (tc *ThresholdCounter) Observe(c docker.ContainerInfo, e LogEntry) []notify.Notification {
+	now := e.Time
+	if now.IsZero() {
+		now = time.Now()
+	}
+
+	var fired []notify.Notification
+
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+
+	for _, r := range managers.Thresholds.All() {
+		if !r.Applies(c, e.Stream) || !ruleMatches(r.LogRule, e) {
+			continue
+		}
+
+		// Matches counted before the rule was edited don't count towards the new definition
+		if signature := r.Signature(); tc.counted[r.ID] != signature {
+			delete(tc.matches, r.ID)
+			tc.counted[r.ID] = signature
+		}
+
+		byContainer := tc.matches[r.ID]
+		if byContainer == nil {
+			byContainer = make(map[string][]time.Time)
+			tc.matches[r.ID] = byContainer
+		}
+
+		// Drop matches that left the window
+		times := byContainer[c.ID]
+		cutoff := now.Add(-r.Window)
+		i := 0
+		for i < len(times) && !times[i].After(cutoff) {
+			i++
+		}
+		times = append(times[i:], now)
+
+		if len(times) < r.Count {
+			byContainer[c.ID] = times
+			continue
+		}
+
+		delete(byContainer, c.ID)
+		fired = append(fired, notify.Notification{
+			Type:      notify.NotificationThreshold,
+			EventType: r.EventType,
+			Details:   e.Text,
+			Stream:    e.Stream,
+			Time:      e.Time,
+			Container: c,
+			Rule:      r.Pattern,
+			Count:     len(times),
+			Window:    r.Window,
+		})
+	}
+
+	return fired
+}
+
+// Forget drops the matches counted in the container, called when its scanner stops
+func (tc *ThresholdCounter) Forget(containerID string) {
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+
+	for id, byContainer := range tc.matches {
+		delete(byContainer, containerID)
+		if len(byContainer) == 0 {
+			delete(tc.matches, id)
+		}
+	}
+}
+
+// Prune drops the matches of rules that are no longer among `rules` or were edited, called when threshold rules are reloaded
+func (tc *ThresholdCounter) Prune(rules []managers.ThresholdRule) {
+	current := make(map[uint]string, len(rules))
+	for _, r := range rules {
+		current[r.ID] = r.Signature()
+	}
+
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+
+	for id, signature := range tc.counted {
+		if current[id] != signature {
+			delete(tc.matches, id)
+			delete(tc.counted, id)
+		}
+	}
+}
+
+// ForgetContainer drops the analysis state of the container, called when its scanner stops
+func ForgetContainer(containerID string) {
+	thresholds.Forget(containerID)
+}
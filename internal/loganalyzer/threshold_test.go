@@ -0,0 +1,123 @@
+package loganalyzer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ilyxenc/rattle/internal/docker"
+	"github.com/ilyxenc/rattle/internal/managers"
+	"github.com/ilyxenc/rattle/internal/models"
+	"gorm.io/gorm"
+)
+
+func TestThresholdCounterState(t *testing.T) {
+	rule := func(id uint, pattern string) models.ThresholdRule {
+		return models.ThresholdRule{Model: gorm.Model{ID: id}, Pattern: pattern, EventType: models.EventTypeError, Count: 3, Window: 60}
+	}
+	managers.Thresholds.Load([]models.ThresholdRule{rule(1, `timeout`), rule(2, `refused`)})
+	t.Cleanup(func() { managers.Thresholds.Load(nil) })
+
+	tc := NewThresholdCounter()
+	api := docker.ContainerInfo{ID: "api", Name: "api"}
+	db := docker.ContainerInfo{ID: "db", Name: "db"}
+	at := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+
+	observe := func(c docker.ContainerInfo, text string, sec int) int {
+		return len(tc.Observe(c, LogEntry{Text: text, Stream: models.StreamStdout, Time: at.Add(time.Duration(sec) * time.Second)}))
+	}
+	counted := func(id uint, container string) int {
+		tc.mu.Lock()
+		defer tc.mu.Unlock()
+		return len(tc.matches[id][container])
+	}
+
+	observe(api, "read timeout", 0)
+	observe(api, "connection refused", 1)
+	observe(db, "read timeout", 2)
+	observe(db, "connection refused", 3)
+
+	// A stopped container leaves no counts behind, others keep theirs
+	tc.Forget("api")
+	if counted(1, "api") != 0 || counted(2, "api") != 0 {
+		t.Error("matches of a forgotten container are kept")
+	}
+	if counted(1, "db") != 1 || counted(2, "db") != 1 {
+		t.Error("matches of another container were dropped")
+	}
+
+	// Counting starts over after the container comes back
+	observe(api, "read timeout", 4)
+	if n := observe(api, "read timeout", 5); n != 0 {
+		t.Errorf("rule fired after 2 matches since the container was forgotten, want 3")
+	}
+	if n := observe(api, "read timeout", 6); n != 1 {
+		t.Errorf("rule didn't fire on the third match")
+	}
+
+	// Rule 2 is removed: its counts are dropped on reload
+	managers.Thresholds.Load([]models.ThresholdRule{rule(1, `timeout`)})
+	tc.Prune(managers.Thresholds.All())
+
+	tc.mu.Lock()
+	_, kept := tc.matches[1]
+	_, removed := tc.matches[2]
+	tc.mu.Unlock()
+	if !kept || removed {
+		t.Errorf("after pruning rule 1 kept = %v, rule 2 kept = %v, want only rule 1", kept, removed)
+	}
+
+	// Forgetting the last container of a rule leaves no empty entries
+	tc.Forget("db")
+	tc.mu.Lock()
+	left := len(tc.matches)
+	tc.mu.Unlock()
+	if left != 0 {
+		t.Errorf("%d rules left with no counted matches, want none", left)
+	}
+}
+
+func TestThresholdRuleEdited(t *testing.T) {
+	rule := models.ThresholdRule{Model: gorm.Model{ID: 1}, Pattern: `timeout`, EventType: models.EventTypeError, Count: 3, Window: 60}
+	managers.Thresholds.Load([]models.ThresholdRule{rule})
+	t.Cleanup(func() { managers.Thresholds.Load(nil) })
+
+	tc := NewThresholdCounter()
+	api := docker.ContainerInfo{ID: "api", Name: "api"}
+	at := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	observe := func(sec int) int {
+		return len(tc.Observe(api, LogEntry{Text: "read timeout", Stream: models.StreamStdout, Time: at.Add(time.Duration(sec) * time.Second)}))
+	}
+
+	observe(0)
+	observe(1)
+
+	// Lowering the count starts over instead of firing on the old matches
+	rule.Count = 2
+	managers.Thresholds.Load([]models.ThresholdRule{rule})
+	if n := observe(2); n != 0 {
+		t.Error("edited rule fired on matches counted before the edit")
+	}
+	if n := observe(3); n != 1 {
+		t.Error("edited rule didn't fire on its own count")
+	}
+
+	// Pruning drops the matches of an edited rule as well
+	observe(4)
+	rule.Window = 120
+	managers.Thresholds.Load([]models.ThresholdRule{rule})
+	tc.Prune(managers.Thresholds.All())
+	tc.mu.Lock()
+	_, kept := tc.matches[1]
+	tc.mu.Unlock()
+	if kept {
+		t.Error("matches of an edited rule were kept after pruning")
+	}
+
+	// Unchanged rules keep their matches on reload
+	observe(5)
+	managers.Thresholds.Load([]models.ThresholdRule{rule})
+	tc.Prune(managers.Thresholds.All())
+	if n := observe(6); n != 1 {
+		t.Error("reloading an unchanged rule dropped its matches")
+	}
+}
@@ -0,0 +1,12 @@
+package loganalyzer
+
+import "time"
+
+// LogEntry is a log event read from a container
+type LogEntry struct {
+	Text   string    // Single line or a group of lines (e.g. stack trace)
+	Stream string    // models.StreamStdout / StreamStderr
+	Time   time.Time // Docker timestamp of the first line
+
+	Structured *StructuredLog // Parsed JSON/logfmt line, nil if the line is not structured or parsing is disabled
+}
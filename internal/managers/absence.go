@@ -0,0 +1,75 @@
+package managers
+
+import (
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ilyxenc/rattle/internal/database"
+	"github.com/ilyxenc/rattle/internal/logger"
+	"github.com/ilyxenc/rattle/internal/models"
+)
+
+// AbsenceRule is a compiled absence rule
+type AbsenceRule struct {
+	LogRule
+	Pattern    string        // Source pattern, shown in alerts
+	MaxSilence time.Duration // Longest allowed period without a matching line
+}
+
+// AbsenceManager keeps compiled absence rules in memory
+type AbsenceManager struct {
+	mu    sync.RWMutex
+	rules []AbsenceRule
+}
+
+// Absences is the global absence manager instance
+var Absences = &AbsenceManager{}
+
+// Reload fetches absence rules from DB and compiles them
+func (am *AbsenceManager) Reload() error {
+	var all []models.AbsenceRule
+
+	if err := database.DB.Find(&all).Error; err != nil {
+		return err
+	}
+
+	rules := make([]AbsenceRule, 0, len(all))
+	for _, r := range all {
+		pattern := strings.TrimSpace(r.Pattern)
+		if pattern == "" || r.MaxSilence <= 0 {
+			continue
+		}
+
+		regex, err := regexp.Compile(pattern)
+		if err != nil {
+			logger.Log.Warnf("Invalid absence regex pattern: %s", pattern)
+			continue
+		}
+
+		rules = append(rules, AbsenceRule{
+			LogRule: LogRule{
+				Regex:     regex,
+				EventType: strings.ToLower(r.EventType),
+				Container: r.Container,
+				ID:        r.ID,
+			},
+			Pattern:    pattern,
+			MaxSilence: time.Duration(r.MaxSilence) * time.Second,
+		})
+	}
+
+	am.mu.Lock()
+	am.rules = rules
+	am.mu.Unlock()
+
+	return nil
+}
+
+// All returns compiled absence rules
+func (am *AbsenceManager) All() []AbsenceRule {
+	am.mu.RLock()
+	defer am.mu.RUnlock()
+	return am.rules
+}
@@ -0,0 +1,40 @@
+package managers
+
+import (
+	"sync"
+
+	"github.com/ilyxenc/rattle/internal/database"
+	"github.com/ilyxenc/rattle/internal/models"
+	"golang.org/x/exp/slices"
+)
+
+// ChannelManager keeps enabled notification channels in memory
+type ChannelManager struct {
+	mu       sync.RWMutex
+	channels []models.Channel
+}
+
+// Channels is the global channel manager instance
+var Channels = &ChannelManager{}
+
+// Reload fetches enabled notification channels from DB
+func (cm *ChannelManager) Reload() error {
+	var channels []models.Channel
+
+	if err := database.DB.Where("enabled = ?", true).Order("id").Find(&channels).Error; err != nil {
+		return err
+	}
+
+	cm.mu.Lock()
+	cm.channels = channels
+	cm.mu.Unlock()
+
+	return nil
+}
+
+// All returns a copy of enabled notification channels
+func (cm *ChannelManager) All() []models.Channel {
+	cm.mu.RLock()
+	defer cm.mu.RUnlock()
+	return slices.Clone(cm.channels)
+}
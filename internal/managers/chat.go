@@ -10,8 +10,9 @@ import (
 
 // ChatManager is responsible for managing active chat IDs in memory and keeping them in sync with the database
 type ChatManager struct {
-	mu      sync.RWMutex // Read-write mutex to protect concurrent access
-	chatIDs []string     // Cached list of active chat IDs
+	mu      sync.RWMutex  // Read-write mutex to protect concurrent access
+	chatIDs []string      // Cached list of active chat IDs
+	chats   []models.Chat // Cached active chats with their forum topics
 }
 
 // Chats is a globally accessible instance of ChatManager
@@ -32,6 +33,7 @@ func (cm *ChatManager) Reload() error {
 
 	cm.mu.Lock()
 	cm.chatIDs = ids
+	cm.chats = chats
 	cm.mu.Unlock()
 
 	return nil
@@ -43,3 +45,10 @@ func (cm *ChatManager) All() []string {
 	defer cm.mu.RUnlock()
 	return slices.Clone(cm.chatIDs)
 }
+
+// List returns a copy of all active chats stored in memory
+func (cm *ChatManager) List() []models.Chat {
+	cm.mu.RLock()
+	defer cm.mu.RUnlock()
+	return slices.Clone(cm.chats)
+}
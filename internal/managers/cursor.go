@@ -0,0 +1,117 @@
+package managers
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/ilyxenc/rattle/internal/database"
+	"github.com/ilyxenc/rattle/internal/logger"
+	"github.com/ilyxenc/rattle/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// CursorManager keeps per-container log cursors in memory and periodically persists them to the database
+type CursorManager struct {
+	mu    sync.Mutex
+	cache map[string]models.LogCursor // Key = container ID
+	dirty map[string]struct{}         // Container IDs with cursors not yet saved
+}
+
+// Cursors is the global cursor manager instance
+var Cursors = &CursorManager{
+	cache: make(map[string]models.LogCursor),
+	dirty: make(map[string]struct{}),
+}
+
+// Get returns the cursor for the container from memory or the database
+func (cm *CursorManager) Get(containerID string) (models.LogCursor, bool) {
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+
+	if c, ok := cm.cache[containerID]; ok {
+		return c, true
+	}
+
+	var c models.LogCursor
+	err := database.DB.Where("container_id = ?", containerID).First(&c).Error
+	if err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			logger.Log.Warnf("Failed to load log cursor for %s: %v", containerID, err)
+		}
+		return models.LogCursor{}, false
+	}
+
+	cm.cache[containerID] = c
+	return c, true
+}
+
+// Set updates the cursor in memory. It's saved to the database on the next Flush
+func (cm *CursorManager) Set(containerID string, ts time.Time, seq int) {
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+
+	c := cm.cache[containerID]
+	c.ContainerID = containerID
+	c.UnixNano = ts.UnixNano()
+	c.Seq = seq
+
+	cm.cache[containerID] = c
+	cm.dirty[containerID] = struct{}{}
+}
+
+// Delete removes the cursor from memory and the database
+func (cm *CursorManager) Delete(containerID string) error {
+	cm.mu.Lock()
+	delete(cm.cache, containerID)
+	delete(cm.dirty, containerID)
+	cm.mu.Unlock()
+
+	return database.DB.Unscoped().Where("container_id = ?", containerID).Delete(&models.LogCursor{}).Error
+}
+
+// Flush saves all changed cursors to the database
+func (cm *CursorManager) Flush() error {
+	cm.mu.Lock()
+	cursors := make([]models.LogCursor, 0, len(cm.dirty))
+	for id := range cm.dirty {
+		c := cm.cache[id]
+		c.ID = 0 // Let the upsert resolve the row by container ID
+		cursors = append(cursors, c)
+	}
+	cm.dirty = make(map[string]struct{})
+	cm.mu.Unlock()
+
+	if len(cursors) == 0 {
+		return nil
+	}
+
+	err := database.DB.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "container_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"unix_nano", "seq", "updated_at"}),
+	}).Create(&cursors).Error
+	if err != nil {
+		// Mark cursors as dirty again to retry on the next flush
+		cm.mu.Lock()
+		for _, c := range cursors {
+			cm.dirty[c.ContainerID] = struct{}{}
+		}
+		cm.mu.Unlock()
+	}
+
+	return err
+}
+
+// StartCursorFlusher periodically saves changed cursors to the database
+func StartCursorFlusher(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+
+	go func() {
+		for range ticker.C {
+			if err := Cursors.Flush(); err != nil {
+				logger.Log.Warnf("Failed to save log cursors: %v", err)
+			}
+		}
+	}()
+}
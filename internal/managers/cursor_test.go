@@ -0,0 +1,59 @@
+package managers
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/ilyxenc/rattle/internal/database"
+	"github.com/ilyxenc/rattle/internal/models"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+// TestCursorReload saves a cursor to Postgres and loads it back. Runs only with TEST_POSTGRES_DSN set
+func TestCursorReload(t *testing.T) {
+	dsn := os.Getenv("TEST_POSTGRES_DSN")
+	if dsn == "" {
+		t.Skip("TEST_POSTGRES_DSN is not set")
+	}
+
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.AutoMigrate(&models.LogCursor{}); err != nil {
+		t.Fatal(err)
+	}
+	database.DB = db
+
+	// Docker timestamps have nanoseconds, Postgres timestamps only microseconds
+	ts := time.Date(2025, 6, 1, 10, 0, 0, 123456789, time.UTC)
+	saved := &CursorManager{cache: make(map[string]models.LogCursor), dirty: make(map[string]struct{})}
+	saved.Set("reload", ts, 2)
+	if err := saved.Flush(); err != nil {
+		t.Fatalf("Flush: %v", err)
+	}
+	t.Cleanup(func() { _ = saved.Delete("reload") })
+
+	loaded := &CursorManager{cache: make(map[string]models.LogCursor), dirty: make(map[string]struct{})}
+	c, ok := loaded.Get("reload")
+	if !ok {
+		t.Fatal("cursor not found after reload")
+	}
+	if !c.Time().Equal(ts) || c.Seq != 2 {
+		t.Errorf("reloaded cursor = %s #%d, want %s #2", c.Time().Format(time.RFC3339Nano), c.Seq, ts.Format(time.RFC3339Nano))
+	}
+}
+
+func TestCursorTime(t *testing.T) {
+	ts := time.Date(2025, 6, 1, 10, 0, 0, 123456789, time.UTC)
+
+	cm := &CursorManager{cache: make(map[string]models.LogCursor), dirty: make(map[string]struct{})}
+	cm.Set("c1", ts, 0)
+
+	c, _ := cm.Get("c1")
+	if !c.Time().Equal(ts) {
+		t.Errorf("Time() = %s, want %s", c.Time().Format(time.RFC3339Nano), ts.Format(time.RFC3339Nano))
+	}
+}
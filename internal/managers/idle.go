@@ -0,0 +1,69 @@
+package managers
+
+import (
+	"sync"
+	"time"
+
+	"github.com/ilyxenc/rattle/internal/database"
+	"github.com/ilyxenc/rattle/internal/docker"
+	"github.com/ilyxenc/rattle/internal/models"
+)
+
+// IdleRule is an idle threshold for containers matching the selector
+type IdleRule struct {
+	Container string        // Container selector, empty for any container
+	Timeout   time.Duration // Longest allowed period without log lines
+}
+
+// IdleManager keeps idle rules in memory
+type IdleManager struct {
+	mu    sync.RWMutex
+	rules []IdleRule
+}
+
+// Idles is the global idle manager instance
+var Idles = &IdleManager{}
+
+// Reload fetches idle rules from DB
+func (im *IdleManager) Reload() error {
+	var all []models.IdleRule
+
+	if err := database.DB.Find(&all).Error; err != nil {
+		return err
+	}
+
+	rules := make([]IdleRule, 0, len(all))
+	for _, r := range all {
+		if r.Timeout <= 0 {
+			continue
+		}
+
+		rules = append(rules, IdleRule{
+			Container: r.Container,
+			Timeout:   time.Duration(r.Timeout) * time.Second,
+		})
+	}
+
+	im.mu.Lock()
+	im.rules = rules
+	im.mu.Unlock()
+
+	return nil
+}
+
+// Timeout returns the shortest idle threshold of the rules matching the container, or 0 if none match
+func (im *IdleManager) Timeout(ci docker.ContainerInfo) time.Duration {
+	im.mu.RLock()
+	defer im.mu.RUnlock()
+
+	var timeout time.Duration
+	for _, r := range im.rules {
+		if !docker.MatchSelector(ci, r.Container) {
+			continue
+		}
+		if timeout == 0 || r.Timeout < timeout {
+			timeout = r.Timeout
+		}
+	}
+	return timeout
+}
@@ -11,6 +11,15 @@ func Init() {
 	if err := Chats.Reload(); err != nil {
 		logger.Log.Fatalf("Failed to load chat IDs: %v", err)
 	}
+	if err := Channels.Reload(); err != nil {
+		logger.Log.Fatalf("Failed to load notification channels: %v", err)
+	}
+	if err := Routes.Reload(); err != nil {
+		logger.Log.Fatalf("Failed to load notification routes: %v", err)
+	}
+	if err := Mutes.Reload(); err != nil {
+		logger.Log.Fatalf("Failed to load mutes: %v", err)
+	}
 	if err := Mode.Reload(); err != nil {
 		logger.Log.Fatalf("Failed to load mode: %v", err)
 	}
@@ -20,6 +29,15 @@ func Init() {
 	if err := Logs.Reload(); err != nil {
 		logger.Log.Fatalf("Failed to load logs exclusions: %v", err)
 	}
+	if err := Thresholds.Reload(); err != nil {
+		logger.Log.Fatalf("Failed to load threshold rules: %v", err)
+	}
+	if err := Absences.Reload(); err != nil {
+		logger.Log.Fatalf("Failed to load absence rules: %v", err)
+	}
+	if err := Idles.Reload(); err != nil {
+		logger.Log.Fatalf("Failed to load idle rules: %v", err)
+	}
 
 	// Register table watchers (no duplicate interval)
 	AddWatcher("log_exclusions", []string{"updated_at", "deleted_at"}, func() {
@@ -27,6 +45,31 @@ func Init() {
 			logger.Log.Warnf("Failed to reload log exclusions: %v", err)
 		}
 	})
+	AddWatcher("threshold_rules", []string{"updated_at", "deleted_at"}, func() {
+		if err := Thresholds.Reload(); err != nil {
+			logger.Log.Warnf("Failed to reload threshold rules: %v", err)
+		}
+	})
+	AddWatcher("absence_rules", []string{"updated_at", "deleted_at"}, func() {
+		if err := Absences.Reload(); err != nil {
+			logger.Log.Warnf("Failed to reload absence rules: %v", err)
+		}
+	})
+	AddWatcher("idle_rules", []string{"updated_at", "deleted_at"}, func() {
+		if err := Idles.Reload(); err != nil {
+			logger.Log.Warnf("Failed to reload idle rules: %v", err)
+		}
+	})
+	AddWatcher("routes", []string{"updated_at", "deleted_at"}, func() {
+		if err := Routes.Reload(); err != nil {
+			logger.Log.Warnf("Failed to reload notification routes: %v", err)
+		}
+	})
+	AddWatcher("mutes", []string{"updated_at", "deleted_at"}, func() {
+		if err := Mutes.Reload(); err != nil {
+			logger.Log.Warnf("Failed to reload mutes: %v", err)
+		}
+	})
 	AddWatcher("modes", []string{"updated_at", "deleted_at"}, func() {
 		if err := Mode.Reload(); err != nil {
 			logger.Log.Warnf("Failed to reload mode: %v", err)
@@ -42,7 +85,15 @@ func Init() {
 			logger.Log.Warnf("Failed to reload chat IDs: %v", err)
 		}
 	})
+	AddWatcher("channels", []string{"updated_at", "deleted_at"}, func() {
+		if err := Channels.Reload(); err != nil {
+			logger.Log.Warnf("Failed to reload notification channels: %v", err)
+		}
+	})
 
 	// Start polling every 15 seconds
 	StartWatchers(15 * time.Second)
+
+	// Save log cursors every 5 seconds
+	StartCursorFlusher(5 * time.Second)
 }
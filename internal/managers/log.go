@@ -2,23 +2,45 @@ package managers
 
 import (
 	"regexp"
+	"sort"
 	"strings"
 	"sync"
 
 	"github.com/ilyxenc/rattle/internal/database"
+	"github.com/ilyxenc/rattle/internal/docker"
 	"github.com/ilyxenc/rattle/internal/logger"
 	"github.com/ilyxenc/rattle/internal/models"
 )
 
+// LogRule is a compiled log pattern with the scope it applies to
+type LogRule struct {
+	Regex     *regexp.Regexp // Compiled pattern
+	EventType string         // Event type for include rules
+	Stream    string         // models.StreamStdout / StreamStderr, empty for any stream
+	Container string         // Container selector, empty for any container
+	Field     string         // Field of a structured line to match, empty for the raw line
+	Priority  int            // Explicit priority, higher is checked first
+	ID        uint           // Database ID, used to keep order stable
+}
+
+// Applies checks if the rule is scoped to the given container and stream
+func (r LogRule) Applies(ci docker.ContainerInfo, stream string) bool {
+	if r.Stream != "" && r.Stream != stream {
+		return false
+	}
+	return docker.MatchSelector(ci, r.Container)
+}
+
 type LogManager struct {
 	mu      sync.RWMutex
-	cache   map[string][]*regexp.Regexp // map[EventType] = compiled regex patterns
-	exclude []*regexp.Regexp            // exclude patterns (no event type)
+	cache   map[string][]LogRule // map[EventType] = compiled include rules
+	include []LogRule            // all include rules in precedence order
+	exclude []LogRule            // exclude rules (no event type)
 }
 
 // Logs is the global log manager instance
 var Logs = &LogManager{
-	cache: make(map[string][]*regexp.Regexp),
+	cache: make(map[string][]LogRule),
 }
 
 // Reload fetches log patterns from DB and compiles them
@@ -29,8 +51,15 @@ func (lm *LogManager) Reload() error {
 		return err
 	}
 
-	newCache := make(map[string][]*regexp.Regexp)
-	newExclude := make([]*regexp.Regexp, 0, len(patterns))
+	lm.Load(patterns)
+	return nil
+}
+
+// Load compiles the patterns and replaces the rules in memory. Invalid patterns are skipped
+func (lm *LogManager) Load(patterns []models.LogExclusion) {
+	newCache := make(map[string][]LogRule)
+	newInclude := make([]LogRule, 0, len(patterns))
+	newExclude := make([]LogRule, 0, len(patterns))
 
 	for _, p := range patterns {
 		pattern := strings.TrimSpace(p.Pattern)
@@ -44,37 +73,68 @@ func (lm *LogManager) Reload() error {
 			continue
 		}
 
+		rule := LogRule{
+			Regex:     regex,
+			EventType: strings.ToLower(p.EventType),
+			Stream:    strings.ToLower(p.Stream),
+			Container: p.Container,
+			Field:     strings.TrimSpace(p.Field),
+			Priority:  p.Priority,
+			ID:        p.ID,
+		}
+
 		if p.MatchType == models.MatchTypeExclude {
-			newExclude = append(newExclude, regex)
+			newExclude = append(newExclude, rule)
 		} else {
-			eventType := strings.ToLower(p.EventType)
-			newCache[eventType] = append(newCache[eventType], regex)
+			newCache[rule.EventType] = append(newCache[rule.EventType], rule)
+			newInclude = append(newInclude, rule)
 		}
 	}
 
+	sort.SliceStable(newInclude, func(i, j int) bool {
+		a, b := newInclude[i], newInclude[j]
+		if a.Priority != b.Priority {
+			return a.Priority > b.Priority
+		}
+		if sa, sb := models.EventTypeSeverity[a.EventType], models.EventTypeSeverity[b.EventType]; sa != sb {
+			return sa > sb
+		}
+		if a.EventType != b.EventType {
+			return a.EventType < b.EventType
+		}
+		return a.ID < b.ID
+	})
+
 	lm.mu.Lock()
 	lm.cache = newCache
+	lm.include = newInclude
 	lm.exclude = newExclude
 	lm.mu.Unlock()
-
-	return nil
 }
 
-// Include returns compiled patterns for the given event type
-func (lm *LogManager) Include(eventType string) []*regexp.Regexp {
+// Include returns compiled rules for the given event type
+func (lm *LogManager) Include(eventType string) []LogRule {
 	lm.mu.RLock()
 	defer lm.mu.RUnlock()
 	return lm.cache[strings.ToLower(eventType)]
 }
 
-// Exclude returns compiled exclusion patterns
-func (lm *LogManager) Exclude() []*regexp.Regexp {
+// IncludeOrdered returns all compiled include rules in precedence order:
+// by priority, then by event type severity (critical > error > warning > info > success)
+func (lm *LogManager) IncludeOrdered() []LogRule {
+	lm.mu.RLock()
+	defer lm.mu.RUnlock()
+	return lm.include
+}
+
+// Exclude returns compiled exclusion rules
+func (lm *LogManager) Exclude() []LogRule {
 	lm.mu.RLock()
 	defer lm.mu.RUnlock()
 	return lm.exclude
 }
 
-// KnownEventTypes returns a list of all event types that exist in memory
+// KnownEventTypes returns a list of all event types that exist in memory, from the most to the least severe
 func (lm *LogManager) KnownEventTypes() []string {
 	lm.mu.RLock()
 	defer lm.mu.RUnlock()
@@ -83,5 +143,13 @@ func (lm *LogManager) KnownEventTypes() []string {
 	for et := range lm.cache {
 		eventTypes = append(eventTypes, et)
 	}
+
+	sort.Slice(eventTypes, func(i, j int) bool {
+		si, sj := models.EventTypeSeverity[eventTypes[i]], models.EventTypeSeverity[eventTypes[j]]
+		if si != sj {
+			return si > sj
+		}
+		return eventTypes[i] < eventTypes[j]
+	})
 	return eventTypes
 }
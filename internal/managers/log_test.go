@@ -0,0 +1,132 @@
+package managers
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/ilyxenc/rattle/internal/logger"
+	"github.com/ilyxenc/rattle/internal/models"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+func TestMain(m *testing.M) {
+	logger.Log = zap.NewNop().Sugar()
+	os.Exit(m.Run())
+}
+
+// pattern returns an include pattern stored with the ID
+func pattern(id uint, eventType, regex string, priority int) models.LogExclusion {
+	return models.LogExclusion{
+		Model:     gorm.Model{ID: id},
+		Pattern:   regex,
+		MatchType: models.MatchTypeInclude,
+		EventType: eventType,
+		Priority:  priority,
+	}
+}
+
+func TestIncludeOrdered(t *testing.T) {
+	tests := []struct {
+		name     string
+		patterns []models.LogExclusion
+		want     []uint // IDs in precedence order
+	}{
+		{
+			name: "severity order on equal priority",
+			patterns: []models.LogExclusion{
+				pattern(1, models.EventTypeSuccess, "a", 0),
+				pattern(2, models.EventTypeInfo, "a", 0),
+				pattern(3, models.EventTypeCritical, "a", 0),
+				pattern(4, models.EventTypeWarning, "a", 0),
+				pattern(5, models.EventTypeError, "a", 0),
+			},
+			want: []uint{3, 5, 4, 2, 1},
+		},
+		{
+			name: "priority beats severity",
+			patterns: []models.LogExclusion{
+				pattern(1, models.EventTypeCritical, "a", 0),
+				pattern(2, models.EventTypeInfo, "a", 10),
+				pattern(3, models.EventTypeError, "a", 5),
+			},
+			want: []uint{2, 3, 1},
+		},
+		{
+			name: "ties of priority and severity keep database order",
+			patterns: []models.LogExclusion{
+				pattern(7, models.EventTypeError, "a", 1),
+				pattern(3, models.EventTypeError, "a", 1),
+				pattern(5, models.EventTypeError, "a", 1),
+			},
+			want: []uint{3, 5, 7},
+		},
+		{
+			name: "negative priority goes last",
+			patterns: []models.LogExclusion{
+				pattern(1, models.EventTypeCritical, "a", -1),
+				pattern(2, models.EventTypeSuccess, "a", 0),
+			},
+			want: []uint{2, 1},
+		},
+		{
+			name: "unknown event types follow known ones",
+			patterns: []models.LogExclusion{
+				pattern(1, "custom", "a", 0),
+				pattern(2, models.EventTypeSuccess, "a", 0),
+			},
+			want: []uint{2, 1},
+		},
+		{
+			name: "exclude, empty and invalid patterns are left out",
+			patterns: []models.LogExclusion{
+				{Model: gorm.Model{ID: 1}, Pattern: "a", MatchType: models.MatchTypeExclude},
+				pattern(2, models.EventTypeError, "  ", 0),
+				pattern(3, models.EventTypeError, "(unclosed", 0),
+				pattern(4, models.EventTypeError, "a", 0),
+			},
+			want: []uint{4},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lm := &LogManager{}
+			lm.Load(tt.patterns)
+
+			got := make([]uint, 0, len(tt.want))
+			for _, r := range lm.IncludeOrdered() {
+				got = append(got, r.ID)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("got rules %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Fatalf("got rules %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestLoadNormalizesScope(t *testing.T) {
+	lm := &LogManager{}
+	lm.Load([]models.LogExclusion{{
+		Model:     gorm.Model{ID: 1},
+		Pattern:   "timeout",
+		MatchType: models.MatchTypeInclude,
+		EventType: "ERROR",
+		Stream:    "STDERR",
+		Container: "image=nginx",
+	}})
+
+	rules := lm.Include(models.EventTypeError)
+	if len(rules) != 1 {
+		t.Fatalf("got %d error rules, want 1", len(rules))
+	}
+	if r := rules[0]; r.Stream != models.StreamStderr || r.Container != "image=nginx" || !strings.EqualFold(r.EventType, models.EventTypeError) {
+		t.Errorf("rule = %+v, want stderr and image=nginx scope", r)
+	}
+}
@@ -0,0 +1,66 @@
+package managers
+
+import (
+	"sync"
+	"time"
+
+	"github.com/ilyxenc/rattle/internal/database"
+	"github.com/ilyxenc/rattle/internal/docker"
+	"github.com/ilyxenc/rattle/internal/models"
+	"golang.org/x/exp/slices"
+)
+
+// MuteManager keeps active mutes in memory
+type MuteManager struct {
+	mu    sync.RWMutex
+	mutes []models.Mute
+}
+
+// Mutes is the global mute manager instance
+var Mutes = &MuteManager{}
+
+// Reload fetches mutes that haven't expired yet from DB
+func (mm *MuteManager) Reload() error {
+	var mutes []models.Mute
+
+	if err := database.DB.Where("until > ?", time.Now()).Order("until").Find(&mutes).Error; err != nil {
+		return err
+	}
+
+	mm.mu.Lock()
+	mm.mutes = mutes
+	mm.mu.Unlock()
+
+	return nil
+}
+
+// Active returns mutes that haven't expired at `now`
+func (mm *MuteManager) Active(now time.Time) []models.Mute {
+	mm.mu.RLock()
+	defer mm.mu.RUnlock()
+
+	active := slices.Clone(mm.mutes)
+	return slices.DeleteFunc(active, func(m models.Mute) bool {
+		return !m.Until.After(now)
+	})
+}
+
+// Muted reports whether notifications of the container with the fingerprint are muted at `now`.
+// Empty fingerprint matches only mutes of the whole container
+func (mm *MuteManager) Muted(ci docker.ContainerInfo, fingerprint string, now time.Time) bool {
+	mm.mu.RLock()
+	defer mm.mu.RUnlock()
+
+	for _, m := range mm.mutes {
+		if !m.Until.After(now) {
+			continue
+		}
+		if m.Fingerprint != "" && m.Fingerprint != fingerprint {
+			continue
+		}
+		if docker.MatchSelector(ci, m.Container) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,49 @@
+package managers
+
+import (
+	"sync"
+
+	"github.com/ilyxenc/rattle/internal/database"
+	"github.com/ilyxenc/rattle/internal/models"
+	"golang.org/x/exp/slices"
+)
+
+// RouteManager keeps notification routes in memory, grouped by destination
+type RouteManager struct {
+	mu     sync.RWMutex
+	routes map[string][]models.Route // Key = destination name
+}
+
+// Routes is the global route manager instance
+var Routes = &RouteManager{}
+
+// Reload fetches notification routes from DB
+func (rm *RouteManager) Reload() error {
+	var all []models.Route
+
+	if err := database.DB.Order("id").Find(&all).Error; err != nil {
+		return err
+	}
+
+	rm.Load(all)
+	return nil
+}
+
+// Load replaces the routes in memory
+func (rm *RouteManager) Load(all []models.Route) {
+	routes := make(map[string][]models.Route)
+	for _, r := range all {
+		routes[r.Destination] = append(routes[r.Destination], r)
+	}
+
+	rm.mu.Lock()
+	rm.routes = routes
+	rm.mu.Unlock()
+}
+
+// For returns the routes of the destination, or nil if it receives every notification
+func (rm *RouteManager) For(destination string) []models.Route {
+	rm.mu.RLock()
+	defer rm.mu.RUnlock()
+	return slices.Clone(rm.routes[destination])
+}
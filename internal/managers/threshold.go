@@ -0,0 +1,101 @@
+package managers
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ilyxenc/rattle/internal/database"
+	"github.com/ilyxenc/rattle/internal/logger"
+	"github.com/ilyxenc/rattle/internal/models"
+)
+
+// ThresholdRule is a compiled threshold rule
+type ThresholdRule struct {
+	LogRule
+	Pattern string        // Source pattern, shown in alerts
+	Count   int           // Number of matches to alert
+	Window  time.Duration // Period the matches are counted in
+}
+
+// Signature describes what the rule counts. It changes when the rule is edited under the same ID
+func (r ThresholdRule) Signature() string {
+	return fmt.Sprintf("%s\x00%s\x00%s\x00%d\x00%s", r.Pattern, r.Stream, r.Container, r.Count, r.Window)
+}
+
+// ThresholdManager keeps compiled threshold rules in memory
+type ThresholdManager struct {
+	mu     sync.RWMutex
+	rules  []ThresholdRule
+	onLoad func([]ThresholdRule) // Called with the new rules after each load
+}
+
+// Thresholds is the global threshold manager instance
+var Thresholds = &ThresholdManager{}
+
+// Reload fetches threshold rules from DB and compiles them
+func (tm *ThresholdManager) Reload() error {
+	var all []models.ThresholdRule
+
+	if err := database.DB.Find(&all).Error; err != nil {
+		return err
+	}
+
+	tm.Load(all)
+	return nil
+}
+
+// Load compiles the rules and replaces them in memory. Invalid rules are skipped
+func (tm *ThresholdManager) Load(all []models.ThresholdRule) {
+	rules := make([]ThresholdRule, 0, len(all))
+	for _, r := range all {
+		pattern := strings.TrimSpace(r.Pattern)
+		if pattern == "" || r.Count <= 0 || r.Window <= 0 {
+			continue
+		}
+
+		regex, err := regexp.Compile(pattern)
+		if err != nil {
+			logger.Log.Warnf("Invalid threshold regex pattern: %s", pattern)
+			continue
+		}
+
+		rules = append(rules, ThresholdRule{
+			LogRule: LogRule{
+				Regex:     regex,
+				EventType: strings.ToLower(r.EventType),
+				Stream:    strings.ToLower(r.Stream),
+				Container: r.Container,
+				ID:        r.ID,
+			},
+			Pattern: pattern,
+			Count:   r.Count,
+			Window:  time.Duration(r.Window) * time.Second,
+		})
+	}
+
+	tm.mu.Lock()
+	tm.rules = rules
+	onLoad := tm.onLoad
+	tm.mu.Unlock()
+
+	if onLoad != nil {
+		onLoad(rules)
+	}
+}
+
+// OnLoad sets the function called with the new rules after each load, e.g. to drop state of removed rules
+func (tm *ThresholdManager) OnLoad(fn func([]ThresholdRule)) {
+	tm.mu.Lock()
+	tm.onLoad = fn
+	tm.mu.Unlock()
+}
+
+// All returns compiled threshold rules
+func (tm *ThresholdManager) All() []ThresholdRule {
+	tm.mu.RLock()
+	defer tm.mu.RUnlock()
+	return tm.rules
+}
@@ -0,0 +1,46 @@
+package managers
+
+import (
+	"testing"
+
+	"github.com/ilyxenc/rattle/internal/models"
+	"gorm.io/gorm"
+)
+
+func TestThresholdLoad(t *testing.T) {
+	tm := &ThresholdManager{}
+
+	var loaded []ThresholdRule
+	tm.OnLoad(func(rules []ThresholdRule) { loaded = rules })
+
+	rule := models.ThresholdRule{Model: gorm.Model{ID: 1}, Pattern: ` timeout `, Count: 3, Window: 60}
+	tm.Load([]models.ThresholdRule{
+		rule,
+		{Model: gorm.Model{ID: 2}, Pattern: `(`, Count: 3, Window: 60},
+		{Model: gorm.Model{ID: 3}, Pattern: `refused`, Count: 0, Window: 60},
+	})
+	if len(loaded) != 1 || loaded[0].ID != 1 || loaded[0].Pattern != "timeout" {
+		t.Fatalf("OnLoad got %+v, want only the valid rule 1", loaded)
+	}
+
+	before := loaded[0].Signature()
+	tm.Load([]models.ThresholdRule{rule})
+	if loaded[0].Signature() != before {
+		t.Error("signature of an unchanged rule changed on reload")
+	}
+
+	for _, edit := range []func(*models.ThresholdRule){
+		func(r *models.ThresholdRule) { r.Pattern = "timed out" },
+		func(r *models.ThresholdRule) { r.Count = 5 },
+		func(r *models.ThresholdRule) { r.Window = 120 },
+		func(r *models.ThresholdRule) { r.Stream = models.StreamStderr },
+		func(r *models.ThresholdRule) { r.Container = "api" },
+	} {
+		edited := rule
+		edit(&edited)
+		tm.Load([]models.ThresholdRule{edited})
+		if loaded[0].Signature() == before {
+			t.Errorf("signature didn't change after editing the rule to %+v", edited)
+		}
+	}
+}
@@ -0,0 +1,13 @@
+package models
+
+import (
+	"gorm.io/gorm"
+)
+
+type AbsenceRule struct {
+	gorm.Model
+	Pattern    string `json:"pattern"`     // regex-pattern of the expected line
+	EventType  string `json:"event_type"`  // models.EventTypeError / etc, used for the alert
+	Container  string `json:"container"`   // Container selector (e.g. "name=worker"), empty for any container
+	MaxSilence int    `json:"max_silence"` // Alert when no line matched within MaxSilence seconds. Tracked per container
+}
@@ -0,0 +1,137 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+type Channel struct {
+	gorm.Model
+	Name    string        `gorm:"uniqueIndex" json:"name"`
+	Type    string        `json:"type"`                     // models.ChannelTelegram / etc
+	Config  ChannelConfig `gorm:"type:jsonb" json:"config"` // Backend-specific settings (chat ID, URL, token, etc)
+	Enabled bool          `json:"enabled"`                  // Deliver notifications only if true
+}
+
+// ChannelConfig holds backend-specific settings of a notification channel as a JSON object
+type ChannelConfig map[string]any
+
+// RedactedSecret replaces secrets of channel configs in API responses.
+// Sending it back in an update keeps the stored secret
+const RedactedSecret = "********"
+
+// secretKeys are config keys holding credentials. Slack and Discord webhook URLs embed a token.
+// All values of "headers" (webhook channels) are secret, they usually carry authorization
+var secretKeys = map[string]bool{
+	"bot_token": true, "password": true, "secret": true, "token": true,
+	"access_token": true, "routing_key": true, "webhook_url": true,
+}
+
+// typeSecretKeys are config keys that are secret only for some channel types. Webhook URLs often embed a token
+var typeSecretKeys = map[string]map[string]bool{
+	ChannelWebhook: {"url": true},
+}
+
+// Destination returns the name routes and deliveries refer to the channel by, e.g. "slack:ops"
+func (ch Channel) Destination() string {
+	return ch.Type + ":" + ch.Name
+}
+
+// Redacted returns the channel with secrets of its config replaced by RedactedSecret
+func (ch Channel) Redacted() Channel {
+	ch.Config = ch.Config.redacted(typeSecretKeys[ch.Type])
+	return ch
+}
+
+// Redacted returns a copy of the config with secret values replaced by RedactedSecret
+func (c ChannelConfig) Redacted() ChannelConfig {
+	return c.redacted(nil)
+}
+
+// redacted returns a copy of the config with values of secretKeys and `extra` keys replaced by RedactedSecret
+func (c ChannelConfig) redacted(extra map[string]bool) ChannelConfig {
+	redacted := make(ChannelConfig, len(c))
+	for key, value := range c {
+		switch {
+		case key == "headers":
+			if headers, ok := value.(map[string]any); ok {
+				masked := make(map[string]any, len(headers))
+				for name := range headers {
+					masked[name] = RedactedSecret
+				}
+				value = masked
+			}
+		case secretKeys[key] || extra[key]:
+			if str, ok := value.(string); ok && str != "" {
+				value = RedactedSecret
+			}
+		}
+		redacted[key] = value
+	}
+	return redacted
+}
+
+// RestoreSecrets replaces RedactedSecret values with the secrets of the stored config,
+// so a config read from the API can be sent back with other fields changed
+func (c ChannelConfig) RestoreSecrets(stored ChannelConfig) {
+	for key, value := range c {
+		if value == RedactedSecret {
+			c[key] = stored[key]
+			continue
+		}
+
+		if key != "headers" {
+			continue
+		}
+		headers, _ := value.(map[string]any)
+		storedHeaders, _ := stored[key].(map[string]any)
+		for name, v := range headers {
+			if v == RedactedSecret {
+				headers[name] = storedHeaders[name]
+			}
+		}
+	}
+}
+
+// Value stores the config as JSON
+func (c ChannelConfig) Value() (driver.Value, error) {
+	if c == nil {
+		return "{}", nil
+	}
+
+	b, err := json.Marshal(c)
+	if err != nil {
+		return nil, err
+	}
+	return string(b), nil
+}
+
+// Scan reads the config from JSON
+func (c *ChannelConfig) Scan(value any) error {
+	var b []byte
+	switch v := value.(type) {
+	case []byte:
+		b = v
+	case string:
+		b = []byte(v)
+	case nil:
+		*c = ChannelConfig{}
+		return nil
+	default:
+		return fmt.Errorf("unsupported channel config type %T", value)
+	}
+
+	return json.Unmarshal(b, c)
+}
+
+// Decode converts the config into a backend-specific struct with json tags
+func (c ChannelConfig) Decode(dst any) error {
+	b, err := json.Marshal(c)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, dst)
+}
@@ -0,0 +1,82 @@
+package models
+
+import "testing"
+
+func TestChannelConfigRedacted(t *testing.T) {
+	cfg := ChannelConfig{
+		"chat_id":   "-100",
+		"bot_token": "123:abc",
+		"password":  "",
+		"headers":   map[string]any{"Authorization": "Bearer x"},
+	}
+
+	redacted := cfg.Redacted()
+	if redacted["bot_token"] != RedactedSecret {
+		t.Errorf("bot_token = %v, want redacted", redacted["bot_token"])
+	}
+	if redacted["chat_id"] != "-100" {
+		t.Errorf("chat_id = %v, want unchanged", redacted["chat_id"])
+	}
+	if redacted["password"] != "" {
+		t.Errorf("empty password = %v, want empty", redacted["password"])
+	}
+	if h := redacted["headers"].(map[string]any); h["Authorization"] != RedactedSecret {
+		t.Errorf("Authorization header = %v, want redacted", h["Authorization"])
+	}
+	if cfg["bot_token"] != "123:abc" || cfg["headers"].(map[string]any)["Authorization"] != "Bearer x" {
+		t.Error("Redacted must not change the original config")
+	}
+}
+
+func TestChannelConfigRestoreSecrets(t *testing.T) {
+	stored := ChannelConfig{
+		"bot_token": "123:abc",
+		"headers":   map[string]any{"Authorization": "Bearer x"},
+	}
+
+	// A config read from the API, sent back with a changed chat
+	update := stored.Redacted()
+	update["chat_id"] = "-200"
+	update["headers"].(map[string]any)["X-Extra"] = "1"
+	update.RestoreSecrets(stored)
+
+	if update["bot_token"] != "123:abc" {
+		t.Errorf("bot_token = %v, want the stored secret", update["bot_token"])
+	}
+	headers := update["headers"].(map[string]any)
+	if headers["Authorization"] != "Bearer x" || headers["X-Extra"] != "1" {
+		t.Errorf("headers = %v, want the stored Authorization and the new X-Extra", headers)
+	}
+
+	// A new secret replaces the stored one
+	update = ChannelConfig{"bot_token": "456:def"}
+	update.RestoreSecrets(stored)
+	if update["bot_token"] != "456:def" {
+		t.Errorf("bot_token = %v, want the new secret", update["bot_token"])
+	}
+}
+
+func TestChannelRedacted(t *testing.T) {
+	webhook := Channel{Type: ChannelWebhook, Config: ChannelConfig{"url": "https://hooks.example.com/T0k3n", "secret": "s"}}
+
+	redacted := webhook.Redacted()
+	if redacted.Config["url"] != RedactedSecret || redacted.Config["secret"] != RedactedSecret {
+		t.Errorf("webhook config = %v, want url and secret redacted", redacted.Config)
+	}
+	if webhook.Config["url"] != "https://hooks.example.com/T0k3n" {
+		t.Error("Redacted must not change the original channel")
+	}
+
+	// Sending the redacted url back keeps the stored one
+	update := redacted.Config
+	update.RestoreSecrets(webhook.Config)
+	if update["url"] != "https://hooks.example.com/T0k3n" {
+		t.Errorf("url = %v, want the stored url", update["url"])
+	}
+
+	// Other types keep their url, e.g. a public server address
+	other := Channel{Type: ChannelNtfy, Config: ChannelConfig{"url": "https://ntfy.example.com"}}
+	if url := other.Redacted().Config["url"]; url != "https://ntfy.example.com" {
+		t.Errorf("%s url = %v, want unchanged", other.Type, url)
+	}
+}
@@ -1,11 +1,51 @@
 package models
 
 import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+
 	"gorm.io/gorm"
 )
 
 type Chat struct {
 	gorm.Model
-	ChatID string `gorm:"uniqueIndex" json:"chat_id"`
-	Send   bool   `gorm:"default:true" json:"send"` // Send notifications only if true
+	ChatID          string     `gorm:"uniqueIndex" json:"chat_id"`
+	Send            bool       `gorm:"default:true" json:"send"` // Send notifications only if true
+	MessageThreadID int        `json:"message_thread_id"`        // Forum topic for notifications, 0 for the General topic or a regular chat
+	Topics          ChatTopics `gorm:"type:jsonb" json:"topics"` // Forum topics by notification type or event type, override MessageThreadID
+}
+
+// ChatTopics maps notification types (e.g. "container_start") and event types (e.g. "critical") to forum topic IDs
+type ChatTopics map[string]int
+
+// Value stores the topics as JSON
+func (t ChatTopics) Value() (driver.Value, error) {
+	if t == nil {
+		return "{}", nil
+	}
+
+	b, err := json.Marshal(t)
+	if err != nil {
+		return nil, err
+	}
+	return string(b), nil
+}
+
+// Scan reads the topics from JSON
+func (t *ChatTopics) Scan(value any) error {
+	var b []byte
+	switch v := value.(type) {
+	case []byte:
+		b = v
+	case string:
+		b = []byte(v)
+	case nil:
+		*t = ChatTopics{}
+		return nil
+	default:
+		return fmt.Errorf("unsupported chat topics type %T", value)
+	}
+
+	return json.Unmarshal(b, t)
 }
@@ -25,4 +25,35 @@ const (
 	// Container monitoring modes
 	Blacklist = "blacklist"
 	Whitelist = "whitelist"
+
+	// Container log streams
+	StreamStdout = "stdout"
+	StreamStderr = "stderr"
+
+	// Notification channel types
+	ChannelTelegram  = "telegram"
+	ChannelSlack     = "slack"
+	ChannelDiscord   = "discord"
+	ChannelEmail     = "email"
+	ChannelWebhook   = "webhook"
+	ChannelPagerDuty = "pagerduty"
+	ChannelMatrix    = "matrix"
+	ChannelNtfy      = "ntfy"
+	ChannelGotify    = "gotify"
+
+	// Outbox delivery statuses
+	DeliveryPending = "pending" // Waiting for the first or next attempt
+	DeliverySent    = "sent"
+	DeliveryFailed  = "failed" // Gave up after the maximum number of attempts
+	DeliveryDigest  = "digest" // Waiting to be sent with other notifications in a digest
 )
+
+// EventTypeSeverity orders event types from the most to the least severe.
+// Used to pick the event type when a line matches patterns of several types
+var EventTypeSeverity = map[string]int{
+	EventTypeCritical: 5,
+	EventTypeError:    4,
+	EventTypeWarning:  3,
+	EventTypeInfo:     2,
+	EventTypeSuccess:  1,
+}
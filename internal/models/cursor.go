@@ -0,0 +1,19 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type LogCursor struct {
+	gorm.Model
+	ContainerID string `gorm:"uniqueIndex" json:"container_id"`
+	UnixNano    int64  `json:"unix_nano"` // Docker timestamp of the last processed log line. Postgres timestamps keep only microseconds
+	Seq         int    `json:"seq"`       // Index of the last processed line among lines with the same timestamp, -1 if none processed
+}
+
+// Time returns the Docker timestamp of the last processed log line
+func (c LogCursor) Time() time.Time {
+	return time.Unix(0, c.UnixNano)
+}
@@ -0,0 +1,63 @@
+package models
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Delivery is an outbox entry: a notification queued for a single destination
+type Delivery struct {
+	gorm.Model
+	Destination   string          `gorm:"index" json:"destination"`     // Notifier name, e.g. "telegram:-1234567890" or "slack:ops"
+	Type          string          `json:"type"`                         // Notification type, e.g. log_event
+	Payload       DeliveryPayload `gorm:"type:jsonb" json:"payload"`    // The notification as JSON
+	Status        string          `gorm:"index" json:"status"`          // models.DeliveryPending / DeliverySent / DeliveryFailed / DeliveryDigest
+	Attempts      int             `json:"attempts"`                     // Number of send attempts made
+	LastError     string          `json:"last_error"`                   // Error of the last failed attempt
+	SentParts     int             `json:"sent_parts"`                   // Parts of a notification sent as several messages that are delivered already
+	NextAttemptAt time.Time       `gorm:"index" json:"next_attempt_at"` // When a pending delivery is due
+	SentAt        *time.Time      `json:"sent_at"`                      // When the delivery succeeded
+}
+
+// DeliveryPayload holds the JSON of a queued notification
+type DeliveryPayload []byte
+
+// Value stores the payload as JSON
+func (p DeliveryPayload) Value() (driver.Value, error) {
+	if len(p) == 0 {
+		return "{}", nil
+	}
+	return string(p), nil
+}
+
+// Scan reads the payload from JSON
+func (p *DeliveryPayload) Scan(value any) error {
+	switch v := value.(type) {
+	case []byte:
+		*p = append((*p)[:0], v...)
+	case string:
+		*p = DeliveryPayload(v)
+	case nil:
+		*p = nil
+	default:
+		return fmt.Errorf("unsupported delivery payload type %T", value)
+	}
+	return nil
+}
+
+// MarshalJSON embeds the payload as is, so API responses show the notification as an object
+func (p DeliveryPayload) MarshalJSON() ([]byte, error) {
+	if len(p) == 0 {
+		return []byte("null"), nil
+	}
+	return p, nil
+}
+
+// UnmarshalJSON keeps the raw payload
+func (p *DeliveryPayload) UnmarshalJSON(b []byte) error {
+	*p = append((*p)[:0], b...)
+	return nil
+}
@@ -0,0 +1,11 @@
+package models
+
+import (
+	"gorm.io/gorm"
+)
+
+type IdleRule struct {
+	gorm.Model
+	Container string `json:"container"` // Container selector (e.g. "project=shop"), empty for any container
+	Timeout   int    `json:"timeout"`   // Alert when the container produced no log lines within Timeout seconds
+}
@@ -9,4 +9,8 @@ type LogExclusion struct {
 	Pattern   string `json:"pattern"`    // regex-pattern
 	MatchType string `json:"match_type"` // models.MatchTypeInclude / MatchTypeExclude
 	EventType string `json:"event_type"` // models.EventTypeError / etc
+	Stream    string `json:"stream"`     // models.StreamStdout / StreamStderr, empty for any stream
+	Container string `json:"container"`  // Container selector (e.g. "image=nginx"), empty for any container
+	Field     string `json:"field"`      // Field of a structured (JSON/logfmt) line to match (e.g. msg, status), empty for the raw line
+	Priority  int    `json:"priority"`   // Include rules with higher priority are checked first, equal priority falls back to severity order
 }
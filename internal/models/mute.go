@@ -0,0 +1,15 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type Mute struct {
+	gorm.Model
+	Container   string    `json:"container"`   // Container selector (e.g. "api" or "image=nginx")
+	Fingerprint string    `json:"fingerprint"` // Mute only log events with this fingerprint, empty for all notifications of the container
+	Until       time.Time `json:"until"`       // Notifications are muted until this time
+	CreatedBy   string    `json:"created_by"`  // Telegram ID of the user who muted
+}
@@ -0,0 +1,17 @@
+package models
+
+import (
+	"gorm.io/gorm"
+)
+
+// Route sends matching notifications to a destination. A destination without routes receives every notification,
+// a destination with routes only those matching at least one of them
+type Route struct {
+	gorm.Model
+	Destination      string `gorm:"index" json:"destination"` // Destination name, e.g. "telegram:-1234567890" or "slack:payments"
+	EventType        string `json:"event_type"`               // models.EventTypeError / etc, empty for any
+	MinSeverity      string `json:"min_severity"`             // Lowest event type to match (e.g. "warning"), empty for any
+	NotificationType string `json:"notification_type"`        // e.g. "log_event" or "container_start", empty for any
+	Container        string `json:"container"`                // Container selector (e.g. "image=payments"), empty for any container
+	Project          string `json:"project"`                  // Docker Compose project, empty for any
+}
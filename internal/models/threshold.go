@@ -0,0 +1,15 @@
+package models
+
+import (
+	"gorm.io/gorm"
+)
+
+type ThresholdRule struct {
+	gorm.Model
+	Pattern   string `json:"pattern"`    // regex-pattern
+	EventType string `json:"event_type"` // models.EventTypeError / etc, used for the alert
+	Stream    string `json:"stream"`     // models.StreamStdout / StreamStderr, empty for any stream
+	Container string `json:"container"`  // Container selector (e.g. "image=nginx"), empty for any container
+	Count     int    `json:"count"`      // Alert when at least Count lines match...
+	Window    int    `json:"window"`     // ...within Window seconds. Counted per container
+}
@@ -0,0 +1,152 @@
+package discord
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+	"github.com/ilyxenc/rattle/internal/models"
+	"github.com/ilyxenc/rattle/internal/notify"
+)
+
+const (
+	maxAttempts  = 3                // Attempts to send a message that was rate limited
+	maxRateLimit = 60 * time.Second // Longest rate limit to wait for, the outbox retries later if Discord asks for more
+)
+
+// channelConfig is the config of a Discord notification channel
+type channelConfig struct {
+	WebhookURL string `json:"webhook_url"` // Webhook URL (https://discord.com/api/webhooks/...)
+	Username   string `json:"username"`    // Optional, overrides the webhook name
+}
+
+// Notifier sends notifications to a Discord webhook as embeds, following its rate limits
+type Notifier struct {
+	name       string
+	webhookURL string
+	username   string
+	client     *resty.Client
+
+	mu      sync.Mutex // Protects resetAt
+	resetAt time.Time  // When the exhausted rate limit bucket is refilled
+}
+
+// rateLimitResponse is the body of a 429 response
+type rateLimitResponse struct {
+	RetryAfter float64 `json:"retry_after"` // Seconds
+	Global     bool    `json:"global"`
+}
+
+// NewNotifier creates a notifier posting to the webhook
+func NewNotifier(name, webhookURL, username string) *Notifier {
+	// Rate limits are handled by the notifier
+	client := notify.NewHTTPClient(notify.RetryPolicy{Count: 3, Wait: 2 * time.Second, MaxWait: 10 * time.Second})
+
+	return &Notifier{
+		name:       name,
+		webhookURL: webhookURL,
+		username:   username,
+		client:     client,
+	}
+}
+
+// NewChannelNotifier creates a notifier from a notification channel stored in DB
+func NewChannelNotifier(ch models.Channel) (notify.Notifier, error) {
+	var cfg channelConfig
+	if err := ch.Config.Decode(&cfg); err != nil {
+		return nil, err
+	}
+	if cfg.WebhookURL == "" {
+		return nil, errors.New("webhook_url is required")
+	}
+
+	return NewNotifier(ch.Name, cfg.WebhookURL, cfg.Username), nil
+}
+
+// Name identifies the channel in logs
+func (dn *Notifier) Name() string {
+	return "discord:" + dn.name
+}
+
+// Send renders the notification as an embed and posts it to the webhook.
+// If the rate limit is exhausted, it waits until the bucket is refilled. Longer waits are returned as notify.RetryAfterError
+func (dn *Notifier) Send(n notify.Notification) error {
+	msg := RenderMessage(n, dn.username)
+
+	for attempt := 1; ; attempt++ {
+		if wait := dn.rateLimited(); wait > 0 {
+			if wait > maxRateLimit {
+				return &notify.RetryAfterError{After: wait, Err: errors.New("discord rate limit is exhausted")}
+			}
+			time.Sleep(wait)
+		}
+
+		resp, err := dn.client.R().
+			SetHeader("Content-Type", "application/json").
+			SetBody(msg).
+			Post(dn.webhookURL)
+
+		if err != nil {
+			return fmt.Errorf("failed to send Discord message: %w", err)
+		}
+
+		dn.mu.Lock()
+		dn.updateRateLimit(resp)
+		dn.mu.Unlock()
+
+		if resp.StatusCode() == http.StatusTooManyRequests {
+			if attempt < maxAttempts {
+				continue // Wait for the bucket and try again
+			}
+			return &notify.RetryAfterError{After: dn.rateLimited(), Err: fmt.Errorf("discord responded with status 429: %s", resp.String())}
+		}
+
+		if !resp.IsSuccess() {
+			return fmt.Errorf("discord responded with status %d: %s", resp.StatusCode(), resp.String())
+		}
+
+		return nil
+	}
+}
+
+// rateLimited returns how long to wait until the rate limit bucket is refilled, 0 if requests are allowed
+func (dn *Notifier) rateLimited() time.Duration {
+	dn.mu.Lock()
+	defer dn.mu.Unlock()
+
+	return max(time.Until(dn.resetAt), 0)
+}
+
+// updateRateLimit remembers when requests are allowed again from the rate limit headers and 429 body. Must be called with the lock held
+func (dn *Notifier) updateRateLimit(resp *resty.Response) {
+	now := time.Now()
+
+	if resp.StatusCode() == http.StatusTooManyRequests {
+		var body rateLimitResponse
+		retryAfter := 0.0
+		if err := json.Unmarshal(resp.Body(), &body); err == nil && body.RetryAfter > 0 {
+			retryAfter = body.RetryAfter
+		} else if v, err := strconv.ParseFloat(resp.Header().Get("Retry-After"), 64); err == nil {
+			retryAfter = v
+		}
+
+		dn.resetAt = now.Add(max(time.Duration(retryAfter*float64(time.Second)), time.Second))
+		return
+	}
+
+	if resp.Header().Get("X-RateLimit-Remaining") != "0" {
+		dn.resetAt = time.Time{}
+		return
+	}
+
+	resetAfter, err := strconv.ParseFloat(resp.Header().Get("X-RateLimit-Reset-After"), 64)
+	if err != nil {
+		return
+	}
+	dn.resetAt = now.Add(time.Duration(resetAfter * float64(time.Second)))
+}
@@ -0,0 +1,156 @@
+package discord
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ilyxenc/rattle/internal/docker"
+	"github.com/ilyxenc/rattle/internal/models"
+	"github.com/ilyxenc/rattle/internal/notify"
+)
+
+var event = notify.Notification{
+	Type:      notify.NotificationLogEvent,
+	EventType: models.EventTypeError,
+	Details:   "connection refused",
+	Container: docker.ContainerInfo{ID: "c1", ShortID: "c1", Name: "api", Image: "api:1"},
+}
+
+// webhookAPI is a Discord webhook stand-in answering with queued responses and recording request times
+type webhookAPI struct {
+	mu        sync.Mutex
+	responses []func(w http.ResponseWriter) // Answers of the next requests, 204 when none are left
+	requests  []time.Time
+}
+
+func (api *webhookAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	api.mu.Lock()
+	defer api.mu.Unlock()
+
+	api.requests = append(api.requests, time.Now())
+	if len(api.responses) == 0 {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	respond := api.responses[0]
+	api.responses = api.responses[1:]
+	respond(w)
+}
+
+// rateLimited answers 429 with retry_after in the body
+func rateLimited(retryAfter string) func(w http.ResponseWriter) {
+	return func(w http.ResponseWriter) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusTooManyRequests)
+		_, _ = w.Write([]byte(`{"message": "You are being rate limited.", "retry_after": ` + retryAfter + `, "global": false}`))
+	}
+}
+
+// exhausted answers 204 with a rate limit bucket that is refilled after `resetAfter` seconds
+func exhausted(resetAfter string) func(w http.ResponseWriter) {
+	return func(w http.ResponseWriter) {
+		w.Header().Set("X-RateLimit-Remaining", "0")
+		w.Header().Set("X-RateLimit-Reset-After", resetAfter)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func newTestNotifier(t *testing.T, api *webhookAPI) *Notifier {
+	t.Helper()
+
+	srv := httptest.NewServer(api)
+	t.Cleanup(srv.Close)
+	return NewNotifier("ops", srv.URL, "rattle")
+}
+
+func TestSendWaitsForExhaustedBucket(t *testing.T) {
+	api := &webhookAPI{responses: []func(http.ResponseWriter){exhausted("0.3")}}
+	dn := newTestNotifier(t, api)
+
+	for range 2 {
+		if err := dn.Send(event); err != nil {
+			t.Fatalf("Send: %v", err)
+		}
+	}
+
+	if len(api.requests) != 2 {
+		t.Fatalf("sent %d requests, want 2", len(api.requests))
+	}
+	if gap := api.requests[1].Sub(api.requests[0]); gap < 300*time.Millisecond {
+		t.Errorf("second message was sent %s after the first, want after the bucket reset", gap)
+	}
+}
+
+func TestSendRetryAfterLongReset(t *testing.T) {
+	api := &webhookAPI{responses: []func(http.ResponseWriter){exhausted("120")}}
+	dn := newTestNotifier(t, api)
+
+	if err := dn.Send(event); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+
+	// The bucket is refilled in 2 minutes, too long to wait in the notifier
+	err := dn.Send(event)
+	var rae *notify.RetryAfterError
+	if !errors.As(err, &rae) || rae.After < 110*time.Second || rae.After > 120*time.Second {
+		t.Fatalf("Send = %v, want RetryAfterError of about 2m", err)
+	}
+	if len(api.requests) != 1 {
+		t.Errorf("sent %d requests, want none while rate limited", len(api.requests)-1)
+	}
+}
+
+func TestSendRetries429(t *testing.T) {
+	api := &webhookAPI{responses: []func(http.ResponseWriter){rateLimited("0.2")}}
+	dn := newTestNotifier(t, api)
+
+	if err := dn.Send(event); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+
+	if len(api.requests) != 2 {
+		t.Fatalf("sent %d requests, want a retry after 429", len(api.requests))
+	}
+	if gap := api.requests[1].Sub(api.requests[0]); gap < 200*time.Millisecond {
+		t.Errorf("retried %s after 429, want after retry_after", gap)
+	}
+}
+
+func TestSendRetryAfterOn429(t *testing.T) {
+	tests := []struct {
+		name      string
+		responses []func(http.ResponseWriter)
+		min, max  time.Duration
+	}{
+		{
+			name:      "retry_after above the longest wait",
+			responses: []func(http.ResponseWriter){rateLimited("90")},
+			min:       85 * time.Second,
+			max:       90 * time.Second,
+		},
+		{
+			name:      "attempts run out",
+			responses: []func(http.ResponseWriter){rateLimited("0.01"), rateLimited("0.01"), rateLimited("5")},
+			min:       4 * time.Second,
+			max:       5 * time.Second,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			api := &webhookAPI{responses: tt.responses}
+			dn := newTestNotifier(t, api)
+
+			err := dn.Send(event)
+			var rae *notify.RetryAfterError
+			if !errors.As(err, &rae) || rae.After < tt.min || rae.After > tt.max {
+				t.Fatalf("Send = %v, want RetryAfterError between %s and %s", err, tt.min, tt.max)
+			}
+		})
+	}
+}
@@ -0,0 +1,139 @@
+package discord
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ilyxenc/rattle/internal/models"
+	"github.com/ilyxenc/rattle/internal/notify"
+)
+
+// Discord limits
+const (
+	maxTitleLen       = 256
+	maxDescriptionLen = 2000 // Discord allows 4096 in an embed, but messages are kept within the 2000 characters of plain content
+	maxFields         = 25
+	maxFieldNameLen   = 256
+	maxFieldValueLen  = 1024
+)
+
+// eventColors are embed colors by event type
+var eventColors = map[string]int{
+	models.EventTypeCritical: 0x992D22, // Dark red
+	models.EventTypeError:    0xE74C3C, // Red
+	models.EventTypeWarning:  0xF1C40F, // Yellow
+	models.EventTypeInfo:     0x3498DB, // Blue
+	models.EventTypeSuccess:  0x2ECC71, // Green
+}
+
+// defaultColor is used for unknown event types
+const defaultColor = 0x95A5A6 // Grey
+
+// Message is a webhook message with a single embed
+type Message struct {
+	Username string  `json:"username,omitempty"`
+	Embeds   []Embed `json:"embeds"`
+}
+
+// Embed is a Discord message embed. Only fields used by Rattle are defined
+type Embed struct {
+	Title       string  `json:"title"`
+	Description string  `json:"description,omitempty"`
+	Color       int     `json:"color"`
+	Fields      []Field `json:"fields,omitempty"`
+	Timestamp   string  `json:"timestamp,omitempty"`
+}
+
+// Field is an embed field
+type Field struct {
+	Name   string `json:"name"`
+	Value  string `json:"value"`
+	Inline bool   `json:"inline"`
+}
+
+// RenderMessage formats a notification as a message with an embed
+func RenderMessage(n notify.Notification, username string) Message {
+	color, ok := eventColors[notify.Severity(n)]
+	if !ok {
+		color = defaultColor
+	}
+
+	embed := Embed{
+		Title:       notify.Truncate(notify.Headline(n), maxTitleLen),
+		Description: description(n),
+		Color:       color,
+		Timestamp:   notify.EventTime(n).Format(time.RFC3339Nano),
+	}
+
+	if n.Rule != "" {
+		embed.Fields = append(embed.Fields, field("Pattern", code(n.Rule), false))
+	}
+
+	if n.Stream != "" {
+		embed.Fields = append(embed.Fields, field("Stream", code(n.Stream), true))
+	}
+
+	for _, f := range n.Fields {
+		embed.Fields = append(embed.Fields, field(f.Key, code(f.Value), true))
+	}
+
+	// Container metadata, same as in Telegram messages
+	if notify.HasContainer(n) {
+		for _, f := range notify.MetaFields(n.Container) {
+			embed.Fields = append(embed.Fields, field(f.Key, code(f.Value), true))
+		}
+	}
+
+	if len(embed.Fields) > maxFields {
+		embed.Fields = embed.Fields[:maxFields]
+	}
+
+	return Message{
+		Username: username,
+		Embeds:   []Embed{embed},
+	}
+}
+
+// description returns the log line as a code block, or the list of containers for the summary
+func description(n notify.Notification) string {
+	if n.Type == notify.NotificationContainersSummary {
+		lines := make([]string, 0, len(n.Containers))
+		for _, ci := range n.Containers {
+			lines = append(lines, fmt.Sprintf("- `%s`: %s", ci.ShortID, escape(ci.Name)))
+		}
+		return notify.Truncate(strings.Join(lines, "\n"), maxDescriptionLen)
+	}
+
+	if n.Details == "" {
+		return ""
+	}
+
+	// Keep the closing fence within the limit
+	text := strings.ReplaceAll(strings.ToValidUTF8(n.Details, ""), "```", "'''")
+	return "```\n" + notify.Truncate(text, maxDescriptionLen-8) + "\n```"
+}
+
+// field returns an embed field with name and value cut to Discord limits
+func field(name, value string, inline bool) Field {
+	return Field{
+		Name:   notify.Truncate(name, maxFieldNameLen),
+		Value:  notify.Truncate(value, maxFieldValueLen),
+		Inline: inline,
+	}
+}
+
+// code wraps the value in inline code
+func code(value string) string {
+	value = strings.ReplaceAll(strings.ToValidUTF8(value, ""), "`", "'")
+	if value == "" {
+		return "-" // Discord rejects empty field values
+	}
+	return "`" + notify.Truncate(value, maxFieldValueLen-2) + "`"
+}
+
+// escape escapes Discord markdown characters
+func escape(text string) string {
+	replacer := strings.NewReplacer(`\`, `\\`, "*", `\*`, "_", `\_`, "~", `\~`, "`", "\\`", "|", `\|`, ">", `\>`)
+	return replacer.Replace(strings.ToValidUTF8(text, ""))
+}
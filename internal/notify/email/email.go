@@ -0,0 +1,243 @@
+package email
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ilyxenc/rattle/internal/models"
+	"github.com/ilyxenc/rattle/internal/notify"
+)
+
+// TLS modes
+const (
+	TLSStartTLS = "starttls" // Plain connection upgraded with STARTTLS, usually port 587
+	TLSImplicit = "tls"      // TLS from the start, usually port 465
+	TLSNone     = "none"     // No encryption, for local relays and test sinks
+)
+
+const dialTimeout = 10 * time.Second
+
+// sessionTimeout limits the whole SMTP session, so a server that stalls doesn't block the delivery forever
+var sessionTimeout = time.Minute
+
+// channelConfig is the config of an email notification channel
+type channelConfig struct {
+	Host     string   `json:"host"`
+	Port     int      `json:"port"` // Defaults to 587 for starttls, 465 for tls and 25 for none
+	Username string   `json:"username"`
+	Password string   `json:"password"`
+	From     string   `json:"from"`
+	To       []string `json:"to"`
+	TLS      string   `json:"tls"`    // starttls (default), tls or none
+	Digest   string   `json:"digest"` // Optional period like "15m": log events are bundled into one email sent once per period
+}
+
+// Notifier sends notifications by email through an SMTP server
+type Notifier struct {
+	name     string
+	cfg      channelConfig
+	from     string        // Envelope sender address
+	to       []string      // Envelope recipient addresses
+	digestOf time.Duration // Digest period, 0 if every notification is sent right away
+}
+
+// NewChannelNotifier creates a notifier from a notification channel stored in DB
+func NewChannelNotifier(ch models.Channel) (notify.Notifier, error) {
+	var cfg channelConfig
+	if err := ch.Config.Decode(&cfg); err != nil {
+		return nil, err
+	}
+
+	if cfg.Host == "" {
+		return nil, errors.New("host is required")
+	}
+	if cfg.From == "" {
+		return nil, errors.New("from is required")
+	}
+	if len(cfg.To) == 0 {
+		return nil, errors.New("at least one recipient in to is required")
+	}
+
+	// Addresses may include display names ("Rattle <rattle@example.com>"), SMTP needs bare ones
+	from, err := mail.ParseAddress(cfg.From)
+	if err != nil {
+		return nil, fmt.Errorf("invalid from address: %w", err)
+	}
+	to := make([]string, 0, len(cfg.To))
+	for _, r := range cfg.To {
+		addr, err := mail.ParseAddress(r)
+		if err != nil {
+			return nil, fmt.Errorf("invalid recipient %q: %w", r, err)
+		}
+		to = append(to, addr.Address)
+	}
+
+	cfg.TLS = strings.ToLower(cfg.TLS)
+	switch cfg.TLS {
+	case "":
+		cfg.TLS = TLSStartTLS
+	case TLSStartTLS, TLSImplicit, TLSNone:
+	default:
+		return nil, fmt.Errorf("unknown tls mode: %s", cfg.TLS)
+	}
+
+	if cfg.Port == 0 {
+		switch cfg.TLS {
+		case TLSStartTLS:
+			cfg.Port = 587
+		case TLSImplicit:
+			cfg.Port = 465
+		case TLSNone:
+			cfg.Port = 25
+		}
+	}
+
+	var digest time.Duration
+	if cfg.Digest != "" {
+		d, err := time.ParseDuration(cfg.Digest)
+		if err != nil || d <= 0 {
+			return nil, fmt.Errorf("invalid digest period: %s", cfg.Digest)
+		}
+		digest = d
+	}
+
+	return &Notifier{
+		name:     ch.Name,
+		cfg:      cfg,
+		from:     from.Address,
+		to:       to,
+		digestOf: digest,
+	}, nil
+}
+
+// Name identifies the channel in logs
+func (en *Notifier) Name() string {
+	return "email:" + en.name
+}
+
+// Send emails the notification right away
+func (en *Notifier) Send(n notify.Notification) error {
+	subject, text, html := renderNotification(n)
+	return en.send(subject, text, html)
+}
+
+// DigestPeriod returns the digest period for log events, which the outbox keeps until the digest is due.
+// Other notifications are sent right away
+func (en *Notifier) DigestPeriod(n notify.Notification) time.Duration {
+	if !isLogEvent(n) {
+		return 0
+	}
+	return en.digestOf
+}
+
+// SendDigest emails the log events as one digest
+func (en *Notifier) SendDigest(events []notify.Notification) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	subject, text, html := renderDigest(events, en.digestOf)
+	return en.send(subject, text, html)
+}
+
+// send delivers a multipart message with text and HTML bodies to all recipients
+func (en *Notifier) send(subject, text, html string) error {
+	msg, err := buildMessage(en.cfg.From, en.cfg.To, subject, text, html)
+	if err != nil {
+		return err
+	}
+
+	c, err := en.dial()
+	if err != nil {
+		return fmt.Errorf("failed to connect to SMTP server: %w", err)
+	}
+	defer c.Close()
+
+	if en.cfg.Username != "" {
+		auth := smtp.PlainAuth("", en.cfg.Username, en.cfg.Password, en.cfg.Host)
+		if err := c.Auth(auth); err != nil {
+			return fmt.Errorf("SMTP auth failed: %w", err)
+		}
+	}
+
+	if err := c.Mail(en.from); err != nil {
+		return err
+	}
+	for _, to := range en.to {
+		if err := c.Rcpt(to); err != nil {
+			return fmt.Errorf("recipient %s rejected: %w", to, err)
+		}
+	}
+
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(msg); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+
+	return c.Quit()
+}
+
+// dial connects to the SMTP server using the configured TLS mode
+func (en *Notifier) dial() (*smtp.Client, error) {
+	addr := net.JoinHostPort(en.cfg.Host, strconv.Itoa(en.cfg.Port))
+	tlsConfig := &tls.Config{ServerName: en.cfg.Host}
+
+	if en.cfg.TLS == TLSImplicit {
+		conn, err := tls.DialWithDialer(&net.Dialer{Timeout: dialTimeout}, "tcp", addr, tlsConfig)
+		if err != nil {
+			return nil, err
+		}
+		_ = conn.SetDeadline(time.Now().Add(sessionTimeout))
+
+		c, err := smtp.NewClient(conn, en.cfg.Host)
+		if err != nil {
+			conn.Close()
+			return nil, err
+		}
+		return c, nil
+	}
+
+	conn, err := net.DialTimeout("tcp", addr, dialTimeout)
+	if err != nil {
+		return nil, err
+	}
+	_ = conn.SetDeadline(time.Now().Add(sessionTimeout)) // Still applies after STARTTLS, the TLS connection wraps this one
+
+	c, err := smtp.NewClient(conn, en.cfg.Host)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	if en.cfg.TLS == TLSStartTLS {
+		if err := c.StartTLS(tlsConfig); err != nil {
+			c.Close()
+			return nil, fmt.Errorf("STARTTLS failed: %w", err)
+		}
+	}
+
+	return c, nil
+}
+
+// isLogEvent reports whether the notification comes from container logs and can be bundled into a digest
+func isLogEvent(n notify.Notification) bool {
+	switch n.Type {
+	case notify.NotificationLogEvent, notify.NotificationLogRepeated, notify.NotificationThreshold:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,233 @@
+package email
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ilyxenc/rattle/internal/docker"
+	"github.com/ilyxenc/rattle/internal/models"
+	"github.com/ilyxenc/rattle/internal/notify"
+)
+
+// smtpSink is a local SMTP server that accepts mail and records received messages.
+// Recipients listed in `reject` are refused
+type smtpSink struct {
+	ln     net.Listener
+	reject string
+	stall  bool // Accept connections, but never answer
+
+	mu       sync.Mutex
+	messages []string // DATA of received messages
+}
+
+func newSMTPSink(t *testing.T) *smtpSink {
+	t.Helper()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sink := &smtpSink{ln: ln}
+	t.Cleanup(func() { ln.Close() })
+
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go sink.serve(conn)
+		}
+	}()
+	return sink
+}
+
+// serve speaks just enough SMTP for net/smtp: greeting, EHLO, MAIL, RCPT, DATA and QUIT
+func (s *smtpSink) serve(conn net.Conn) {
+	defer conn.Close()
+
+	if s.stall {
+		_, _ = io.Copy(io.Discard, conn) // Until the client gives up
+		return
+	}
+	r := bufio.NewReader(conn)
+	reply := func(line string) { _, _ = conn.Write([]byte(line + "\r\n")) }
+
+	reply("220 sink ready")
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		cmd := strings.ToUpper(strings.TrimSpace(line))
+
+		switch {
+		case strings.HasPrefix(cmd, "EHLO"), strings.HasPrefix(cmd, "HELO"):
+			reply("250 sink")
+		case strings.HasPrefix(cmd, "MAIL FROM"):
+			reply("250 OK")
+		case strings.HasPrefix(cmd, "RCPT TO"):
+			if s.reject != "" && strings.Contains(cmd, strings.ToUpper(s.reject)) {
+				reply("550 no such user")
+				continue
+			}
+			reply("250 OK")
+		case cmd == "DATA":
+			reply("354 end with .")
+			var data strings.Builder
+			for {
+				l, err := r.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if l == ".\r\n" {
+					break
+				}
+				data.WriteString(l)
+			}
+			s.mu.Lock()
+			s.messages = append(s.messages, data.String())
+			s.mu.Unlock()
+			reply("250 queued")
+		case cmd == "QUIT":
+			reply("221 bye")
+			return
+		default:
+			reply("250 OK")
+		}
+	}
+}
+
+// received returns messages received so far
+func (s *smtpSink) received() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.messages...)
+}
+
+// newTestNotifier creates a notifier sending through the sink without TLS
+func newTestNotifier(t *testing.T, sink *smtpSink, digest string) *Notifier {
+	t.Helper()
+
+	addr := sink.ln.Addr().(*net.TCPAddr)
+	n, err := NewChannelNotifier(models.Channel{Name: "ops", Type: models.ChannelEmail, Config: models.ChannelConfig{
+		"host":   addr.IP.String(),
+		"port":   addr.Port,
+		"tls":    TLSNone,
+		"from":   "Rattle <rattle@example.com>",
+		"to":     []any{"ops@example.com", "oncall@example.com"},
+		"digest": digest,
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return n.(*Notifier)
+}
+
+var app = docker.ContainerInfo{ID: "c1", ShortID: "c1", Name: "api", Image: "api:1"}
+
+func logEvent(line string) notify.Notification {
+	return notify.Notification{
+		Type:      notify.NotificationLogEvent,
+		EventType: models.EventTypeError,
+		Details:   line,
+		Container: app,
+		Time:      time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC),
+	}
+}
+
+func TestSendDeliversToSMTP(t *testing.T) {
+	sink := newSMTPSink(t)
+	en := newTestNotifier(t, sink, "")
+
+	if err := en.Send(logEvent("connection refused")); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+
+	msgs := sink.received()
+	if len(msgs) != 1 {
+		t.Fatalf("sink got %d messages, want 1", len(msgs))
+	}
+	if !strings.Contains(msgs[0], "connection refused") || !strings.Contains(msgs[0], "multipart/alternative") {
+		t.Errorf("message doesn't hold the log line in text and HTML:\n%s", msgs[0])
+	}
+}
+
+func TestSendFailsOnRejectedRecipient(t *testing.T) {
+	sink := newSMTPSink(t)
+	sink.reject = "oncall@example.com"
+	en := newTestNotifier(t, sink, "")
+
+	if err := en.Send(logEvent("boom")); err == nil {
+		t.Fatal("Send succeeded while a recipient was rejected")
+	}
+	if msgs := sink.received(); len(msgs) != 0 {
+		t.Errorf("sink got %d messages, want none", len(msgs))
+	}
+}
+
+func TestSendFailsWithoutServer(t *testing.T) {
+	sink := newSMTPSink(t)
+	en := newTestNotifier(t, sink, "")
+	sink.ln.Close()
+
+	if err := en.Send(logEvent("boom")); err == nil {
+		t.Fatal("Send succeeded while the SMTP server is down")
+	}
+}
+
+func TestSendTimesOutOnStalledServer(t *testing.T) {
+	prev := sessionTimeout
+	sessionTimeout = 200 * time.Millisecond
+	t.Cleanup(func() { sessionTimeout = prev })
+
+	sink := newSMTPSink(t)
+	sink.stall = true
+	en := newTestNotifier(t, sink, "")
+
+	done := make(chan error, 1)
+	go func() { done <- en.Send(logEvent("boom")) }()
+
+	select {
+	case err := <-done:
+		if err == nil {
+			t.Fatal("Send succeeded while the SMTP server stalled")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Send is still blocked on the stalled server")
+	}
+}
+
+func TestDigest(t *testing.T) {
+	sink := newSMTPSink(t)
+	en := newTestNotifier(t, sink, "15m")
+
+	if got := en.DigestPeriod(logEvent("a")); got != 15*time.Minute {
+		t.Errorf("DigestPeriod(log event) = %s, want 15m", got)
+	}
+	if got := en.DigestPeriod(notify.Notification{Type: notify.NotificationContainerStop, Container: app}); got != 0 {
+		t.Errorf("DigestPeriod(container stop) = %s, want 0", got)
+	}
+	if got := newTestNotifier(t, sink, "").DigestPeriod(logEvent("a")); got != 0 {
+		t.Errorf("DigestPeriod without digest = %s, want 0", got)
+	}
+
+	if err := en.SendDigest([]notify.Notification{logEvent("first failure"), logEvent("second failure")}); err != nil {
+		t.Fatalf("SendDigest: %v", err)
+	}
+
+	msgs := sink.received()
+	if len(msgs) != 1 {
+		t.Fatalf("sink got %d messages, want one digest", len(msgs))
+	}
+	for _, line := range []string{"first failure", "second failure"} {
+		if !strings.Contains(msgs[0], line) {
+			t.Errorf("digest is missing %q:\n%s", line, msgs[0])
+		}
+	}
+}
@@ -0,0 +1,133 @@
+package email
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"time"
+
+	"github.com/ilyxenc/rattle/internal/models"
+	"github.com/ilyxenc/rattle/internal/notify"
+)
+
+// eventColors are accent colors of the HTML body by event type
+var eventColors = map[string]string{
+	models.EventTypeCritical: "#992d22",
+	models.EventTypeError:    "#e74c3c",
+	models.EventTypeWarning:  "#f1c40f",
+	models.EventTypeInfo:     "#3498db",
+	models.EventTypeSuccess:  "#2ecc71",
+}
+
+// htmlView is the data of a single notification in the HTML template
+type htmlView struct {
+	Headline   string
+	Color      string
+	Rule       string
+	Details    string
+	Fields     []notify.Field
+	Stream     string
+	Containers []string
+	Meta       []notify.Field
+	Time       string
+}
+
+var htmlTemplate = template.Must(template.New("email").Parse(`<!DOCTYPE html>
+<html>
+<body style="font-family: -apple-system, Segoe UI, Helvetica, Arial, sans-serif; color: #222;">
+{{- if .Title }}
+<h2>{{ .Title }}</h2>
+{{- end }}
+{{- range .Events }}
+<div style="border-left: 4px solid {{ .Color }}; padding: 8px 12px; margin-bottom: 16px;">
+  <h3 style="margin: 0 0 8px 0;">{{ .Headline }}</h3>
+  {{- if .Rule }}
+  <p>Pattern: <code>{{ .Rule }}</code></p>
+  {{- end }}
+  {{- if .Details }}
+  <pre style="background: #f5f5f5; padding: 8px; white-space: pre-wrap; word-break: break-all;">{{ .Details }}</pre>
+  {{- end }}
+  {{- if or .Fields .Stream }}
+  <table style="border-collapse: collapse;">
+    {{- range .Fields }}
+    <tr><td style="padding-right: 12px;"><b>{{ .Key }}</b></td><td><code>{{ .Value }}</code></td></tr>
+    {{- end }}
+    {{- if .Stream }}
+    <tr><td style="padding-right: 12px;"><b>Stream</b></td><td><code>{{ .Stream }}</code></td></tr>
+    {{- end }}
+  </table>
+  {{- end }}
+  {{- if .Containers }}
+  <ul>
+    {{- range .Containers }}
+    <li><code>{{ . }}</code></li>
+    {{- end }}
+  </ul>
+  {{- end }}
+  <p style="color: #777; font-size: 12px;">
+    {{- range .Meta }}{{ .Key }}: <code>{{ .Value }}</code> · {{ end }}{{ .Time -}}
+  </p>
+</div>
+{{- end }}
+</body>
+</html>
+`))
+
+// renderNotification returns the subject, plain text and HTML bodies of a single notification
+func renderNotification(n notify.Notification) (string, string, string) {
+	html := renderHTML("", []notify.Notification{n})
+	return notify.Headline(n), notify.PlainText(n), html
+}
+
+// renderDigest returns the subject, plain text and HTML bodies of an email bundling log events
+func renderDigest(events []notify.Notification, period time.Duration) (string, string, string) {
+	subject := fmt.Sprintf("📬 Rattle digest: %d log events in %s", len(events), notify.FormatDuration(period))
+
+	var text bytes.Buffer
+	text.WriteString(subject)
+	for _, n := range events {
+		text.WriteString("\n\n----------------------------------------\n\n")
+		text.WriteString(notify.PlainText(n))
+	}
+
+	return subject, text.String(), renderHTML(subject, events)
+}
+
+// renderHTML renders notifications with the HTML template, with an optional title above them
+func renderHTML(title string, events []notify.Notification) string {
+	views := make([]htmlView, 0, len(events))
+	for _, n := range events {
+		color, ok := eventColors[notify.Severity(n)]
+		if !ok {
+			color = "#95a5a6"
+		}
+
+		v := htmlView{
+			Headline: notify.Headline(n),
+			Color:    color,
+			Rule:     n.Rule,
+			Details:  n.Details,
+			Fields:   n.Fields,
+			Stream:   n.Stream,
+			Time:     notify.FormatTime(notify.EventTime(n)),
+		}
+		if n.Type == notify.NotificationContainersSummary {
+			for _, ci := range n.Containers {
+				v.Containers = append(v.Containers, ci.ShortID+": "+ci.Name)
+			}
+		}
+		if notify.HasContainer(n) {
+			v.Meta = notify.MetaFields(n.Container)
+		}
+		views = append(views, v)
+	}
+
+	var b bytes.Buffer
+	if err := htmlTemplate.Execute(&b, struct {
+		Title  string
+		Events []htmlView
+	}{title, views}); err != nil {
+		return "<pre>" + template.HTMLEscapeString(err.Error()) + "</pre>"
+	}
+	return b.String()
+}
@@ -0,0 +1,215 @@
+package notify
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/ilyxenc/rattle/internal/config"
+	"github.com/ilyxenc/rattle/internal/docker"
+	"github.com/ilyxenc/rattle/internal/models"
+)
+
+// EventEmoji returns emoji for title based on event type
+func EventEmoji(eventType string) string {
+	switch eventType {
+	case models.EventTypeError:
+		return "❌"
+	case models.EventTypeWarning:
+		return "⚠️"
+	case models.EventTypeSuccess:
+		return "✅"
+	case models.EventTypeInfo:
+		return "ℹ️"
+	case models.EventTypeCritical:
+		return "🚨"
+	default:
+		return "📦"
+	}
+}
+
+// EventTitle returns the title of a log event based on event type, e.g. "Error in container"
+func EventTitle(eventType string) string {
+	switch eventType {
+	case models.EventTypeError:
+		return "Error in container"
+	case models.EventTypeWarning:
+		return "Warning in container"
+	case models.EventTypeSuccess:
+		return "Success in container"
+	case models.EventTypeInfo:
+		return "Info from container"
+	case models.EventTypeCritical:
+		return "Critical event in container"
+	default:
+		return "Log from container"
+	}
+}
+
+// Severity returns the event type that best describes the notification, used for colors and priorities
+func Severity(n Notification) string {
+	switch n.Type {
+	case NotificationLogEvent, NotificationLogRepeated, NotificationThreshold, NotificationAbsence:
+		if n.EventType != "" {
+			return n.EventType
+		}
+		return models.EventTypeError
+	case NotificationContainerStopWithError, NotificationEventsLost:
+		return models.EventTypeError
+	case NotificationContainerIdle, NotificationShutDownRattle:
+		return models.EventTypeWarning
+	case NotificationContainerStart, NotificationAbsenceRecovered, NotificationContainerResumed, NotificationEventsRestored:
+		return models.EventTypeSuccess
+	default:
+		return models.EventTypeInfo
+	}
+}
+
+// Headline returns a one-line plain text summary of the notification, e.g. "❌ Error in container: api"
+func Headline(n Notification) string {
+	name := n.Container.Name
+	window := FormatDuration(n.Window)
+
+	switch n.Type {
+	case NotificationLogEvent:
+		return fmt.Sprintf("%s %s: %s", EventEmoji(n.EventType), EventTitle(n.EventType), name)
+	case NotificationLogRepeated:
+		return fmt.Sprintf("🔁 Repeated %d times in %s: %s", n.Count, window, name)
+	case NotificationThreshold:
+		return fmt.Sprintf("%s %d matches in %s: %s", EventEmoji(n.EventType), n.Count, window, name)
+	case NotificationAbsence:
+		return fmt.Sprintf("%s No expected log line for %s: %s", EventEmoji(n.EventType), window, name)
+	case NotificationAbsenceRecovered:
+		return fmt.Sprintf("✅ Expected log line is back after %s: %s", window, name)
+	case NotificationContainerIdle:
+		return fmt.Sprintf("💤 No output for %s: %s", window, name)
+	case NotificationContainerResumed:
+		return fmt.Sprintf("🔊 Output resumed after %s: %s", window, name)
+	case NotificationContainerStart:
+		return "✅ Container started: " + name
+	case NotificationContainerStop:
+		return "🛑 Container stopped: " + name
+	case NotificationContainerStopWithError:
+		return "🛑 Container stopped with error: " + name
+	case NotificationShutDownRattle:
+		return "🛑 Rattle is shutting down..."
+	case NotificationStartedRattle:
+		return fmt.Sprintf("🚀 Rattle started in %s mode", config.Cfg.Env)
+	case NotificationContainersSummary:
+		if len(n.Containers) == 0 {
+			return "📦 No active containers running"
+		}
+		return fmt.Sprintf("📊 %d active containers", len(n.Containers))
+	case NotificationEventsLost:
+		return "⚠️ Docker events stream lost"
+	case NotificationEventsRestored:
+		return "🔄 Docker events stream restored"
+	default:
+		return "📦 Unknown notification type"
+	}
+}
+
+// HasContainer reports whether the notification is about a single container, so its metadata should be shown
+func HasContainer(n Notification) bool {
+	return n.Container.ID != ""
+}
+
+// MetaFields returns container metadata shown in notifications: short ID, name and image
+func MetaFields(ci docker.ContainerInfo) []Field {
+	return []Field{
+		{Key: "ID", Value: ci.ShortID},
+		{Key: "Name", Value: ci.Name},
+		{Key: "Image", Value: ci.Image},
+	}
+}
+
+// EventTime returns when the event happened, or the current time if it's unknown
+func EventTime(n Notification) time.Time {
+	if n.Time.IsZero() {
+		return time.Now()
+	}
+	return n.Time
+}
+
+// FormatTime formats the event time with milliseconds
+func FormatTime(t time.Time) string {
+	return t.Format("2006-01-02T15:04:05.000Z07:00")
+}
+
+// FormatDuration returns a compact duration like "5m", "1h30m" or "45s"
+func FormatDuration(d time.Duration) string {
+	d = d.Round(time.Second)
+	s := d.String()
+	if strings.HasSuffix(s, "m0s") {
+		s = strings.TrimSuffix(s, "0s")
+	}
+	if strings.HasSuffix(s, "h0m") {
+		s = strings.TrimSuffix(s, "0m")
+	}
+	return s
+}
+
+// PlainText returns the notification as plain text: headline, details and container metadata
+func PlainText(n Notification) string {
+	return Headline(n) + "\n\n" + PlainBody(n)
+}
+
+// PlainBody returns details and container metadata of the notification as plain text, without the headline
+func PlainBody(n Notification) string {
+	sections := make([]string, 0, 7)
+
+	if n.Rule != "" {
+		sections = append(sections, "Pattern: "+n.Rule)
+	}
+	if n.Details != "" {
+		sections = append(sections, n.Details)
+	}
+	if len(n.Fields) > 0 {
+		lines := make([]string, 0, len(n.Fields))
+		for _, f := range n.Fields {
+			lines = append(lines, f.Key+": "+f.Value)
+		}
+		sections = append(sections, strings.Join(lines, "\n"))
+	}
+	if n.Stream != "" {
+		sections = append(sections, "Stream: "+n.Stream)
+	}
+
+	if n.Type == NotificationContainersSummary && len(n.Containers) > 0 {
+		lines := make([]string, 0, len(n.Containers))
+		for _, ci := range n.Containers {
+			lines = append(lines, fmt.Sprintf("- %s: %s", ci.ShortID, ci.Name))
+		}
+		sections = append(sections, strings.Join(lines, "\n"))
+	}
+
+	if HasContainer(n) {
+		lines := make([]string, 0, 3)
+		for _, f := range MetaFields(n.Container) {
+			lines = append(lines, f.Key+": "+f.Value)
+		}
+		sections = append(sections, strings.Join(lines, "\n"))
+	}
+
+	sections = append(sections, FormatTime(EventTime(n)))
+	return strings.Join(sections, "\n\n")
+}
+
+// Truncate cuts the text to at most `limit` characters, marking the cut with "…"
+func Truncate(text string, limit int) string {
+	if utf8.RuneCountInString(text) <= limit {
+		return text
+	}
+
+	runes := []rune(text)
+	return string(runes[:limit-1]) + "…"
+}
+
+// TruncateBytes cuts the text to at most `limit` bytes without splitting a character
+func TruncateBytes(text string, limit int) string {
+	if len(text) <= limit {
+		return text
+	}
+	return strings.ToValidUTF8(text[:limit], "")
+}
@@ -0,0 +1,42 @@
+package notify
+
+import "testing"
+
+func TestTruncate(t *testing.T) {
+	tests := []struct {
+		text  string
+		limit int
+		want  string
+	}{
+		{"short", 10, "short"},
+		{"exactly10!", 10, "exactly10!"},
+		{"a bit too long", 10, "a bit too…"},
+		{"ошибка соединения", 7, "ошибка…"},
+		{"🔥🔥🔥", 2, "🔥…"},
+	}
+
+	for _, tt := range tests {
+		if got := Truncate(tt.text, tt.limit); got != tt.want {
+			t.Errorf("Truncate(%q, %d) = %q, want %q", tt.text, tt.limit, got, tt.want)
+		}
+	}
+}
+
+func TestTruncateBytes(t *testing.T) {
+	tests := []struct {
+		text  string
+		limit int
+		want  string
+	}{
+		{"short", 10, "short"},
+		{"a bit too long", 5, "a bit"},
+		{"ошибка", 5, "ош"}, // Two bytes per letter, the split one is dropped
+		{"🔥🔥", 6, "🔥"},
+	}
+
+	for _, tt := range tests {
+		if got := TruncateBytes(tt.text, tt.limit); got != tt.want {
+			t.Errorf("TruncateBytes(%q, %d) = %q, want %q", tt.text, tt.limit, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package gotify
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/go-resty/resty/v2"
+	"github.com/ilyxenc/rattle/internal/models"
+	"github.com/ilyxenc/rattle/internal/notify"
+)
+
+// priorities map event types to Gotify priorities (0-10). Android clients show 8+ as high priority
+var priorities = map[string]int{
+	models.EventTypeCritical: 10,
+	models.EventTypeError:    8,
+	models.EventTypeWarning:  5,
+	models.EventTypeInfo:     2,
+	models.EventTypeSuccess:  1,
+}
+
+// channelConfig is the config of a Gotify notification channel
+type channelConfig struct {
+	Server string `json:"server"` // Gotify server URL, e.g. https://gotify.example.com
+	Token  string `json:"token"`  // Application token
+}
+
+// Message is a Gotify message
+type Message struct {
+	Title    string `json:"title"`
+	Message  string `json:"message"`
+	Priority int    `json:"priority"`
+}
+
+// Notifier sends notifications to a Gotify application
+type Notifier struct {
+	name   string
+	server string
+	client *resty.Client
+}
+
+// NewChannelNotifier creates a notifier from a notification channel stored in DB
+func NewChannelNotifier(ch models.Channel) (notify.Notifier, error) {
+	var cfg channelConfig
+	if err := ch.Config.Decode(&cfg); err != nil {
+		return nil, err
+	}
+
+	if cfg.Server == "" {
+		return nil, errors.New("server is required")
+	}
+	if cfg.Token == "" {
+		return nil, errors.New("token is required")
+	}
+
+	client := notify.NewHTTPClient(notify.DefaultRetry).
+		SetHeader("X-Gotify-Key", cfg.Token)
+
+	return &Notifier{
+		name:   ch.Name,
+		server: strings.TrimRight(cfg.Server, "/"),
+		client: client,
+	}, nil
+}
+
+// Name identifies the channel in logs
+func (gn *Notifier) Name() string {
+	return "gotify:" + gn.name
+}
+
+// Send posts the notification with the priority of its event type
+func (gn *Notifier) Send(n notify.Notification) error {
+	resp, err := gn.client.R().
+		SetHeader("Content-Type", "application/json").
+		SetBody(RenderMessage(n)).
+		Post(gn.server + "/message")
+
+	if err != nil {
+		return fmt.Errorf("failed to send Gotify message: %w", err)
+	}
+
+	if !resp.IsSuccess() {
+		return fmt.Errorf("gotify responded with status %d: %s", resp.StatusCode(), resp.String())
+	}
+
+	return nil
+}
+
+// RenderMessage formats the notification as a Gotify message
+func RenderMessage(n notify.Notification) Message {
+	priority, ok := priorities[notify.Severity(n)]
+	if !ok {
+		priority = 5
+	}
+
+	return Message{
+		Title:    notify.Headline(n),
+		Message:  notify.PlainBody(n),
+		Priority: priority,
+	}
+}
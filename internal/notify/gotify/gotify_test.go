@@ -0,0 +1,120 @@
+package gotify
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ilyxenc/rattle/internal/docker"
+	"github.com/ilyxenc/rattle/internal/models"
+	"github.com/ilyxenc/rattle/internal/notify"
+)
+
+var event = notify.Notification{
+	Type:      notify.NotificationLogEvent,
+	EventType: models.EventTypeError,
+	Details:   "db down",
+	Container: docker.ContainerInfo{ID: "c1", ShortID: "c1", Name: "api", Image: "api:1"},
+	Time:      time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC),
+}
+
+// received is a request received by the server stand-in
+type received struct {
+	path string
+	key  string
+	body []byte
+}
+
+// newServer starts a Gotify server stand-in answering with the status and returns a notifier sending to it
+func newServer(t *testing.T, status int) (notify.Notifier, <-chan received) {
+	t.Helper()
+
+	reqs := make(chan received, 10)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		reqs <- received{path: r.URL.Path, key: r.Header.Get("X-Gotify-Key"), body: body}
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(srv.Close)
+
+	n, err := NewChannelNotifier(models.Channel{
+		Name:   "phone",
+		Type:   models.ChannelGotify,
+		Config: models.ChannelConfig{"server": srv.URL + "/", "token": "app-token"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return n, reqs
+}
+
+func TestSendPriority(t *testing.T) {
+	tests := []struct {
+		notification notify.Notification
+		priority     int
+	}{
+		{notify.Notification{Type: notify.NotificationLogEvent, EventType: models.EventTypeCritical}, 10},
+		{notify.Notification{Type: notify.NotificationLogEvent, EventType: models.EventTypeError}, 8},
+		{notify.Notification{Type: notify.NotificationLogEvent, EventType: models.EventTypeWarning}, 5},
+		{notify.Notification{Type: notify.NotificationLogEvent, EventType: models.EventTypeInfo}, 2},
+		{notify.Notification{Type: notify.NotificationContainerStart}, 1},
+		{notify.Notification{Type: notify.NotificationContainerIdle}, 5},
+	}
+
+	nt, reqs := newServer(t, http.StatusOK)
+	for _, tt := range tests {
+		n := tt.notification
+		n.Container = event.Container
+		if err := nt.Send(n); err != nil {
+			t.Fatal(err)
+		}
+
+		r := <-reqs
+		var msg Message
+		if err := json.Unmarshal(r.body, &msg); err != nil {
+			t.Fatal(err)
+		}
+		if msg.Priority != tt.priority {
+			t.Errorf("%s %s: priority %d, want %d", n.Type, n.EventType, msg.Priority, tt.priority)
+		}
+	}
+}
+
+func TestSendAuth(t *testing.T) {
+	nt, reqs := newServer(t, http.StatusOK)
+
+	if err := nt.Send(event); err != nil {
+		t.Fatal(err)
+	}
+
+	r := <-reqs
+	if r.path != "/message" {
+		t.Errorf("posted to %s, want /message", r.path)
+	}
+	if r.key != "app-token" {
+		t.Errorf("X-Gotify-Key = %q, want the application token", r.key)
+	}
+}
+
+func TestSendFailsOnErrorStatus(t *testing.T) {
+	nt, _ := newServer(t, http.StatusUnauthorized)
+
+	if err := nt.Send(event); err == nil || !strings.Contains(err.Error(), "401") {
+		t.Errorf("Send() error = %v, want status 401", err)
+	}
+}
+
+func TestNewChannelNotifierConfig(t *testing.T) {
+	for _, config := range []models.ChannelConfig{
+		{"token": "app-token"},
+		{"server": "https://gotify.example.com"},
+	} {
+		if _, err := NewChannelNotifier(models.Channel{Type: models.ChannelGotify, Config: config}); err == nil {
+			t.Errorf("config %v was accepted", config)
+		}
+	}
+}
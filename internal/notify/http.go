@@ -0,0 +1,41 @@
+package notify
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+)
+
+// requestTimeout bounds a single HTTP request of a notifier
+const requestTimeout = 10 * time.Second
+
+// RetryPolicy configures retries of HTTP requests made by notifiers
+type RetryPolicy struct {
+	Count      int           // Retries after the first attempt
+	Wait       time.Duration // Delay before the first retry, doubled on each attempt with jitter
+	MaxWait    time.Duration // Upper bound of the delay
+	RateLimits bool          // Retry 429 responses too. False if the notifier handles rate limits itself
+}
+
+// DefaultRetry is the retry policy of most notifiers
+var DefaultRetry = RetryPolicy{Count: 3, Wait: 2 * time.Second, MaxWait: 30 * time.Second, RateLimits: true}
+
+// NewHTTPClient returns an HTTP client for a notifier that retries network errors and 5xx responses with the policy
+func NewHTTPClient(p RetryPolicy) *resty.Client {
+	return resty.New().
+		SetTimeout(requestTimeout).
+		SetRetryCount(p.Count).
+		SetRetryWaitTime(p.Wait).
+		SetRetryMaxWaitTime(p.MaxWait).
+		AddRetryCondition(func(r *resty.Response, err error) bool {
+			// Retry on network errors, rate limits (unless handled by the notifier) and 5xx HTTP status codes
+			if err != nil {
+				return true
+			}
+			if r.StatusCode() == http.StatusTooManyRequests {
+				return p.RateLimits
+			}
+			return r.StatusCode() >= 500
+		})
+}
@@ -0,0 +1,61 @@
+package notify
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestNewHTTPClientRetries(t *testing.T) {
+	tests := []struct {
+		name       string
+		statuses   []int // Responses in order, the last one repeats
+		rateLimits bool
+		wantStatus int
+		wantCalls  int32
+	}{
+		{"success", []int{200}, true, 200, 1},
+		{"server error is retried", []int{503, 502, 200}, true, 200, 3},
+		{"server error until retries run out", []int{500}, true, 500, 3},
+		{"rate limit is retried", []int{429, 200}, true, 200, 2},
+		{"rate limit handled by the notifier", []int{429, 200}, false, 429, 1},
+		{"client error isn't retried", []int{400, 200}, true, 400, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls atomic.Int32
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				i := int(calls.Add(1)) - 1
+				w.WriteHeader(tt.statuses[min(i, len(tt.statuses)-1)])
+			}))
+			defer srv.Close()
+
+			client := NewHTTPClient(RetryPolicy{Count: 2, Wait: time.Millisecond, MaxWait: 5 * time.Millisecond, RateLimits: tt.rateLimits})
+			resp, err := client.R().Post(srv.URL)
+			if err != nil {
+				t.Fatalf("request failed: %v", err)
+			}
+			if resp.StatusCode() != tt.wantStatus || calls.Load() != tt.wantCalls {
+				t.Errorf("got status %d after %d calls, want %d after %d", resp.StatusCode(), calls.Load(), tt.wantStatus, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestNewHTTPClientRetriesNetworkErrors(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	url := srv.URL
+	srv.Close() // Nothing listens anymore
+
+	client := NewHTTPClient(RetryPolicy{Count: 2, Wait: time.Millisecond, MaxWait: 5 * time.Millisecond})
+	resp, err := client.R().Post(url)
+	if err == nil {
+		t.Fatal("request to a closed server succeeded")
+	}
+	if resp.Request.Attempt != 3 {
+		t.Errorf("made %d attempts, want 3", resp.Request.Attempt)
+	}
+}
@@ -0,0 +1,169 @@
+package matrix
+
+import (
+	"errors"
+	"fmt"
+	"html"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+	"github.com/ilyxenc/rattle/internal/models"
+	"github.com/ilyxenc/rattle/internal/notify"
+)
+
+// Message types
+const (
+	MsgTypeText   = "m.text"   // Regular message, used for errors and critical events so clients notify about them
+	MsgTypeNotice = "m.notice" // Bot message that clients usually don't notify about, used for everything else
+)
+
+// channelConfig is the config of a Matrix notification channel
+type channelConfig struct {
+	Homeserver  string `json:"homeserver"`   // Homeserver URL, e.g. https://matrix.example.com
+	AccessToken string `json:"access_token"` // Access token of the bot user
+	RoomID      string `json:"room_id"`      // Room ID, e.g. !abc:example.com. The bot must be a member
+}
+
+// Message is the content of an m.room.message event with HTML formatting
+type Message struct {
+	MsgType       string `json:"msgtype"`
+	Body          string `json:"body"`
+	Format        string `json:"format"`
+	FormattedBody string `json:"formatted_body"`
+}
+
+// Notifier sends notifications to a Matrix room through the client-server API
+type Notifier struct {
+	name        string
+	homeserver  string
+	accessToken string
+	roomID      string
+	client      *resty.Client
+	txn         atomic.Uint64 // Transaction counter for notifications sent without a delivery
+}
+
+// NewChannelNotifier creates a notifier from a notification channel stored in DB
+func NewChannelNotifier(ch models.Channel) (notify.Notifier, error) {
+	var cfg channelConfig
+	if err := ch.Config.Decode(&cfg); err != nil {
+		return nil, err
+	}
+
+	if cfg.Homeserver == "" {
+		return nil, errors.New("homeserver is required")
+	}
+	if cfg.AccessToken == "" {
+		return nil, errors.New("access_token is required")
+	}
+	if cfg.RoomID == "" {
+		return nil, errors.New("room_id is required")
+	}
+
+	client := notify.NewHTTPClient(notify.DefaultRetry)
+
+	return &Notifier{
+		name:        ch.Name,
+		homeserver:  strings.TrimRight(cfg.Homeserver, "/"),
+		accessToken: cfg.AccessToken,
+		roomID:      cfg.RoomID,
+		client:      client,
+	}, nil
+}
+
+// Name identifies the channel in logs
+func (mn *Notifier) Name() string {
+	return "matrix:" + mn.name
+}
+
+// Send posts the notification to the room as an m.room.message event
+func (mn *Notifier) Send(n notify.Notification) error {
+	endpoint := fmt.Sprintf("%s/_matrix/client/v3/rooms/%s/send/m.room.message/%s",
+		mn.homeserver, url.PathEscape(mn.roomID), url.PathEscape(mn.txnID(n)))
+
+	resp, err := mn.client.R().
+		SetAuthToken(mn.accessToken).
+		SetHeader("Content-Type", "application/json").
+		SetBody(RenderMessage(n)).
+		Put(endpoint)
+
+	if err != nil {
+		return fmt.Errorf("failed to send Matrix message: %w", err)
+	}
+
+	if !resp.IsSuccess() {
+		return fmt.Errorf("matrix responded with status %d: %s", resp.StatusCode(), resp.String())
+	}
+
+	return nil
+}
+
+// txnID returns the transaction ID of the message. Outbox retries of a delivery reuse its ID,
+// so the homeserver ignores a message it already accepted
+func (mn *Notifier) txnID(n notify.Notification) string {
+	if n.Delivery != 0 {
+		return fmt.Sprintf("rattle-delivery-%d", n.Delivery)
+	}
+	return fmt.Sprintf("rattle-%d-%d", time.Now().UnixNano(), mn.txn.Add(1))
+}
+
+// RenderMessage formats the notification as a message with plain text and HTML bodies.
+// Errors and critical events are sent as m.text so clients notify about them, others as m.notice
+func RenderMessage(n notify.Notification) Message {
+	msgType := MsgTypeNotice
+	if models.EventTypeSeverity[notify.Severity(n)] >= models.EventTypeSeverity[models.EventTypeError] {
+		msgType = MsgTypeText
+	}
+
+	return Message{
+		MsgType:       msgType,
+		Body:          notify.PlainText(n),
+		Format:        "org.matrix.custom.html",
+		FormattedBody: renderHTML(n),
+	}
+}
+
+// renderHTML formats the notification with the HTML subset supported by Matrix clients
+func renderHTML(n notify.Notification) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "<b>%s</b>", html.EscapeString(notify.Headline(n)))
+
+	if n.Rule != "" {
+		fmt.Fprintf(&b, "<br/>Pattern: <code>%s</code>", html.EscapeString(n.Rule))
+	}
+	if n.Details != "" {
+		fmt.Fprintf(&b, "<pre><code>%s</code></pre>", html.EscapeString(n.Details))
+	}
+
+	lines := make([]string, 0, len(n.Fields)+4)
+	for _, f := range n.Fields {
+		lines = append(lines, fmt.Sprintf("<b>%s:</b> <code>%s</code>", html.EscapeString(f.Key), html.EscapeString(f.Value)))
+	}
+	if n.Stream != "" {
+		lines = append(lines, fmt.Sprintf("<b>Stream:</b> <code>%s</code>", n.Stream))
+	}
+	if len(lines) > 0 {
+		b.WriteString("<br/>" + strings.Join(lines, "<br/>"))
+	}
+
+	if n.Type == notify.NotificationContainersSummary && len(n.Containers) > 0 {
+		b.WriteString("<ul>")
+		for _, ci := range n.Containers {
+			fmt.Fprintf(&b, "<li><code>%s</code>: %s</li>", ci.ShortID, html.EscapeString(ci.Name))
+		}
+		b.WriteString("</ul>")
+	}
+
+	meta := make([]string, 0, 4)
+	if notify.HasContainer(n) {
+		for _, f := range notify.MetaFields(n.Container) {
+			meta = append(meta, fmt.Sprintf("%s: <code>%s</code>", f.Key, html.EscapeString(f.Value)))
+		}
+	}
+	meta = append(meta, notify.FormatTime(notify.EventTime(n)))
+	fmt.Fprintf(&b, "<br/><sub>📦 %s</sub>", strings.Join(meta, " · "))
+
+	return b.String()
+}
@@ -0,0 +1,149 @@
+package matrix
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ilyxenc/rattle/internal/docker"
+	"github.com/ilyxenc/rattle/internal/models"
+	"github.com/ilyxenc/rattle/internal/notify"
+)
+
+var event = notify.Notification{
+	Type:      notify.NotificationLogEvent,
+	EventType: models.EventTypeError,
+	Details:   "db <main> down",
+	Container: docker.ContainerInfo{ID: "c1", ShortID: "c1", Name: "api", Image: "api:1"},
+	Time:      time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC),
+}
+
+// received is a request received by the homeserver stand-in
+type received struct {
+	method string
+	path   string
+	auth   string
+	body   []byte
+}
+
+// newHomeserver starts a homeserver stand-in answering with the status and returns a notifier sending to it
+func newHomeserver(t *testing.T, status int) (notify.Notifier, <-chan received) {
+	t.Helper()
+
+	reqs := make(chan received, 10)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		reqs <- received{method: r.Method, path: r.URL.Path, auth: r.Header.Get("Authorization"), body: body}
+		w.WriteHeader(status)
+		_, _ = io.WriteString(w, `{"event_id": "$1"}`)
+	}))
+	t.Cleanup(srv.Close)
+
+	n, err := NewChannelNotifier(models.Channel{
+		Name: "ops",
+		Type: models.ChannelMatrix,
+		Config: models.ChannelConfig{
+			"homeserver":   srv.URL + "/",
+			"access_token": "secret",
+			"room_id":      "!room:example.com",
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return n, reqs
+}
+
+// txnID returns the transaction ID of the send request
+func txnID(t *testing.T, r received) string {
+	t.Helper()
+
+	prefix := "/_matrix/client/v3/rooms/!room:example.com/send/m.room.message/"
+	if r.method != http.MethodPut || !strings.HasPrefix(r.path, prefix) {
+		t.Fatalf("request = %s %s, want PUT %s<txn>", r.method, r.path, prefix)
+	}
+	return strings.TrimPrefix(r.path, prefix)
+}
+
+func TestSendPutsMessage(t *testing.T) {
+	nt, reqs := newHomeserver(t, http.StatusOK)
+
+	if err := nt.Send(event); err != nil {
+		t.Fatal(err)
+	}
+
+	r := <-reqs
+	txnID(t, r)
+	if r.auth != "Bearer secret" {
+		t.Errorf("Authorization = %q, want the access token", r.auth)
+	}
+
+	var msg Message
+	if err := json.Unmarshal(r.body, &msg); err != nil {
+		t.Fatal(err)
+	}
+	if msg.MsgType != MsgTypeText {
+		t.Errorf("msgtype = %q, want %q for errors", msg.MsgType, MsgTypeText)
+	}
+	if !strings.Contains(msg.FormattedBody, "db &lt;main&gt; down") {
+		t.Errorf("formatted body isn't escaped: %s", msg.FormattedBody)
+	}
+}
+
+func TestRetriesReuseTxnID(t *testing.T) {
+	nt, reqs := newHomeserver(t, http.StatusOK)
+
+	retried := event
+	retried.Delivery = 7
+	for range 2 {
+		if err := nt.Send(retried); err != nil {
+			t.Fatal(err)
+		}
+	}
+	first, second := txnID(t, <-reqs), txnID(t, <-reqs)
+	if first != second {
+		t.Errorf("attempts of one delivery use txn IDs %q and %q, want the same", first, second)
+	}
+
+	for range 2 {
+		if err := nt.Send(event); err != nil {
+			t.Fatal(err)
+		}
+	}
+	first, second = txnID(t, <-reqs), txnID(t, <-reqs)
+	if first == second {
+		t.Errorf("direct sends share txn ID %q", first)
+	}
+}
+
+func TestSendFailsOnErrorStatus(t *testing.T) {
+	nt, _ := newHomeserver(t, http.StatusForbidden)
+
+	if err := nt.Send(event); err == nil || !strings.Contains(err.Error(), "403") {
+		t.Errorf("Send() error = %v, want status 403", err)
+	}
+}
+
+func TestRenderMessageType(t *testing.T) {
+	tests := []struct {
+		eventType string
+		want      string
+	}{
+		{models.EventTypeCritical, MsgTypeText},
+		{models.EventTypeError, MsgTypeText},
+		{models.EventTypeWarning, MsgTypeNotice},
+		{models.EventTypeInfo, MsgTypeNotice},
+	}
+
+	for _, tt := range tests {
+		n := event
+		n.EventType = tt.eventType
+		if got := RenderMessage(n).MsgType; got != tt.want {
+			t.Errorf("RenderMessage(%s).MsgType = %q, want %q", tt.eventType, got, tt.want)
+		}
+	}
+}
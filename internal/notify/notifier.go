@@ -0,0 +1,48 @@
+package notify
+
+import (
+	"fmt"
+	"time"
+)
+
+// Notifier delivers notifications to a single destination (chat, channel, endpoint).
+// Each implementation formats notifications for its own destination.
+// Notifiers that queue messages also implement io.Closer to flush them when replaced or on shutdown
+type Notifier interface {
+	// Name identifies the destination in logs and the outbox. Unique among destinations
+	Name() string
+	// Send formats and delivers the notification
+	Send(n Notification) error
+}
+
+// PartSender is implemented by notifiers that send a notification as several messages (e.g. split long log text).
+// The outbox remembers how many parts were sent, so a retry resumes with the part that failed
+type PartSender interface {
+	// SendParts sends the parts of the notification starting with part `from` (0-based).
+	// Returns the number of parts sent so far, including those before `from`
+	SendParts(n Notification, from int) (int, error)
+}
+
+// Digester is implemented by notifiers that bundle notifications into one message sent once per period (e.g. email digests).
+// The outbox keeps bundled notifications until the digest is due and marks them sent only when it's delivered
+type Digester interface {
+	// DigestPeriod returns how long the notification waits to be sent with others, 0 to send it right away
+	DigestPeriod(n Notification) time.Duration
+	// SendDigest sends the notifications as one message
+	SendDigest(ns []Notification) error
+}
+
+// RetryAfterError is returned by notifiers when the destination asked to wait before sending again.
+// The outbox schedules the next attempt exactly after the delay
+type RetryAfterError struct {
+	After time.Duration // How long to wait before the next attempt
+	Err   error         // The rate limit error from the destination
+}
+
+func (e *RetryAfterError) Error() string {
+	return fmt.Sprintf("rate limited, retry after %s: %v", e.After, e.Err)
+}
+
+func (e *RetryAfterError) Unwrap() error {
+	return e.Err
+}
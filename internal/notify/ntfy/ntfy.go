@@ -0,0 +1,132 @@
+package ntfy
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/go-resty/resty/v2"
+	"github.com/ilyxenc/rattle/internal/models"
+	"github.com/ilyxenc/rattle/internal/notify"
+)
+
+// DefaultServer is the public ntfy server
+const DefaultServer = "https://ntfy.sh"
+
+// priorities map event types to ntfy priorities: 5 urgent, 4 high, 3 default, 2 low, 1 min
+var priorities = map[string]int{
+	models.EventTypeCritical: 5,
+	models.EventTypeError:    4,
+	models.EventTypeWarning:  3,
+	models.EventTypeInfo:     2,
+	models.EventTypeSuccess:  2,
+}
+
+// tags map event types to ntfy tags, which are shown as emoji
+var tags = map[string]string{
+	models.EventTypeCritical: "rotating_light",
+	models.EventTypeError:    "x",
+	models.EventTypeWarning:  "warning",
+	models.EventTypeInfo:     "information_source",
+	models.EventTypeSuccess:  "white_check_mark",
+}
+
+// channelConfig is the config of an ntfy notification channel
+type channelConfig struct {
+	Server   string `json:"server"`   // Optional, https://ntfy.sh by default
+	Topic    string `json:"topic"`    // Topic to publish to
+	Token    string `json:"token"`    // Optional access token
+	Username string `json:"username"` // Optional basic auth, used if there is no token
+	Password string `json:"password"`
+}
+
+// Message is an ntfy JSON publish request
+type Message struct {
+	Topic    string   `json:"topic"`
+	Title    string   `json:"title"`
+	Message  string   `json:"message"`
+	Priority int      `json:"priority"`
+	Tags     []string `json:"tags,omitempty"`
+}
+
+// Notifier publishes notifications to an ntfy topic
+type Notifier struct {
+	name   string
+	server string
+	topic  string
+	client *resty.Client
+}
+
+// NewChannelNotifier creates a notifier from a notification channel stored in DB
+func NewChannelNotifier(ch models.Channel) (notify.Notifier, error) {
+	var cfg channelConfig
+	if err := ch.Config.Decode(&cfg); err != nil {
+		return nil, err
+	}
+
+	if cfg.Topic == "" {
+		return nil, errors.New("topic is required")
+	}
+	if cfg.Server == "" {
+		cfg.Server = DefaultServer
+	}
+
+	client := notify.NewHTTPClient(notify.DefaultRetry)
+
+	if cfg.Token != "" {
+		client.SetAuthToken(cfg.Token)
+	} else if cfg.Username != "" {
+		client.SetBasicAuth(cfg.Username, cfg.Password)
+	}
+
+	return &Notifier{
+		name:   ch.Name,
+		server: strings.TrimRight(cfg.Server, "/"),
+		topic:  cfg.Topic,
+		client: client,
+	}, nil
+}
+
+// Name identifies the channel in logs
+func (nn *Notifier) Name() string {
+	return "ntfy:" + nn.name
+}
+
+// Send publishes the notification with the priority of its event type
+func (nn *Notifier) Send(n notify.Notification) error {
+	resp, err := nn.client.R().
+		SetHeader("Content-Type", "application/json").
+		SetBody(RenderMessage(n, nn.topic)).
+		Post(nn.server) // JSON messages are published to the server root
+
+	if err != nil {
+		return fmt.Errorf("failed to send ntfy message: %w", err)
+	}
+
+	if !resp.IsSuccess() {
+		return fmt.Errorf("ntfy responded with status %d: %s", resp.StatusCode(), resp.String())
+	}
+
+	return nil
+}
+
+// RenderMessage formats the notification as an ntfy message for the topic
+func RenderMessage(n notify.Notification, topic string) Message {
+	severity := notify.Severity(n)
+
+	priority, ok := priorities[severity]
+	if !ok {
+		priority = 3
+	}
+
+	msg := Message{
+		Topic:    topic,
+		Title:    notify.Headline(n),
+		Message:  notify.PlainBody(n),
+		Priority: priority,
+	}
+	if tag, ok := tags[severity]; ok {
+		msg.Tags = []string{tag}
+	}
+	return msg
+}
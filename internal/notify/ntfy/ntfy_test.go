@@ -0,0 +1,133 @@
+package ntfy
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ilyxenc/rattle/internal/docker"
+	"github.com/ilyxenc/rattle/internal/models"
+	"github.com/ilyxenc/rattle/internal/notify"
+)
+
+var event = notify.Notification{
+	Type:      notify.NotificationLogEvent,
+	EventType: models.EventTypeError,
+	Details:   "db down",
+	Container: docker.ContainerInfo{ID: "c1", ShortID: "c1", Name: "api", Image: "api:1"},
+	Time:      time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC),
+}
+
+// received is a request received by the server stand-in
+type received struct {
+	path string
+	auth string
+	body []byte
+}
+
+// newServer starts an ntfy server stand-in answering with the status and returns a notifier publishing to it
+func newServer(t *testing.T, status int, config models.ChannelConfig) (notify.Notifier, <-chan received) {
+	t.Helper()
+
+	reqs := make(chan received, 10)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		reqs <- received{path: r.URL.Path, auth: r.Header.Get("Authorization"), body: body}
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(srv.Close)
+
+	config["server"] = srv.URL
+	config["topic"] = "alerts"
+	n, err := NewChannelNotifier(models.Channel{Name: "phone", Type: models.ChannelNtfy, Config: config})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return n, reqs
+}
+
+func TestSendPriority(t *testing.T) {
+	tests := []struct {
+		notification notify.Notification
+		priority     int
+		tag          string
+	}{
+		{notify.Notification{Type: notify.NotificationLogEvent, EventType: models.EventTypeCritical}, 5, "rotating_light"},
+		{notify.Notification{Type: notify.NotificationLogEvent, EventType: models.EventTypeError}, 4, "x"},
+		{notify.Notification{Type: notify.NotificationLogEvent, EventType: models.EventTypeWarning}, 3, "warning"},
+		{notify.Notification{Type: notify.NotificationLogEvent, EventType: models.EventTypeInfo}, 2, "information_source"},
+		{notify.Notification{Type: notify.NotificationContainerStart}, 2, "white_check_mark"},
+		{notify.Notification{Type: notify.NotificationContainerStopWithError}, 4, "x"},
+	}
+
+	nt, reqs := newServer(t, http.StatusOK, models.ChannelConfig{})
+	for _, tt := range tests {
+		n := tt.notification
+		n.Container = event.Container
+		if err := nt.Send(n); err != nil {
+			t.Fatal(err)
+		}
+
+		r := <-reqs
+		var msg Message
+		if err := json.Unmarshal(r.body, &msg); err != nil {
+			t.Fatal(err)
+		}
+		if r.path != "/" || msg.Topic != "alerts" {
+			t.Errorf("published to %s topic %q, want the server root with topic alerts", r.path, msg.Topic)
+		}
+		if msg.Priority != tt.priority || len(msg.Tags) != 1 || msg.Tags[0] != tt.tag {
+			t.Errorf("%s %s: priority %d tags %v, want %d [%s]", n.Type, n.EventType, msg.Priority, msg.Tags, tt.priority, tt.tag)
+		}
+	}
+}
+
+func TestSendAuth(t *testing.T) {
+	tests := []struct {
+		name   string
+		config models.ChannelConfig
+		want   string
+	}{
+		{"token", models.ChannelConfig{"token": "tk_1", "username": "bob", "password": "pw"}, "Bearer tk_1"},
+		{"basic", models.ChannelConfig{"username": "bob", "password": "pw"}, "Basic Ym9iOnB3"},
+		{"anonymous", models.ChannelConfig{}, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			nt, reqs := newServer(t, http.StatusOK, tt.config)
+			if err := nt.Send(event); err != nil {
+				t.Fatal(err)
+			}
+			if auth := (<-reqs).auth; auth != tt.want {
+				t.Errorf("Authorization = %q, want %q", auth, tt.want)
+			}
+		})
+	}
+}
+
+func TestSendFailsOnErrorStatus(t *testing.T) {
+	nt, _ := newServer(t, http.StatusForbidden, models.ChannelConfig{})
+
+	if err := nt.Send(event); err == nil || !strings.Contains(err.Error(), "403") {
+		t.Errorf("Send() error = %v, want status 403", err)
+	}
+}
+
+func TestNewChannelNotifierConfig(t *testing.T) {
+	if _, err := NewChannelNotifier(models.Channel{Type: models.ChannelNtfy, Config: models.ChannelConfig{}}); err == nil {
+		t.Error("channel without a topic was accepted")
+	}
+
+	nt, err := NewChannelNotifier(models.Channel{Type: models.ChannelNtfy, Config: models.ChannelConfig{"topic": "alerts"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if server := nt.(*Notifier).server; server != DefaultServer {
+		t.Errorf("server = %q, want %q by default", server, DefaultServer)
+	}
+}
@@ -0,0 +1,253 @@
+package pagerduty
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+	"github.com/ilyxenc/rattle/internal/models"
+	"github.com/ilyxenc/rattle/internal/notify"
+)
+
+// DefaultURL is the PagerDuty Events API v2 endpoint
+const DefaultURL = "https://events.pagerduty.com/v2/enqueue"
+
+// Event actions
+const (
+	ActionTrigger = "trigger"
+	ActionResolve = "resolve"
+)
+
+// channelConfig is the config of a PagerDuty notification channel
+type channelConfig struct {
+	RoutingKey  string `json:"routing_key"`  // Integration key of the service
+	URL         string `json:"url"`          // Optional, Events API endpoint (e.g. a local mock)
+	MinSeverity string `json:"min_severity"` // Lowest event type that pages: critical (default), error, warning, info
+}
+
+// Event is a PagerDuty Events API v2 event
+type Event struct {
+	RoutingKey  string   `json:"routing_key"`
+	EventAction string   `json:"event_action"`
+	DedupKey    string   `json:"dedup_key"`
+	Payload     *Payload `json:"payload,omitempty"` // Only for trigger events
+}
+
+// Payload describes the alert of a trigger event
+type Payload struct {
+	Summary       string         `json:"summary"`
+	Source        string         `json:"source"`
+	Severity      string         `json:"severity"` // critical, error, warning or info
+	Timestamp     string         `json:"timestamp,omitempty"`
+	Component     string         `json:"component,omitempty"`
+	Group         string         `json:"group,omitempty"`
+	Class         string         `json:"class,omitempty"`
+	CustomDetails map[string]any `json:"custom_details,omitempty"`
+}
+
+// Notifier pages through PagerDuty Events API v2: severe notifications trigger incidents,
+// and notifications that clear a condition (e.g. container started again) resolve them
+type Notifier struct {
+	name        string
+	routingKey  string
+	url         string
+	minSeverity int
+	client      *resty.Client
+}
+
+// NewChannelNotifier creates a notifier from a notification channel stored in DB
+func NewChannelNotifier(ch models.Channel) (notify.Notifier, error) {
+	var cfg channelConfig
+	if err := ch.Config.Decode(&cfg); err != nil {
+		return nil, err
+	}
+
+	if cfg.RoutingKey == "" {
+		return nil, errors.New("routing_key is required")
+	}
+	if cfg.URL == "" {
+		cfg.URL = DefaultURL
+	}
+
+	minSeverity := strings.ToLower(cfg.MinSeverity)
+	if minSeverity == "" {
+		minSeverity = models.EventTypeCritical
+	}
+	if _, ok := models.EventTypeSeverity[minSeverity]; !ok {
+		return nil, fmt.Errorf("unknown min_severity: %s", cfg.MinSeverity)
+	}
+
+	client := notify.NewHTTPClient(notify.DefaultRetry)
+
+	return &Notifier{
+		name:        ch.Name,
+		routingKey:  cfg.RoutingKey,
+		url:         cfg.URL,
+		minSeverity: models.EventTypeSeverity[minSeverity],
+		client:      client,
+	}, nil
+}
+
+// Name identifies the channel in logs
+func (pn *Notifier) Name() string {
+	return "pagerduty:" + pn.name
+}
+
+// Send triggers or resolves an incident for the notification. Notifications that don't page are skipped
+func (pn *Notifier) Send(n notify.Notification) error {
+	event, ok := pn.event(n)
+	if !ok {
+		return nil
+	}
+
+	resp, err := pn.client.R().
+		SetHeader("Content-Type", "application/json").
+		SetBody(event).
+		Post(pn.url)
+
+	if err != nil {
+		return fmt.Errorf("failed to send PagerDuty event: %w", err)
+	}
+
+	if resp.StatusCode() != http.StatusAccepted && !resp.IsSuccess() {
+		return fmt.Errorf("pagerduty responded with status %d: %s", resp.StatusCode(), resp.String())
+	}
+
+	return nil
+}
+
+// event builds the PagerDuty event for the notification. Returns false if it shouldn't page
+func (pn *Notifier) event(n notify.Notification) (Event, bool) {
+	// Resolve the incident of the condition that cleared, if it was severe enough to be triggered
+	if trigger, ok := resolves[n.Type]; ok {
+		if !pn.pages(notify.Notification{Type: trigger, EventType: n.EventType}) {
+			return Event{}, false
+		}
+
+		return Event{
+			RoutingKey:  pn.routingKey,
+			EventAction: ActionResolve,
+			DedupKey:    DedupKey(trigger, n),
+		}, true
+	}
+
+	if !pn.pages(n) {
+		return Event{}, false
+	}
+
+	return Event{
+		RoutingKey:  pn.routingKey,
+		EventAction: ActionTrigger,
+		DedupKey:    DedupKey(n.Type, n),
+		Payload:     newPayload(n, notify.Severity(n)),
+	}, true
+}
+
+// alwaysPages are notifications that page regardless of the min severity
+var alwaysPages = map[notify.NotificationType]bool{
+	notify.NotificationContainerStopWithError: true,
+}
+
+// pages reports whether the notification pages: it's always paged or its event type is severe enough
+func (pn *Notifier) pages(n notify.Notification) bool {
+	return alwaysPages[n.Type] || models.EventTypeSeverity[notify.Severity(n)] >= pn.minSeverity
+}
+
+// resolves maps notifications that clear a condition to the notification that triggered it
+var resolves = map[notify.NotificationType]notify.NotificationType{
+	notify.NotificationContainerStart:   notify.NotificationContainerStopWithError,
+	notify.NotificationAbsenceRecovered: notify.NotificationAbsence,
+	notify.NotificationContainerResumed: notify.NotificationContainerIdle,
+	notify.NotificationEventsRestored:   notify.NotificationEventsLost,
+}
+
+// DedupKey identifies the incident of a notification, so repeats update one incident and resolves close it.
+// Log events are keyed by container and fingerprint, conditions by container and notification type.
+// Containers are keyed by name: a recreated container gets a new ID, but must resolve the incident of the old one
+func DedupKey(t notify.NotificationType, n notify.Notification) string {
+	parts := []string{"rattle", string(t)}
+	if notify.HasContainer(n) {
+		parts = append(parts, n.Container.Name)
+	}
+
+	switch t {
+	case notify.NotificationLogEvent, notify.NotificationLogRepeated:
+		if n.Fingerprint != "" {
+			parts = append(parts, n.Fingerprint)
+		} else {
+			parts = append(parts, shortHash(n.Details))
+		}
+		parts[1] = string(notify.NotificationLogEvent) // Repeats belong to the incident of the first occurrence
+	case notify.NotificationThreshold, notify.NotificationAbsence:
+		parts = append(parts, shortHash(n.Rule))
+	}
+
+	return strings.Join(parts, ":")
+}
+
+// newPayload returns the alert details of a trigger event
+func newPayload(n notify.Notification, severity string) *Payload {
+	source := "rattle"
+	if notify.HasContainer(n) {
+		source = n.Container.Name
+	}
+
+	details := map[string]any{
+		"type": string(n.Type),
+	}
+	if n.Details != "" {
+		details["line"] = n.Details
+	}
+	if n.Rule != "" {
+		details["rule"] = n.Rule
+	}
+	if n.Stream != "" {
+		details["stream"] = n.Stream
+	}
+	if n.Count > 0 {
+		details["count"] = n.Count
+	}
+	for _, f := range n.Fields {
+		details[f.Key] = f.Value
+	}
+	if notify.HasContainer(n) {
+		details["container_id"] = n.Container.ID
+		details["image"] = n.Container.Image
+	}
+
+	return &Payload{
+		Summary:       notify.TruncateBytes(notify.Headline(n), 1024),
+		Source:        source,
+		Severity:      pagerDutySeverity(severity),
+		Timestamp:     notify.EventTime(n).Format(time.RFC3339Nano),
+		Component:     n.Container.Image,
+		Group:         n.Container.Labels["com.docker.compose.project"],
+		Class:         string(n.Type),
+		CustomDetails: details,
+	}
+}
+
+// pagerDutySeverity maps event types to PagerDuty severities
+func pagerDutySeverity(eventType string) string {
+	switch eventType {
+	case models.EventTypeCritical:
+		return "critical"
+	case models.EventTypeError:
+		return "error"
+	case models.EventTypeWarning:
+		return "warning"
+	default:
+		return "info"
+	}
+}
+
+// shortHash returns a short stable hash of the text for dedup keys
+func shortHash(text string) string {
+	sum := sha1.Sum([]byte(text))
+	return hex.EncodeToString(sum[:8])
+}
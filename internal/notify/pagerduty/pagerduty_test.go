@@ -0,0 +1,218 @@
+package pagerduty
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/ilyxenc/rattle/internal/docker"
+	"github.com/ilyxenc/rattle/internal/models"
+	"github.com/ilyxenc/rattle/internal/notify"
+)
+
+// eventsAPI is an Events API v2 stand-in recording received events
+type eventsAPI struct {
+	mu     sync.Mutex
+	events []Event
+	status int // Response status, 202 if zero
+}
+
+func (api *eventsAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var e Event
+	if err := json.NewDecoder(r.Body).Decode(&e); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	api.mu.Lock()
+	defer api.mu.Unlock()
+
+	status := api.status
+	if status == 0 {
+		status = http.StatusAccepted
+		api.events = append(api.events, e)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_, _ = w.Write([]byte(`{"status": "success", "dedup_key": "` + e.DedupKey + `"}`))
+}
+
+// newTestNotifier creates a notifier sending to a local Events API stand-in
+func newTestNotifier(t *testing.T, config models.ChannelConfig) (notify.Notifier, *eventsAPI) {
+	t.Helper()
+
+	api := &eventsAPI{}
+	srv := httptest.NewServer(api)
+	t.Cleanup(srv.Close)
+
+	config["routing_key"] = "key"
+	config["url"] = srv.URL
+	n, err := NewChannelNotifier(models.Channel{Name: "oncall", Type: models.ChannelPagerDuty, Config: config})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return n, api
+}
+
+var app = docker.ContainerInfo{ID: "c1", Name: "api", Image: "api:1"}
+
+func TestLifecyclePages(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   models.ChannelConfig
+		n        notify.Notification
+		action   string // Expected event action, empty if nothing is sent
+		dedup    string
+		severity string
+	}{
+		{
+			name:     "stop with error always triggers",
+			config:   models.ChannelConfig{},
+			n:        notify.Notification{Type: notify.NotificationContainerStopWithError, Container: app},
+			action:   ActionTrigger,
+			dedup:    "rattle:container_stop_with_error:api",
+			severity: "error",
+		},
+		{
+			name:   "start resolves it",
+			config: models.ChannelConfig{},
+			n:      notify.Notification{Type: notify.NotificationContainerStart, Container: app},
+			action: ActionResolve,
+			dedup:  "rattle:container_stop_with_error:api",
+		},
+		{
+			name:   "error log events don't page by default",
+			config: models.ChannelConfig{},
+			n:      notify.Notification{Type: notify.NotificationLogEvent, EventType: models.EventTypeError, Container: app, Fingerprint: "f"},
+		},
+		{
+			name:   "lost events don't page by default",
+			config: models.ChannelConfig{},
+			n:      notify.Notification{Type: notify.NotificationEventsLost, Details: "EOF"},
+		},
+		{
+			name:   "no resolve for what wasn't triggered",
+			config: models.ChannelConfig{},
+			n:      notify.Notification{Type: notify.NotificationEventsRestored},
+		},
+		{
+			name:     "critical log event",
+			config:   models.ChannelConfig{},
+			n:        notify.Notification{Type: notify.NotificationLogEvent, EventType: models.EventTypeCritical, Container: app, Fingerprint: "f"},
+			action:   ActionTrigger,
+			dedup:    "rattle:log_event:api:f",
+			severity: "critical",
+		},
+		{
+			name:     "lost events trigger at error",
+			config:   models.ChannelConfig{"min_severity": "error"},
+			n:        notify.Notification{Type: notify.NotificationEventsLost, Details: "EOF"},
+			action:   ActionTrigger,
+			dedup:    "rattle:events_lost",
+			severity: "error",
+		},
+		{
+			name:   "restored events resolve at error",
+			config: models.ChannelConfig{"min_severity": "error"},
+			n:      notify.Notification{Type: notify.NotificationEventsRestored},
+			action: ActionResolve,
+			dedup:  "rattle:events_lost",
+		},
+		{
+			name:     "idle triggers at warning",
+			config:   models.ChannelConfig{"min_severity": "WARNING"},
+			n:        notify.Notification{Type: notify.NotificationContainerIdle, Container: app},
+			action:   ActionTrigger,
+			dedup:    "rattle:container_idle:api",
+			severity: "warning",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			n, api := newTestNotifier(t, tt.config)
+			if err := n.Send(tt.n); err != nil {
+				t.Fatalf("Send: %v", err)
+			}
+
+			if tt.action == "" {
+				if len(api.events) != 0 {
+					t.Fatalf("sent %+v, want nothing", api.events)
+				}
+				return
+			}
+			if len(api.events) != 1 {
+				t.Fatalf("sent %d events, want 1", len(api.events))
+			}
+
+			e := api.events[0]
+			if e.RoutingKey != "key" || e.EventAction != tt.action || e.DedupKey != tt.dedup {
+				t.Errorf("event = %+v, want %s with dedup key %s", e, tt.action, tt.dedup)
+			}
+			if tt.action == ActionResolve && e.Payload != nil {
+				t.Errorf("resolve event has a payload: %+v", e.Payload)
+			}
+			if tt.action == ActionTrigger && (e.Payload == nil || e.Payload.Severity != tt.severity) {
+				t.Errorf("payload = %+v, want severity %s", e.Payload, tt.severity)
+			}
+		})
+	}
+}
+
+func TestRecreatedContainerResolves(t *testing.T) {
+	n, api := newTestNotifier(t, models.ChannelConfig{})
+
+	// The container crashed, and compose recreated it with a new ID
+	recreated := app
+	recreated.ID = "c2"
+	for _, nt := range []notify.Notification{
+		{Type: notify.NotificationContainerStopWithError, Container: app},
+		{Type: notify.NotificationContainerStart, Container: recreated},
+	} {
+		if err := n.Send(nt); err != nil {
+			t.Fatalf("Send: %v", err)
+		}
+	}
+
+	if len(api.events) != 2 {
+		t.Fatalf("sent %d events, want 2", len(api.events))
+	}
+	trigger, resolve := api.events[0], api.events[1]
+	if trigger.EventAction != ActionTrigger || resolve.EventAction != ActionResolve {
+		t.Fatalf("actions = %s, %s, want trigger then resolve", trigger.EventAction, resolve.EventAction)
+	}
+	if trigger.DedupKey != resolve.DedupKey {
+		t.Errorf("resolve dedup key %s doesn't match trigger %s", resolve.DedupKey, trigger.DedupKey)
+	}
+}
+
+func TestSendRejected(t *testing.T) {
+	n, api := newTestNotifier(t, models.ChannelConfig{})
+	api.status = http.StatusBadRequest
+
+	err := n.Send(notify.Notification{Type: notify.NotificationContainerStopWithError, Container: app})
+	if err == nil {
+		t.Fatal("Send succeeded while the Events API rejected the event")
+	}
+}
+
+func TestNewChannelNotifierConfig(t *testing.T) {
+	tests := []struct {
+		name   string
+		config models.ChannelConfig
+		ok     bool
+	}{
+		{"routing key", models.ChannelConfig{"routing_key": "key"}, true},
+		{"no routing key", models.ChannelConfig{}, false},
+		{"unknown severity", models.ChannelConfig{"routing_key": "key", "min_severity": "fatal"}, false},
+	}
+
+	for _, tt := range tests {
+		_, err := NewChannelNotifier(models.Channel{Name: "oncall", Config: tt.config})
+		if (err == nil) != tt.ok {
+			t.Errorf("%s: err = %v, want ok %v", tt.name, err, tt.ok)
+		}
+	}
+}
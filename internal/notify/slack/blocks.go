@@ -0,0 +1,121 @@
+package slack
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ilyxenc/rattle/internal/notify"
+)
+
+// Block Kit limits
+const (
+	maxHeaderLen  = 150  // Characters in a header block
+	maxSectionLen = 3000 // Characters in a section text
+	maxFields     = 10   // Fields in a section
+)
+
+// Message is a Slack message with a plain text fallback and Block Kit blocks
+type Message struct {
+	Text   string  `json:"text"`
+	Blocks []Block `json:"blocks"`
+}
+
+// Block is a Block Kit layout block. Only fields used by Rattle are defined
+type Block struct {
+	Type     string `json:"type"`               // header, section, context or divider
+	Text     *Text  `json:"text,omitempty"`     // For header and section blocks
+	Fields   []Text `json:"fields,omitempty"`   // For section blocks
+	Elements []Text `json:"elements,omitempty"` // For context blocks
+}
+
+// Text is a Block Kit text object
+type Text struct {
+	Type  string `json:"type"` // plain_text or mrkdwn
+	Text  string `json:"text"`
+	Emoji bool   `json:"emoji,omitempty"`
+}
+
+// RenderMessage formats a notification as a Block Kit message
+func RenderMessage(n notify.Notification) Message {
+	headline := notify.Headline(n)
+
+	blocks := []Block{{
+		Type: "header",
+		Text: &Text{Type: "plain_text", Text: notify.Truncate(headline, maxHeaderLen), Emoji: true},
+	}}
+
+	if n.Rule != "" {
+		blocks = append(blocks, section(fmt.Sprintf("🔎 Pattern: `%s`", escape(n.Rule))))
+	}
+
+	if n.Details != "" {
+		blocks = append(blocks, section(codeBlock(n.Details)))
+	}
+
+	if fields := detailFields(n); len(fields) > 0 {
+		blocks = append(blocks, Block{Type: "section", Fields: fields})
+	}
+
+	if n.Type == notify.NotificationContainersSummary && len(n.Containers) > 0 {
+		lines := make([]string, 0, len(n.Containers))
+		for _, ci := range n.Containers {
+			lines = append(lines, fmt.Sprintf("• `%s`: %s", ci.ShortID, escape(ci.Name)))
+		}
+		blocks = append(blocks, section(notify.Truncate(strings.Join(lines, "\n"), maxSectionLen)))
+	}
+
+	if notify.HasContainer(n) {
+		blocks = append(blocks, Block{Type: "divider"}, Block{
+			Type:     "context",
+			Elements: []Text{{Type: "mrkdwn", Text: meta(n)}},
+		})
+	}
+
+	return Message{
+		Text:   headline, // Shown in push notifications and clients without Block Kit support
+		Blocks: blocks,
+	}
+}
+
+// detailFields returns the stream and fields of a structured line as section fields
+func detailFields(n notify.Notification) []Text {
+	fields := make([]Text, 0, len(n.Fields)+1)
+	if n.Stream != "" {
+		fields = append(fields, Text{Type: "mrkdwn", Text: fmt.Sprintf("*Stream:*\n`%s`", n.Stream)})
+	}
+
+	for _, f := range n.Fields {
+		if len(fields) == maxFields {
+			break
+		}
+		fields = append(fields, Text{Type: "mrkdwn", Text: fmt.Sprintf("*%s:*\n`%s`", escape(f.Key), escape(f.Value))})
+	}
+	return fields
+}
+
+// meta returns container metadata with the event time
+func meta(n notify.Notification) string {
+	parts := make([]string, 0, 4)
+	for _, f := range notify.MetaFields(n.Container) {
+		parts = append(parts, fmt.Sprintf("%s: `%s`", f.Key, escape(f.Value)))
+	}
+	parts = append(parts, notify.FormatTime(notify.EventTime(n)))
+	return "📦 " + strings.Join(parts, " · ")
+}
+
+// section returns a mrkdwn section block
+func section(text string) Block {
+	return Block{Type: "section", Text: &Text{Type: "mrkdwn", Text: text}}
+}
+
+// codeBlock wraps the text in a code block that fits into a section
+func codeBlock(text string) string {
+	text = strings.ReplaceAll(escape(text), "```", "'''")
+	return "```" + notify.Truncate(text, maxSectionLen-6) + "```"
+}
+
+// escape escapes characters that have special meaning in Slack mrkdwn
+func escape(text string) string {
+	replacer := strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+	return replacer.Replace(strings.ToValidUTF8(text, ""))
+}
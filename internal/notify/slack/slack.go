@@ -0,0 +1,85 @@
+package slack
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+	"github.com/ilyxenc/rattle/internal/models"
+	"github.com/ilyxenc/rattle/internal/notify"
+)
+
+// channelConfig is the config of a Slack notification channel
+type channelConfig struct {
+	WebhookURL string `json:"webhook_url"` // Incoming webhook URL (https://hooks.slack.com/services/...)
+}
+
+// Notifier sends notifications to a Slack incoming webhook as Block Kit messages
+type Notifier struct {
+	name       string
+	webhookURL string
+	client     *resty.Client
+}
+
+// NewNotifier creates a notifier posting to the incoming webhook
+func NewNotifier(name, webhookURL string) *Notifier {
+	client := notify.NewHTTPClient(notify.DefaultRetry).
+		SetRetryAfter(retryAfter)
+
+	return &Notifier{
+		name:       name,
+		webhookURL: webhookURL,
+		client:     client,
+	}
+}
+
+// NewChannelNotifier creates a notifier from a notification channel stored in DB
+func NewChannelNotifier(ch models.Channel) (notify.Notifier, error) {
+	var cfg channelConfig
+	if err := ch.Config.Decode(&cfg); err != nil {
+		return nil, err
+	}
+	if cfg.WebhookURL == "" {
+		return nil, errors.New("webhook_url is required")
+	}
+
+	return NewNotifier(ch.Name, cfg.WebhookURL), nil
+}
+
+// Name identifies the channel in logs
+func (sn *Notifier) Name() string {
+	return "slack:" + sn.name
+}
+
+// Send renders the notification with Block Kit and posts it to the webhook
+func (sn *Notifier) Send(n notify.Notification) error {
+	resp, err := sn.client.R().
+		SetHeader("Content-Type", "application/json").
+		SetBody(RenderMessage(n)).
+		Post(sn.webhookURL)
+
+	if err != nil {
+		return fmt.Errorf("failed to send Slack message: %w", err)
+	}
+
+	if resp.StatusCode() != http.StatusOK {
+		return fmt.Errorf("slack responded with status %d: %s", resp.StatusCode(), resp.String())
+	}
+
+	return nil
+}
+
+// retryAfter waits as long as Slack asks in the Retry-After header of a rate-limited response
+func retryAfter(_ *resty.Client, resp *resty.Response) (time.Duration, error) {
+	if resp == nil || resp.StatusCode() != http.StatusTooManyRequests {
+		return 0, nil // Default backoff
+	}
+
+	var seconds int
+	if _, err := fmt.Sscan(resp.Header().Get("Retry-After"), &seconds); err != nil || seconds <= 0 {
+		return 0, nil
+	}
+	return time.Duration(seconds) * time.Second, nil
+}
@@ -0,0 +1,80 @@
+package slack
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/ilyxenc/rattle/internal/docker"
+	"github.com/ilyxenc/rattle/internal/models"
+	"github.com/ilyxenc/rattle/internal/notify"
+)
+
+var event = notify.Notification{
+	Type:      notify.NotificationLogEvent,
+	EventType: models.EventTypeError,
+	Details:   strings.Repeat("x", 5000),
+	Container: docker.ContainerInfo{ID: "c1", ShortID: "c1", Name: "api", Image: "api:1"},
+}
+
+func TestSendPostsBlocks(t *testing.T) {
+	var got Message
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Content-Type") != "application/json" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		_ = json.NewDecoder(r.Body).Decode(&got)
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer srv.Close()
+
+	if err := NewNotifier("ops", srv.URL).Send(event); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+
+	if len(got.Blocks) == 0 || got.Blocks[0].Type != "header" || !strings.Contains(got.Blocks[0].Text.Text, "api") {
+		t.Fatalf("message = %+v, want a header block naming the container", got)
+	}
+	for _, b := range got.Blocks {
+		if b.Text != nil && len([]rune(b.Text.Text)) > maxSectionLen {
+			t.Errorf("block text of %d characters exceeds the Slack limit", len([]rune(b.Text.Text)))
+		}
+	}
+}
+
+func TestSendWaitsRetryAfter(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer srv.Close()
+
+	if err := NewNotifier("ops", srv.URL).Send(event); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	if calls.Load() != 2 {
+		t.Errorf("webhook called %d times, want a retry after the rate limit", calls.Load())
+	}
+}
+
+func TestSendRejected(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte("no_service"))
+	}))
+	defer srv.Close()
+
+	err := NewNotifier("ops", srv.URL).Send(event)
+	if err == nil || !strings.Contains(err.Error(), "no_service") {
+		t.Fatalf("Send = %v, want the error from Slack", err)
+	}
+}
@@ -0,0 +1,66 @@
+package notify
+
+import (
+	"time"
+
+	"github.com/ilyxenc/rattle/internal/docker"
+)
+
+// NotificationType defines the type of event being reported
+type NotificationType string
+
+const (
+	NotificationContainerStart         NotificationType = "container_start"           // Sent when a container starts
+	NotificationContainerStop          NotificationType = "container_stop"            // Sent when a container stops normally
+	NotificationLogEvent               NotificationType = "log_event"                 // Sent when an event is detected in logs
+	NotificationContainerStopWithError NotificationType = "container_stop_with_error" // Sent when a container stops unexpectedly with error
+	NotificationShutDownRattle         NotificationType = "shut_down_rattle"          // Sent when Rattle is shutting down
+	NotificationStartedRattle          NotificationType = "started_rattle"            // Sent when Rattle starts
+	NotificationContainersSummary      NotificationType = "containers_summary"        // Sent when Rattle starts and find containers
+	NotificationLogRepeated            NotificationType = "log_repeated"              // Sent when a log event was repeated within the dedup window
+	NotificationThreshold              NotificationType = "threshold"                 // Sent when a threshold rule matched enough lines within its window
+	NotificationAbsence                NotificationType = "absence"                   // Sent when an expected log line didn't appear in time
+	NotificationAbsenceRecovered       NotificationType = "absence_recovered"         // Sent when an expected log line appeared again after an absence alert
+	NotificationContainerIdle          NotificationType = "container_idle"            // Sent when a container produced no log lines for longer than its idle threshold
+	NotificationContainerResumed       NotificationType = "container_resumed"         // Sent when an idle container produced log lines again
+	NotificationEventsLost             NotificationType = "events_lost"               // Sent when Docker events stream fails
+	NotificationEventsRestored         NotificationType = "events_restored"           // Sent when Docker events stream is back and scanners are reconciled
+)
+
+// notificationTypes lists all notification types, used to validate settings keyed by type
+var notificationTypes = map[NotificationType]struct{}{
+	NotificationContainerStart: {}, NotificationContainerStop: {}, NotificationLogEvent: {}, NotificationContainerStopWithError: {},
+	NotificationShutDownRattle: {}, NotificationStartedRattle: {}, NotificationContainersSummary: {}, NotificationLogRepeated: {},
+	NotificationThreshold: {}, NotificationAbsence: {}, NotificationAbsenceRecovered: {}, NotificationContainerIdle: {},
+	NotificationContainerResumed: {}, NotificationEventsLost: {}, NotificationEventsRestored: {},
+}
+
+// Valid reports whether the notification type is known
+func (t NotificationType) Valid() bool {
+	_, ok := notificationTypes[t]
+	return ok
+}
+
+// Field is a key-value pair shown in a notification
+type Field struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+// Notification represents an event to be delivered to notification channels
+type Notification struct {
+	Type        NotificationType       `json:"type"`                  // The type of event
+	EventType   string                 `json:"event_type,omitempty"`  // error, info, success, warning, critical
+	Title       string                 `json:"title,omitempty"`       // Notification template title
+	Details     string                 `json:"details,omitempty"`     // Optional details (e.g., error message or log content)
+	Stream      string                 `json:"stream,omitempty"`      // Log stream of the event: stdout / stderr
+	Fields      []Field                `json:"fields,omitempty"`      // Selected fields of a structured log line
+	Fingerprint string                 `json:"fingerprint,omitempty"` // Identifies similar log events of the container (numbers, IDs, timestamps ignored)
+	Rule        string                 `json:"rule,omitempty"`        // Pattern of the rule that triggered the notification
+	Count       int                    `json:"count,omitempty"`       // Number of repeats or matches for NotificationLogRepeated / NotificationThreshold
+	Window      time.Duration          `json:"window,omitempty"`      // Period of repeats or matches for NotificationLogRepeated / NotificationThreshold, period of silence for absence and idle events
+	Time        time.Time              `json:"time"`                  // When the event happened, defaults to the time it was queued
+	Container   docker.ContainerInfo   `json:"container"`             // Metadata about the container related to the event
+	Containers  []docker.ContainerInfo `json:"containers,omitempty"`  // For summary events like containers list
+	Delivery    uint                   `json:"-"`                     // ID of the outbox delivery sending the notification, 0 when sent directly
+}
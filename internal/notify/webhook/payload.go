@@ -0,0 +1,95 @@
+package webhook
+
+import (
+	"encoding/json"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/ilyxenc/rattle/internal/notify"
+)
+
+// PayloadVersion is increased on breaking changes of the payload schema
+const PayloadVersion = 1
+
+// Payload is the stable JSON schema of webhook requests.
+// It's also the data of custom body templates
+type Payload struct {
+	Version     int               `json:"version"`
+	Type        string            `json:"type"`       // Notification type, e.g. log_event, container_stop
+	EventType   string            `json:"event_type"` // error, info, success, warning, critical
+	Title       string            `json:"title"`      // One-line summary
+	Container   *Container        `json:"container,omitempty"`
+	Line        string            `json:"line,omitempty"`   // Log line or other details
+	Stream      string            `json:"stream,omitempty"` // stdout / stderr
+	Fields      map[string]string `json:"fields,omitempty"` // Selected fields of a structured log line
+	Fingerprint string            `json:"fingerprint,omitempty"`
+	Rule        string            `json:"rule,omitempty"`           // Pattern of the rule that triggered the notification
+	Count       int               `json:"count,omitempty"`          // Repeats or matches
+	Window      int               `json:"window_seconds,omitempty"` // Period of repeats, matches or silence
+	Containers  []Container       `json:"containers,omitempty"`     // Running containers for the startup summary
+	Timestamp   time.Time         `json:"timestamp"`
+}
+
+// Container is container metadata in the payload
+type Container struct {
+	ID     string            `json:"id"`
+	Name   string            `json:"name"`
+	Image  string            `json:"image"`
+	Labels map[string]string `json:"labels,omitempty"`
+}
+
+// NewPayload converts a notification to the payload schema
+func NewPayload(n notify.Notification) Payload {
+	p := Payload{
+		Version:     PayloadVersion,
+		Type:        string(n.Type),
+		EventType:   notify.Severity(n),
+		Title:       notify.Headline(n),
+		Line:        n.Details,
+		Stream:      n.Stream,
+		Fingerprint: n.Fingerprint,
+		Rule:        n.Rule,
+		Count:       n.Count,
+		Window:      int(n.Window.Seconds()),
+		Timestamp:   notify.EventTime(n),
+	}
+
+	if notify.HasContainer(n) {
+		p.Container = &Container{
+			ID:     n.Container.ID,
+			Name:   n.Container.Name,
+			Image:  n.Container.Image,
+			Labels: n.Container.Labels,
+		}
+	}
+
+	if len(n.Fields) > 0 {
+		p.Fields = make(map[string]string, len(n.Fields))
+		for _, f := range n.Fields {
+			p.Fields[f.Key] = f.Value
+		}
+	}
+
+	for _, ci := range n.Containers {
+		p.Containers = append(p.Containers, Container{ID: ci.ID, Name: ci.Name, Image: ci.Image, Labels: ci.Labels})
+	}
+
+	return p
+}
+
+// templateFuncs are available in custom body templates
+var templateFuncs = template.FuncMap{
+	// json encodes a value, e.g. {"text": {{ json .Line }}}
+	"json": func(v any) (string, error) {
+		b, err := json.Marshal(v)
+		return string(b), err
+	},
+	"upper": strings.ToUpper,
+	"lower": strings.ToLower,
+}
+
+// parseTemplate compiles a custom body template
+func parseTemplate(text string) (*template.Template, error) {
+	return template.New("webhook").Funcs(templateFuncs).Option("missingkey=zero").Parse(text)
+}
@@ -0,0 +1,148 @@
+package webhook
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+	"github.com/ilyxenc/rattle/internal/models"
+	"github.com/ilyxenc/rattle/internal/notify"
+)
+
+// SignatureHeader carries "sha256=" and the hex HMAC-SHA256 of the request body signed with the channel secret
+const SignatureHeader = "X-Rattle-Signature"
+
+const defaultRetries = 3
+
+// channelConfig is the config of a webhook notification channel
+type channelConfig struct {
+	URL         string            `json:"url"`
+	Secret      string            `json:"secret"`       // Optional, requests are signed if set
+	Template    string            `json:"template"`     // Optional text/template of the body, the JSON payload is sent if empty
+	ContentType string            `json:"content_type"` // Content type of the body, application/json by default
+	Headers     map[string]string `json:"headers"`      // Optional extra headers (e.g. Authorization)
+	Retries     *int              `json:"retries"`      // Retry attempts on network errors, 429 and 5xx, 3 by default
+}
+
+// Notifier posts notifications to an HTTP endpoint
+type Notifier struct {
+	name        string
+	url         string
+	secret      []byte
+	tmpl        *template.Template // nil to send the JSON payload
+	contentType string
+	headers     map[string]string
+	client      *resty.Client
+}
+
+// NewChannelNotifier creates a notifier from a notification channel stored in DB
+func NewChannelNotifier(ch models.Channel) (notify.Notifier, error) {
+	var cfg channelConfig
+	if err := ch.Config.Decode(&cfg); err != nil {
+		return nil, err
+	}
+
+	if cfg.URL == "" {
+		return nil, errors.New("url is required")
+	}
+	if !strings.HasPrefix(cfg.URL, "http://") && !strings.HasPrefix(cfg.URL, "https://") {
+		return nil, errors.New("url must start with http:// or https://")
+	}
+
+	var tmpl *template.Template
+	if strings.TrimSpace(cfg.Template) != "" {
+		t, err := parseTemplate(cfg.Template)
+		if err != nil {
+			return nil, fmt.Errorf("invalid template: %w", err)
+		}
+		tmpl = t
+	}
+
+	if cfg.ContentType == "" {
+		cfg.ContentType = "application/json"
+	}
+
+	retries := defaultRetries
+	if cfg.Retries != nil {
+		retries = max(*cfg.Retries, 0)
+	}
+
+	client := notify.NewHTTPClient(notify.RetryPolicy{
+		Count:      retries,
+		Wait:       1 * time.Second,
+		MaxWait:    30 * time.Second,
+		RateLimits: true,
+	})
+
+	return &Notifier{
+		name:        ch.Name,
+		url:         cfg.URL,
+		secret:      []byte(cfg.Secret),
+		tmpl:        tmpl,
+		contentType: cfg.ContentType,
+		headers:     cfg.Headers,
+		client:      client,
+	}, nil
+}
+
+// Name identifies the channel in logs
+func (wn *Notifier) Name() string {
+	return "webhook:" + wn.name
+}
+
+// Send posts the notification payload, or the rendered template, to the endpoint
+func (wn *Notifier) Send(n notify.Notification) error {
+	body, err := wn.render(NewPayload(n))
+	if err != nil {
+		return err
+	}
+
+	req := wn.client.R().
+		SetHeaders(wn.headers).
+		SetHeader("Content-Type", wn.contentType).
+		SetHeader("User-Agent", "Rattle-Webhook").
+		SetBody(body)
+
+	if len(wn.secret) > 0 {
+		req.SetHeader(SignatureHeader, Sign(wn.secret, body))
+	}
+
+	resp, err := req.Post(wn.url)
+	if err != nil {
+		return fmt.Errorf("failed to send webhook: %w", err)
+	}
+
+	if !resp.IsSuccess() {
+		return fmt.Errorf("webhook responded with status %d: %s", resp.StatusCode(), resp.String())
+	}
+
+	return nil
+}
+
+// render returns the request body
+func (wn *Notifier) render(p Payload) ([]byte, error) {
+	if wn.tmpl == nil {
+		return json.Marshal(p)
+	}
+
+	var b bytes.Buffer
+	if err := wn.tmpl.Execute(&b, p); err != nil {
+		return nil, fmt.Errorf("failed to render webhook template: %w", err)
+	}
+	return b.Bytes(), nil
+}
+
+// Sign returns the signature header value of the body: "sha256=" and the hex HMAC-SHA256
+func Sign(secret, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
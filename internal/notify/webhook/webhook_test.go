@@ -0,0 +1,160 @@
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ilyxenc/rattle/internal/docker"
+	"github.com/ilyxenc/rattle/internal/models"
+	"github.com/ilyxenc/rattle/internal/notify"
+)
+
+var event = notify.Notification{
+	Type:        notify.NotificationLogEvent,
+	EventType:   models.EventTypeError,
+	Details:     `db "main" down`,
+	Stream:      models.StreamStderr,
+	Fingerprint: "f1",
+	Container:   docker.ContainerInfo{ID: "c1", ShortID: "c1", Name: "api", Image: "api:1"},
+	Time:        time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC),
+}
+
+// received is a request received by the endpoint stand-in
+type received struct {
+	header http.Header
+	body   []byte
+}
+
+// newEndpoint starts an endpoint stand-in and returns its URL and the requests it receives
+func newEndpoint(t *testing.T, status int) (string, <-chan received) {
+	t.Helper()
+
+	reqs := make(chan received, 10)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		reqs <- received{header: r.Header, body: body}
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(srv.Close)
+	return srv.URL, reqs
+}
+
+func newTestNotifier(t *testing.T, config models.ChannelConfig) notify.Notifier {
+	t.Helper()
+
+	n, err := NewChannelNotifier(models.Channel{Name: "hook", Type: models.ChannelWebhook, Config: config})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return n
+}
+
+func TestSendSignsBody(t *testing.T) {
+	url, reqs := newEndpoint(t, http.StatusOK)
+	n := newTestNotifier(t, models.ChannelConfig{"url": url, "secret": "s3cret", "headers": map[string]any{"Authorization": "Bearer t"}})
+
+	if err := n.Send(event); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	r := <-reqs
+
+	// The receiver verifies the signature over the exact bytes it got
+	mac := hmac.New(sha256.New, []byte("s3cret"))
+	mac.Write(r.body)
+	want := "sha256=" + hex.EncodeToString(mac.Sum(nil))
+	if got := r.header.Get(SignatureHeader); !hmac.Equal([]byte(got), []byte(want)) {
+		t.Errorf("%s = %q, want %q", SignatureHeader, got, want)
+	}
+
+	if got := r.header.Get("Authorization"); got != "Bearer t" {
+		t.Errorf("Authorization = %q, want the configured header", got)
+	}
+	if got := r.header.Get("Content-Type"); got != "application/json" {
+		t.Errorf("Content-Type = %q, want application/json", got)
+	}
+
+	var p Payload
+	if err := json.Unmarshal(r.body, &p); err != nil {
+		t.Fatalf("body isn't the JSON payload: %v", err)
+	}
+	if p.Version != PayloadVersion || p.Type != "log_event" || p.EventType != models.EventTypeError ||
+		p.Line != event.Details || p.Container == nil || p.Container.Name != "api" || !p.Timestamp.Equal(event.Time) {
+		t.Errorf("payload = %+v", p)
+	}
+}
+
+func TestSendUnsignedWithoutSecret(t *testing.T) {
+	url, reqs := newEndpoint(t, http.StatusNoContent)
+	n := newTestNotifier(t, models.ChannelConfig{"url": url})
+
+	if err := n.Send(event); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	if got := (<-reqs).header.Get(SignatureHeader); got != "" {
+		t.Errorf("%s = %q without a secret", SignatureHeader, got)
+	}
+}
+
+func TestSendRendersTemplate(t *testing.T) {
+	url, reqs := newEndpoint(t, http.StatusOK)
+	n := newTestNotifier(t, models.ChannelConfig{
+		"url":          url,
+		"secret":       "s3cret",
+		"content_type": "text/plain",
+		"template":     `{"text": {{ json .Title }}, "line": {{ json .Line }}, "level": "{{ upper .EventType }}", "missing": "{{ .Container.Labels.team }}"}`,
+	})
+
+	if err := n.Send(event); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	r := <-reqs
+
+	want := `{"text": "❌ Error in container: api", "line": "db \"main\" down", "level": "ERROR", "missing": ""}`
+	if string(r.body) != want {
+		t.Errorf("body = %s, want %s", r.body, want)
+	}
+	if got := r.header.Get("Content-Type"); got != "text/plain" {
+		t.Errorf("Content-Type = %q, want text/plain", got)
+	}
+	if got := r.header.Get(SignatureHeader); got != Sign([]byte("s3cret"), r.body) {
+		t.Errorf("rendered body isn't signed: %q", got)
+	}
+}
+
+func TestSendFailsOnErrorStatus(t *testing.T) {
+	url, _ := newEndpoint(t, http.StatusBadRequest)
+	n := newTestNotifier(t, models.ChannelConfig{"url": url})
+
+	if err := n.Send(event); err == nil {
+		t.Fatal("Send succeeded while the endpoint answered 400")
+	}
+}
+
+func TestNewChannelNotifierConfig(t *testing.T) {
+	tests := []struct {
+		name   string
+		config models.ChannelConfig
+		ok     bool
+	}{
+		{"url", models.ChannelConfig{"url": "https://example.com/hook"}, true},
+		{"template", models.ChannelConfig{"url": "https://example.com/hook", "template": `{{ json . }}`}, true},
+		{"no url", models.ChannelConfig{}, false},
+		{"not http", models.ChannelConfig{"url": "ftp://example.com"}, false},
+		{"unclosed action", models.ChannelConfig{"url": "https://example.com/hook", "template": `{"text": {{ .Title }`}, false},
+		{"unknown function", models.ChannelConfig{"url": "https://example.com/hook", "template": `{{ yaml .Title }}`}, false},
+	}
+
+	for _, tt := range tests {
+		_, err := NewChannelNotifier(models.Channel{Name: "hook", Config: tt.config})
+		if (err == nil) != tt.ok {
+			t.Errorf("%s: err = %v, want ok %v", tt.name, err, tt.ok)
+		}
+	}
+}
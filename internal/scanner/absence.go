@@ -0,0 +1,179 @@
+package scanner
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ilyxenc/rattle/internal/docker"
+	"github.com/ilyxenc/rattle/internal/loganalyzer"
+	"github.com/ilyxenc/rattle/internal/managers"
+	"github.com/ilyxenc/rattle/internal/notify"
+)
+
+// absenceCheckInterval is how often absence rules are checked against scanned containers
+const absenceCheckInterval = 10 * time.Second
+
+// absenceState tracks the expected line of a single absence rule in a single container
+type absenceState struct {
+	lastSeen time.Time // When the line matched last time, or when tracking started
+	alerted  bool      // Absence alert was sent and recovery is pending
+}
+
+// AbsenceMonitor alerts when lines expected by absence rules don't appear in scanned containers in time,
+// and sends a recovery notification when they appear again
+type AbsenceMonitor struct {
+	mu         sync.Mutex
+	containers map[string]docker.ContainerInfo // Scanned containers by ID
+	states     map[string]*absenceState        // Key = rule ID + container ID
+	notify     func(notify.Notification)
+}
+
+// NewAbsenceMonitor creates a monitor that sends notifications with `notify`
+func NewAbsenceMonitor(notify func(notify.Notification)) *AbsenceMonitor {
+	return &AbsenceMonitor{
+		containers: make(map[string]docker.ContainerInfo),
+		states:     make(map[string]*absenceState),
+		notify:     notify,
+	}
+}
+
+// Track starts watching the container. Silence is counted from now
+func (am *AbsenceMonitor) Track(ci docker.ContainerInfo) {
+	am.mu.Lock()
+	defer am.mu.Unlock()
+
+	am.containers[ci.ID] = ci
+
+	now := time.Now()
+	for _, r := range managers.Absences.All() {
+		key := absenceKey(r.ID, ci.ID)
+		if _, ok := am.states[key]; !ok && docker.MatchSelector(ci, r.Container) {
+			am.states[key] = &absenceState{lastSeen: now}
+		}
+	}
+}
+
+// Forget stops watching the container and drops its state
+func (am *AbsenceMonitor) Forget(id string) {
+	am.mu.Lock()
+	defer am.mu.Unlock()
+
+	delete(am.containers, id)
+	for key := range am.states {
+		if strings.HasSuffix(key, ":"+id) {
+			delete(am.states, key)
+		}
+	}
+}
+
+// Observe resets the silence of every absence rule matching the entry
+func (am *AbsenceMonitor) Observe(ci docker.ContainerInfo, e loganalyzer.LogEntry) {
+	seen := e.Time
+	if seen.IsZero() {
+		seen = time.Now()
+	}
+
+	var recovered []notify.Notification
+
+	am.mu.Lock()
+	for _, r := range managers.Absences.All() {
+		if !r.Applies(ci, e.Stream) || !r.Regex.MatchString(e.Text) {
+			continue
+		}
+
+		key := absenceKey(r.ID, ci.ID)
+		st, ok := am.states[key]
+		if !ok {
+			am.states[key] = &absenceState{lastSeen: seen}
+			continue
+		}
+
+		if st.alerted {
+			recovered = append(recovered, notify.Notification{
+				Type:      notify.NotificationAbsenceRecovered,
+				EventType: r.EventType,
+				Details:   e.Text,
+				Time:      e.Time,
+				Container: ci,
+				Rule:      r.Pattern,
+				Window:    seen.Sub(st.lastSeen),
+			})
+			st.alerted = false
+		}
+		if seen.After(st.lastSeen) {
+			st.lastSeen = seen
+		}
+	}
+	am.mu.Unlock()
+
+	// Send outside of the lock so scanners are never blocked by slow delivery
+	for _, n := range recovered {
+		am.notify(n)
+	}
+}
+
+// Check sends an alert for every rule whose line hasn't matched in a tracked container for longer than its max silence
+func (am *AbsenceMonitor) Check(now time.Time) {
+	var alerts []notify.Notification
+
+	am.mu.Lock()
+	for _, r := range managers.Absences.All() {
+		for id, ci := range am.containers {
+			if !docker.MatchSelector(ci, r.Container) {
+				continue
+			}
+
+			// Rules added after the container was tracked count silence from the first check
+			key := absenceKey(r.ID, id)
+			st, ok := am.states[key]
+			if !ok {
+				am.states[key] = &absenceState{lastSeen: now}
+				continue
+			}
+
+			silence := now.Sub(st.lastSeen)
+			if st.alerted || silence < r.MaxSilence {
+				continue
+			}
+
+			st.alerted = true
+			alerts = append(alerts, notify.Notification{
+				Type:      notify.NotificationAbsence,
+				EventType: r.EventType,
+				Container: ci,
+				Rule:      r.Pattern,
+				Window:    r.MaxSilence,
+			})
+		}
+	}
+	am.mu.Unlock()
+
+	for _, n := range alerts {
+		am.notify(n)
+	}
+}
+
+// Start periodically checks absence rules until the context is cancelled
+func (am *AbsenceMonitor) Start(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+
+	go func() {
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case now := <-ticker.C:
+				am.Check(now)
+			}
+		}
+	}()
+}
+
+// absenceKey returns the state key of the rule in the container
+func absenceKey(ruleID uint, containerID string) string {
+	return fmt.Sprintf("%d:%s", ruleID, containerID)
+}
@@ -0,0 +1,141 @@
+package scanner
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/ilyxenc/rattle/internal/models"
+)
+
+// Stream IDs in headers of Docker's multiplexed log frames, see stdcopy
+const (
+	frameStdin     = 0
+	frameStdout    = 1
+	frameStderr    = 2
+	frameSystemErr = 3
+)
+
+// frameHeaderLen is the size of a frame header: stream ID, 3 unused bytes and the payload size (big endian)
+const frameHeaderLen = 8
+
+// maxLineLen is the length of a line after which it's emitted even without a line break
+const maxLineLen = 1 << 20
+
+// readStream reads lines of the container log stream in order and sends them to `lines`.
+// A TTY stream is raw output reported as stdout, otherwise frames are demultiplexed into stdout and stderr.
+// Returns nil at the end of the stream
+func readStream(ctx context.Context, r io.Reader, tty bool, lines chan<- streamLine) error {
+	send := func(stream, text string) bool {
+		select {
+		case lines <- streamLine{Stream: stream, Text: strings.TrimRight(text, "\r")}:
+			return true
+		case <-ctx.Done():
+			return false
+		}
+	}
+
+	if tty {
+		return splitLines(bufio.NewReader(r), func(text string) bool {
+			return send(models.StreamStdout, text)
+		})
+	}
+
+	return readFrames(r, send)
+}
+
+// readFrames demultiplexes Docker log frames in a single pass, so lines of both streams keep their order.
+// Lines split across frames are joined per stream
+func readFrames(r io.Reader, send func(stream, text string) bool) error {
+	br := bufio.NewReader(r)
+	partial := map[string]*bytes.Buffer{
+		models.StreamStdout: {},
+		models.StreamStderr: {},
+	}
+
+	var header [frameHeaderLen]byte
+	for {
+		if _, err := io.ReadFull(br, header[:]); err != nil {
+			if errors.Is(err, io.EOF) {
+				// Emit lines without a trailing line break
+				for _, stream := range []string{models.StreamStdout, models.StreamStderr} {
+					if buf := partial[stream]; buf.Len() > 0 && !send(stream, buf.String()) {
+						return nil
+					}
+				}
+				return nil
+			}
+			return err
+		}
+
+		size := int64(binary.BigEndian.Uint32(header[4:]))
+
+		var stream string
+		switch header[0] {
+		case frameStdin, frameStdout:
+			stream = models.StreamStdout
+		case frameStderr:
+			stream = models.StreamStderr
+		case frameSystemErr:
+			msg, _ := io.ReadAll(io.LimitReader(br, size))
+			return fmt.Errorf("error from daemon in stream: %s", msg)
+		default:
+			return fmt.Errorf("unrecognized stream: %d", header[0])
+		}
+
+		buf := partial[stream]
+		if _, err := io.CopyN(buf, br, size); err != nil {
+			return err
+		}
+
+		for {
+			i := bytes.IndexByte(buf.Bytes(), '\n')
+			if i < 0 && buf.Len() < maxLineLen {
+				break
+			}
+
+			n := buf.Len()
+			if i >= 0 {
+				n = i
+			}
+			line := string(buf.Next(n))
+			if i >= 0 {
+				buf.Next(1) // Line break
+			}
+			if !send(stream, line) {
+				return nil
+			}
+		}
+	}
+}
+
+// splitLines reads the raw stream line by line. Stops early if `send` returns false
+func splitLines(br *bufio.Reader, send func(text string) bool) error {
+	var line []byte
+	for {
+		chunk, isPrefix, err := br.ReadLine()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				if len(line) > 0 {
+					send(string(line))
+				}
+				return nil
+			}
+			return err
+		}
+
+		line = append(line, chunk...)
+		if isPrefix && len(line) < maxLineLen {
+			continue
+		}
+		if !send(string(line)) {
+			return nil
+		}
+		line = line[:0]
+	}
+}
@@ -0,0 +1,118 @@
+package scanner
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"testing"
+	"time"
+
+	"github.com/ilyxenc/rattle/internal/config"
+	"github.com/ilyxenc/rattle/internal/models"
+)
+
+// frame encodes a Docker log frame of the stream
+func frame(stream byte, payload string) []byte {
+	header := make([]byte, frameHeaderLen)
+	header[0] = stream
+	binary.BigEndian.PutUint32(header[4:], uint32(len(payload)))
+	return append(header, payload...)
+}
+
+// collect reads all lines of the stream
+func collect(t *testing.T, data []byte, tty bool) []streamLine {
+	t.Helper()
+
+	lines := make(chan streamLine)
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- readStream(context.Background(), bytes.NewReader(data), tty, lines)
+		close(lines)
+	}()
+
+	var got []streamLine
+	for l := range lines {
+		got = append(got, l)
+	}
+	if err := <-errCh; err != nil {
+		t.Fatalf("readStream: %v", err)
+	}
+	return got
+}
+
+func TestReadFramesKeepsOrder(t *testing.T) {
+	var data []byte
+	data = append(data, frame(frameStdout, "out 1\n")...)
+	data = append(data, frame(frameStderr, "err 1\nerr ")...) // Line split across frames
+	data = append(data, frame(frameStdout, "out 2\nout 3\n")...)
+	data = append(data, frame(frameStderr, "2\n")...)
+	data = append(data, frame(frameStdout, "out 4")...) // No trailing line break
+
+	want := []streamLine{
+		{Stream: models.StreamStdout, Text: "out 1"},
+		{Stream: models.StreamStderr, Text: "err 1"},
+		{Stream: models.StreamStdout, Text: "out 2"},
+		{Stream: models.StreamStdout, Text: "out 3"},
+		{Stream: models.StreamStderr, Text: "err 2"},
+		{Stream: models.StreamStdout, Text: "out 4"},
+	}
+
+	got := collect(t, data, false)
+	if len(got) != len(want) {
+		t.Fatalf("got %d lines %q, want %d", len(got), got, len(want))
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("line %d: got %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestReadFramesSystemError(t *testing.T) {
+	lines := make(chan streamLine, 10)
+	err := readStream(context.Background(), bytes.NewReader(frame(frameSystemErr, "boom")), false, lines)
+	if err == nil {
+		t.Fatal("expected an error for a system error frame")
+	}
+}
+
+func TestAdvanceCursorStopsAtPendingEvent(t *testing.T) {
+	cfg := config.Multiline{Enabled: true, Presets: []string{"go"}, MaxLines: 100, FlushTimeout: time.Minute}
+	aggs := map[string]*MultilineAggregator{
+		models.StreamStdout: NewMultilineAggregator(cfg),
+		models.StreamStderr: NewMultilineAggregator(cfg),
+	}
+
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	s := &LogScanner{cursor: logPosition{Time: base, Seq: -1}}
+	s.Container.ID = "test-advance-cursor"
+
+	feed := func(stream, text string, sec int) logPosition {
+		l := logLine{Text: text, Stream: stream, Pos: logPosition{Time: base.Add(time.Duration(sec) * time.Second)}}
+		aggs[stream].Add(l)
+		s.advanceCursor(aggs, l.Pos)
+		return l.Pos
+	}
+
+	panicPos := feed(models.StreamStdout, "panic: boom", 1)
+	feed(models.StreamStdout, "goroutine 1 [running]:", 2)
+	errPos := feed(models.StreamStderr, "plain error", 3)
+	feed(models.StreamStderr, "another error", 4) // Emits "plain error"
+
+	// The stack trace on stdout is still pending, its lines must be read again after a restart
+	if want := panicPos.prev(); s.cursor != want {
+		t.Fatalf("cursor = %+v, want %+v (before the pending stack trace)", s.cursor, want)
+	}
+	if !panicPos.after(s.cursor) || !errPos.after(s.cursor) {
+		t.Fatal("lines after the pending stack trace must come after the cursor")
+	}
+
+	// Once both streams are flushed, the cursor moves to the last processed line
+	aggs[models.StreamStdout].Flush()
+	aggs[models.StreamStderr].Flush()
+	last := logPosition{Time: base.Add(4 * time.Second)}
+	s.advanceCursor(aggs, last)
+	if s.cursor != last {
+		t.Fatalf("cursor = %+v, want %+v", s.cursor, last)
+	}
+}
@@ -0,0 +1,78 @@
+package scanner
+
+import (
+	"strings"
+	"time"
+
+	"github.com/ilyxenc/rattle/internal/dispatcher"
+	"github.com/ilyxenc/rattle/internal/logger"
+	"github.com/ilyxenc/rattle/internal/managers"
+	"github.com/ilyxenc/rattle/internal/notify"
+)
+
+const (
+	idleTimeoutLabel  = "rattle.idle_timeout" // Container label with the idle threshold (e.g. "20m"), "0" or "off" disables it
+	idleCheckInterval = 10 * time.Second      // How often scanners check if their container went quiet
+)
+
+// parseIdleLabel reads the idle threshold from the `rattle.idle_timeout` label once, labels can't change for a running container.
+// An invalid label is reported here and ignored afterwards
+func (s *LogScanner) parseIdleLabel() {
+	s.idleLabel = nil
+
+	v, ok := s.Container.Labels[idleTimeoutLabel]
+	if !ok {
+		return
+	}
+
+	v = strings.ToLower(strings.TrimSpace(v))
+	if v == "off" || v == "0" {
+		off := time.Duration(0)
+		s.idleLabel = &off
+		return
+	}
+
+	d, err := time.ParseDuration(v)
+	if err != nil || d <= 0 {
+		logger.Log.Warnf("Invalid %s label on container %s: %q, idle rules apply instead", idleTimeoutLabel, s.Container.Name, v)
+		return
+	}
+	s.idleLabel = &d
+}
+
+// idleTimeout returns the idle threshold of the container, or 0 if silence isn't tracked.
+// The `rattle.idle_timeout` label takes precedence over idle rules
+func (s *LogScanner) idleTimeout() time.Duration {
+	if s.idleLabel != nil {
+		return *s.idleLabel
+	}
+	return managers.Idles.Timeout(s.Container)
+}
+
+// markActive records that the container produced a line, and reports it's back if it was idle
+func (s *LogScanner) markActive(now time.Time) {
+	if s.idle {
+		s.idle = false
+		dispatcher.Notify(notify.Notification{
+			Type:      notify.NotificationContainerResumed,
+			Container: s.Container,
+			Window:    now.Sub(s.lastLine),
+		})
+	}
+	s.lastLine = now
+}
+
+// checkIdle reports the container once it has been quiet for longer than its idle threshold
+func (s *LogScanner) checkIdle(now time.Time) {
+	timeout := s.idleTimeout()
+	if timeout <= 0 || s.idle || now.Sub(s.lastLine) < timeout {
+		return
+	}
+
+	s.idle = true
+	dispatcher.Notify(notify.Notification{
+		Type:      notify.NotificationContainerIdle,
+		Container: s.Container,
+		Window:    timeout,
+	})
+}
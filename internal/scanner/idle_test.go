@@ -0,0 +1,53 @@
+package scanner
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ilyxenc/rattle/internal/docker"
+	"github.com/ilyxenc/rattle/internal/logger"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestIdleLabelParsedOnce(t *testing.T) {
+	core, logs := observer.New(zapcore.WarnLevel)
+	prev := logger.Log
+	logger.Log = zap.New(core).Sugar()
+	t.Cleanup(func() { logger.Log = prev })
+
+	tests := []struct {
+		label  string
+		want   time.Duration
+		warned int
+	}{
+		{"20m", 20 * time.Minute, 0},
+		{" 1H ", time.Hour, 0},
+		{"off", 0, 0},
+		{"0", 0, 0},
+		{"soon", 0, 1}, // No idle rules, so silence isn't tracked
+		{"-5m", 0, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.label, func(t *testing.T) {
+			logs.TakeAll()
+
+			s := &LogScanner{Container: docker.ContainerInfo{ID: "c1", Name: "api", Labels: map[string]string{idleTimeoutLabel: tt.label}}}
+			s.parseIdleLabel()
+
+			// Checks run every idleCheckInterval and must not warn again
+			for range 3 {
+				if got := s.idleTimeout(); got != tt.want {
+					t.Fatalf("idleTimeout() = %s, want %s", got, tt.want)
+				}
+				s.checkIdle(time.Now())
+			}
+
+			if n := logs.Len(); n != tt.warned {
+				t.Errorf("logged %d warnings, want %d", n, tt.warned)
+			}
+		})
+	}
+}
@@ -1,8 +1,14 @@
 package scanner
 
 import (
+	"bytes"
 	"context"
 	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
 	"sync"
 	"time"
 
@@ -10,10 +16,20 @@ import (
 	"github.com/docker/docker/api/types/events"
 	"github.com/docker/docker/api/types/filters"
 	"github.com/docker/docker/client"
+	"github.com/docker/docker/pkg/stdcopy"
+	"github.com/ilyxenc/rattle/internal/dispatcher"
 	"github.com/ilyxenc/rattle/internal/docker"
 	"github.com/ilyxenc/rattle/internal/loganalyzer"
 	"github.com/ilyxenc/rattle/internal/logger"
-	"github.com/ilyxenc/rattle/internal/telegram"
+	"github.com/ilyxenc/rattle/internal/managers"
+	"github.com/ilyxenc/rattle/internal/notify"
+)
+
+const (
+	eventsMinBackoff = 1 * time.Second  // Initial delay before reconnecting to Docker events
+	eventsMaxBackoff = 60 * time.Second // Maximum delay between reconnect attempts
+
+	eventsStableAfter = 10 * time.Second // How long a resubscribed event stream has to stay up to count as restored
 )
 
 // LogScanManager manages log scanners for all running containers
@@ -23,6 +39,7 @@ type LogScanManager struct {
 	Scanners map[string]*LogScanner // Map of active scanners by container ID
 	Mu       sync.Mutex             // Mutex to protect Scanners map
 	wg       sync.WaitGroup         // Tracks active scanner goroutines
+	absence  *AbsenceMonitor        // Alerts when expected log lines don't appear
 }
 
 // NewLogScanManager creates a new LogScanManager instance
@@ -31,6 +48,7 @@ func NewLogScanManager(ctx context.Context, cli *client.Client) *LogScanManager
 		Client:   cli,
 		Ctx:      ctx,
 		Scanners: make(map[string]*LogScanner),
+		absence:  NewAbsenceMonitor(dispatcher.Notify),
 	}
 }
 
@@ -51,17 +69,19 @@ func (m *LogScanManager) StartAll() error {
 		if shouldIgnoreContainer(ci) {
 			continue
 		}
-		m.startScanner(c, true)
+		m.startScanner(c, time.Now(), true)
 		active = append(active, ci)
 	}
 
-	telegram.Notify(telegram.Notification{
-		Type:       telegram.NotificationContainersSummary,
+	dispatcher.Notify(notify.Notification{
+		Type:       notify.NotificationContainersSummary,
 		Containers: active,
 	})
 
 	go m.watchContainerEvents()
 
+	m.absence.Start(m.Ctx, absenceCheckInterval)
+
 	return nil
 }
 
@@ -79,8 +99,9 @@ func (m *LogScanManager) StopAll() {
 }
 
 // startScanner creates and starts a log scanner for the given container.
+// Logs are read from the saved cursor of the container or from `since` if there is none.
 // If a scanner already exists, it will be stopped and replaced
-func (m *LogScanManager) startScanner(c container.Summary, suppressNotify bool) {
+func (m *LogScanManager) startScanner(c container.Summary, since time.Time, suppressNotify bool) {
 	info := docker.NewContainerInfo(c)
 
 	m.Mu.Lock()
@@ -98,8 +119,8 @@ func (m *LogScanManager) startScanner(c container.Summary, suppressNotify bool)
 	s := &LogScanner{
 		Client:         m.Client,
 		Container:      info,
-		OnLog:          loganalyzer.AnalyzeLogLine,
-		Since:          time.Now(),
+		OnLog:          m.onLog,
+		Since:          since,
 		ReconnectDelay: 5 * time.Second,
 		MaxRetry:       0,
 		Cancel:         cancel,
@@ -107,10 +128,12 @@ func (m *LogScanManager) startScanner(c container.Summary, suppressNotify bool)
 	m.Scanners[info.ID] = s
 	m.Mu.Unlock()
 
+	m.absence.Track(info)
+
 	// Notify about container start if not already started
 	if !suppressNotify {
-		telegram.Notify(telegram.Notification{
-			Type:      telegram.NotificationContainerStart,
+		dispatcher.Notify(notify.Notification{
+			Type:      notify.NotificationContainerStart,
 			Container: info,
 		})
 	}
@@ -124,16 +147,20 @@ func (m *LogScanManager) startScanner(c container.Summary, suppressNotify bool)
 		err := s.Start(ctx)
 
 		if err != nil {
-			telegram.Notify(telegram.Notification{
-				Type:      telegram.NotificationContainerStopWithError,
+			dispatcher.Notify(notify.Notification{
+				Type:      notify.NotificationContainerStopWithError,
 				Container: info,
 			})
 			logger.Log.Warnw("Scanner stopped", "container", info.Name, "error", err)
 		}
 
-		// Remove scanner from the registry
+		// Remove scanner from the registry unless it was already replaced
 		m.Mu.Lock()
-		delete(m.Scanners, info.ID)
+		if m.Scanners[info.ID] == s {
+			delete(m.Scanners, info.ID)
+			m.absence.Forget(info.ID)
+			loganalyzer.ForgetContainer(info.ID)
+		}
 		m.Mu.Unlock()
 
 		// Skip notification if container stopped due to app shutdown
@@ -142,75 +169,308 @@ func (m *LogScanManager) startScanner(c container.Summary, suppressNotify bool)
 		}
 
 		// Notify only if not in shutdown mode
-		telegram.Notify(telegram.Notification{
-			Type:      telegram.NotificationContainerStop,
+		dispatcher.Notify(notify.Notification{
+			Type:      m.stopType(info.ID),
 			Container: info,
 		})
 		logger.Log.Infof("Scanner removed for container %s", info.Name)
 	}()
 }
 
-// watchContainerEvents listens for Docker container start/stop/restart events and updates scanners accordingly
+// stopType tells a container that exited with a non-zero code from one that stopped normally
+func (m *LogScanManager) stopType(id string) notify.NotificationType {
+	info, err := m.Client.ContainerInspect(m.Ctx, id)
+	if err != nil || info.ContainerJSONBase == nil || info.State == nil {
+		return notify.NotificationContainerStop
+	}
+
+	if !info.State.Running && info.State.ExitCode != 0 {
+		return notify.NotificationContainerStopWithError
+	}
+	return notify.NotificationContainerStop
+}
+
+// onLog passes the log event of a scanner to the absence monitor and the log analyzer
+func (m *LogScanManager) onLog(c docker.ContainerInfo, e loganalyzer.LogEntry) {
+	m.absence.Observe(c, e)
+	loganalyzer.AnalyzeLogLine(c, e)
+}
+
+// watchContainerEvents listens for Docker container start/stop/restart events and updates scanners accordingly.
+// If the event stream fails (e.g. daemon restart), it reconnects with backoff and reconciles scanners with running containers.
+// The stream counts as restored only once it delivers an event or stays up for eventsStableAfter
 func (m *LogScanManager) watchContainerEvents() {
-	eventFilter := filters.NewArgs()
-	eventFilter.Add("type", "container")
-	eventFilter.Add("event", "start")
-	eventFilter.Add("event", "die")
-	eventFilter.Add("event", "destroy")
+	backoff := eventsMinBackoff
+	var lostAt time.Time     // When the event stream was lost, zero if it's healthy
+	var started, stopped int // Scanners changed by reconciling since the stream was lost
 
-	eventsCh, errsCh := m.Client.Events(m.Ctx, events.ListOptions{Filters: eventFilter})
+	// restored reports the stream as healthy again and resets the backoff
+	restored := func() {
+		if lostAt.IsZero() {
+			return
+		}
+
+		logger.Log.Infof("Docker events stream restored: %d scanners started, %d stopped", started, stopped)
+		dispatcher.Notify(notify.Notification{
+			Type:    notify.NotificationEventsRestored,
+			Details: fmt.Sprintf("Scanners started: %d, stopped: %d", started, stopped),
+		})
+
+		lostAt = time.Time{}
+		started, stopped = 0, 0
+		backoff = eventsMinBackoff
+	}
+
+	for {
+		if !lostAt.IsZero() {
+			// Wait for the daemon to come back before subscribing again
+			if _, err := m.Client.Ping(m.Ctx); err != nil {
+				logger.Log.Warnf("Docker daemon is unavailable, retrying in %s: %v", backoff, err)
+				if !sleepCtx(m.Ctx, backoff) {
+					return
+				}
+				backoff = min(backoff*2, eventsMaxBackoff)
+				continue
+			}
+		}
+
+		eventFilter := filters.NewArgs()
+		eventFilter.Add("type", "container")
+		eventFilter.Add("event", "start")
+		eventFilter.Add("event", "die")
+		eventFilter.Add("event", "destroy")
+
+		eventsCh, errsCh := m.Client.Events(m.Ctx, events.ListOptions{Filters: eventFilter})
+
+		if !lostAt.IsZero() {
+			// Catch up on containers started or stopped while events were lost
+			s, st, err := m.reconcile(lostAt)
+			if err != nil {
+				logger.Log.Errorf("Failed to reconcile scanners: %v", err)
+			}
+			started += s
+			stopped += st
+		}
+
+		err := m.consumeEvents(eventsCh, errsCh, restored)
+		if m.Ctx.Err() != nil {
+			return
+		}
+
+		if lostAt.IsZero() {
+			logger.Log.Errorf("Docker event error: %v", err)
+			dispatcher.Notify(notify.Notification{
+				Type:    notify.NotificationEventsLost,
+				Details: err.Error(),
+			})
+			lostAt = time.Now()
+		} else {
+			// Still lost, it was already reported
+			logger.Log.Warnf("Docker events stream failed again, retrying in %s: %v", backoff, err)
+		}
+
+		if !sleepCtx(m.Ctx, backoff) {
+			return
+		}
+		backoff = min(backoff*2, eventsMaxBackoff)
+	}
+}
+
+// consumeEvents handles container events until the stream fails or the context is cancelled.
+// onStable is called once, on the first event or when the stream has stayed up for eventsStableAfter
+func (m *LogScanManager) consumeEvents(eventsCh <-chan events.Message, errsCh <-chan error, onStable func()) error {
+	stable := time.NewTimer(eventsStableAfter)
+	defer stable.Stop()
+
+	markStable := func() {
+		if onStable != nil {
+			onStable()
+			onStable = nil
+		}
+	}
 
 	for {
 		select {
 		case <-m.Ctx.Done():
-			return
+			return m.Ctx.Err()
+		case <-stable.C:
+			markStable()
 		case event := <-eventsCh:
-			id := event.Actor.ID
-			name := event.Actor.Attributes["name"]
-
-			switch event.Action {
-			case "start":
-				logger.Log.Infof("Container started: %s", name)
-
-				// Find full container info and start scanner
-				containers, err := m.Client.ContainerList(m.Ctx, container.ListOptions{
-					All: false,
-				})
-				if err != nil {
-					logger.Log.Errorf("Failed to list containers: %v", err)
+			markStable()
+			m.handleEvent(event)
+		case err := <-errsCh:
+			return err
+		}
+	}
+}
+
+// handleEvent starts or stops the scanner of the container from the event
+func (m *LogScanManager) handleEvent(event events.Message) {
+	id := event.Actor.ID
+	name := event.Actor.Attributes["name"]
+
+	switch event.Action {
+	case "start":
+		logger.Log.Infof("Container started: %s", name)
+
+		// Find full container info and start scanner
+		containers, err := m.Client.ContainerList(m.Ctx, container.ListOptions{
+			All: false,
+		})
+		if err != nil {
+			logger.Log.Errorf("Failed to list containers: %v", err)
+			return
+		}
+
+		for _, c := range containers {
+			if c.ID == id {
+				ci := docker.NewContainerInfo(c)
+				if shouldIgnoreContainer(ci) {
+					logger.Log.Infof("Ignored container %s due to filters", name)
 					continue
 				}
 
-				for _, c := range containers {
-					if c.ID == id {
-						ci := docker.NewContainerInfo(c)
-						if shouldIgnoreContainer(ci) {
-							logger.Log.Infof("Ignored container %s due to filters", name)
-							continue
-						}
-
-						m.startScanner(c, false)
-						break
-					}
-				}
-			case "die", "destroy":
-				logger.Log.Infof("Container stopped/destroyed: %s", name)
-
-				// Cancel and remove scanner
-				m.Mu.Lock()
-				if s, ok := m.Scanners[id]; ok {
-					if s.Cancel != nil {
-						s.Cancel()
-					}
-					delete(m.Scanners, id)
-					logger.Log.Infof("Stopped scanner for container %s", name)
-				}
-				m.Mu.Unlock()
+				// Read logs from the moment the container started
+				m.startScanner(c, time.Unix(0, event.TimeNano), false)
+				break
 			}
-		case err := <-errsCh:
-			logger.Log.Errorf("Docker event error: %v", err)
+		}
+	case "die", "destroy":
+		logger.Log.Infof("Container stopped/destroyed: %s", name)
 
-			return
+		// Cancel and remove scanner
+		if m.stopScanner(id) {
+			logger.Log.Infof("Stopped scanner for container %s", name)
+		}
+
+		// Destroyed container will never produce logs again
+		if event.Action == "destroy" {
+			if err := managers.Cursors.Delete(id); err != nil {
+				logger.Log.Warnf("Failed to delete log cursor for %s: %v", name, err)
+			}
 		}
 	}
 }
+
+// stopScanner cancels and removes the scanner of the container. Returns false if there was none
+func (m *LogScanManager) stopScanner(id string) bool {
+	m.Mu.Lock()
+	defer m.Mu.Unlock()
+
+	s, ok := m.Scanners[id]
+	if !ok {
+		return false
+	}
+
+	if s.Cancel != nil {
+		s.Cancel()
+	}
+	delete(m.Scanners, id)
+	m.absence.Forget(id)
+	loganalyzer.ForgetContainer(id)
+
+	return true
+}
+
+// reconcile starts scanners for running containers that have none and stops scanners of containers that are gone.
+// New scanners read logs from `since` unless the container has a saved cursor
+func (m *LogScanManager) reconcile(since time.Time) (started, stopped int, err error) {
+	containers, err := m.Client.ContainerList(m.Ctx, container.ListOptions{
+		All: false, // Only running containers
+	})
+	if err != nil {
+		return 0, 0, err
+	}
+
+	running := make(map[string]struct{}, len(containers))
+	for _, c := range containers {
+		running[c.ID] = struct{}{}
+
+		m.Mu.Lock()
+		_, exists := m.Scanners[c.ID]
+		m.Mu.Unlock()
+
+		if exists || shouldIgnoreContainer(docker.NewContainerInfo(c)) {
+			continue
+		}
+
+		m.startScanner(c, since, false)
+		started++
+	}
+
+	m.Mu.Lock()
+	stale := make([]string, 0)
+	for id := range m.Scanners {
+		if _, ok := running[id]; !ok {
+			stale = append(stale, id)
+		}
+	}
+	m.Mu.Unlock()
+
+	for _, id := range stale {
+		if m.stopScanner(id) {
+			stopped++
+		}
+	}
+
+	return started, stopped, nil
+}
+
+// Active returns containers being scanned, sorted by name
+func (m *LogScanManager) Active() []docker.ContainerInfo {
+	m.Mu.Lock()
+	active := make([]docker.ContainerInfo, 0, len(m.Scanners))
+	for _, s := range m.Scanners {
+		active = append(active, s.Container)
+	}
+	m.Mu.Unlock()
+
+	sort.Slice(active, func(i, j int) bool { return active[i].Name < active[j].Name })
+	return active
+}
+
+// Running returns all running containers, sorted by name
+func (m *LogScanManager) Running() ([]docker.ContainerInfo, error) {
+	containers, err := m.Client.ContainerList(m.Ctx, container.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	running := make([]docker.ContainerInfo, 0, len(containers))
+	for _, c := range containers {
+		running = append(running, docker.NewContainerInfo(c))
+	}
+
+	sort.Slice(running, func(i, j int) bool { return running[i].Name < running[j].Name })
+	return running, nil
+}
+
+// Logs returns the last `lines` lines of the container output, stdout and stderr merged
+func (m *LogScanManager) Logs(ctx context.Context, id string, lines int) (string, error) {
+	inspect, err := m.Client.ContainerInspect(ctx, id)
+	if err != nil {
+		return "", err
+	}
+
+	reader, err := m.Client.ContainerLogs(ctx, id, container.LogsOptions{
+		ShowStdout: true,
+		ShowStderr: true,
+		Tail:       strconv.Itoa(lines),
+	})
+	if err != nil {
+		return "", err
+	}
+	defer reader.Close()
+
+	var buf bytes.Buffer
+	if inspect.Config != nil && inspect.Config.Tty {
+		_, err = io.Copy(&buf, reader)
+	} else {
+		_, err = stdcopy.StdCopy(&buf, &buf, reader)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimRight(buf.String(), "\n"), nil
+}
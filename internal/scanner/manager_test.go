@@ -0,0 +1,211 @@
+package scanner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/api/types/events"
+	"github.com/docker/docker/client"
+	"github.com/ilyxenc/rattle/internal/docker"
+	"github.com/ilyxenc/rattle/internal/managers"
+	"github.com/ilyxenc/rattle/internal/notify"
+)
+
+// fakeDocker is a Docker API stand-in listing fixed running containers with log streams that stay open
+type fakeDocker struct {
+	mu      sync.Mutex
+	running []string       // Names of running containers, IDs are the same
+	exited  map[string]int // Exit codes of stopped containers
+}
+
+func (f *fakeDocker) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	switch {
+	case strings.HasSuffix(r.URL.Path, "/containers/json"):
+		f.mu.Lock()
+		list := make([]string, 0, len(f.running))
+		for _, id := range f.running {
+			list = append(list, `{"Id": "`+id+`", "Names": ["/`+id+`"], "Image": "app", "State": "running"}`)
+		}
+		f.mu.Unlock()
+		_, _ = w.Write([]byte("[" + strings.Join(list, ",") + "]"))
+	case strings.HasSuffix(r.URL.Path, "/json"):
+		id := path.Base(path.Dir(r.URL.Path))
+		f.mu.Lock()
+		code, ok := f.exited[id]
+		f.mu.Unlock()
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"message": "no such container"}`))
+			return
+		}
+		_, _ = fmt.Fprintf(w, `{"Id": %q, "State": {"Status": "exited", "Running": false, "ExitCode": %d}}`, id, code)
+	case strings.HasSuffix(r.URL.Path, "/logs"):
+		w.WriteHeader(http.StatusOK)
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+	default:
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(`{"message": "not found"}`))
+	}
+}
+
+// newTestManager creates a manager talking to the Docker API stand-in
+func newTestManager(t *testing.T, api http.Handler) *LogScanManager {
+	t.Helper()
+
+	srv := httptest.NewServer(api)
+	cli, err := client.NewClientWithOpts(client.WithHost("tcp://"+srv.Listener.Addr().String()), client.WithVersion("1.45"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	m := &LogScanManager{
+		Ctx:      ctx,
+		Client:   cli,
+		Scanners: make(map[string]*LogScanner),
+		absence:  NewAbsenceMonitor(func(notify.Notification) {}),
+	}
+	t.Cleanup(func() {
+		cancel()
+		m.wg.Wait()
+		cli.Close()
+		srv.Close()
+	})
+	return m
+}
+
+// scanned returns IDs of containers with scanners, sorted
+func (m *LogScanManager) scanned() []string {
+	m.Mu.Lock()
+	defer m.Mu.Unlock()
+
+	ids := make([]string, 0, len(m.Scanners))
+	for id := range m.Scanners {
+		ids = append(ids, id)
+	}
+	slices.Sort(ids)
+	return ids
+}
+
+func TestReconcile(t *testing.T) {
+	api := &fakeDocker{running: []string{"kept", "new"}}
+	m := newTestManager(t, api)
+
+	// Cursors are saved, so scanners don't look them up in the database
+	since := time.Now().Add(-time.Minute)
+	for _, id := range []string{"kept", "new", "gone"} {
+		managers.Cursors.Set(id, since, 0)
+	}
+
+	// Scanners of containers that were running before the event stream was lost
+	for _, id := range []string{"kept", "gone"} {
+		m.Scanners[id] = &LogScanner{Container: docker.ContainerInfo{ID: id, Name: id}, Cancel: func() {}}
+	}
+	kept := m.Scanners["kept"]
+
+	started, stopped, err := m.reconcile(since)
+	if err != nil {
+		t.Fatalf("reconcile: %v", err)
+	}
+	if started != 1 || stopped != 1 {
+		t.Errorf("reconcile = %d started, %d stopped, want 1 and 1", started, stopped)
+	}
+	if got := m.scanned(); !slices.Equal(got, []string{"kept", "new"}) {
+		t.Errorf("scanned containers = %v, want [kept new]", got)
+	}
+	if m.Scanners["kept"] != kept {
+		t.Error("scanner of a container that kept running was replaced")
+	}
+
+	// Nothing changed since, so a second pass is a no-op
+	started, stopped, err = m.reconcile(since)
+	if err != nil || started != 0 || stopped != 0 {
+		t.Errorf("second reconcile = %d, %d, %v, want no changes", started, stopped, err)
+	}
+}
+
+func TestReconcileListError(t *testing.T) {
+	m := newTestManager(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = w.Write([]byte(`{"message": "daemon is restarting"}`))
+	}))
+	m.Scanners["a"] = &LogScanner{Container: docker.ContainerInfo{ID: "a", Name: "a"}, Cancel: func() {}}
+
+	if _, _, err := m.reconcile(time.Now()); err == nil {
+		t.Fatal("reconcile succeeded while containers can't be listed")
+	}
+	if got := m.scanned(); !slices.Equal(got, []string{"a"}) {
+		t.Errorf("scanned containers = %v, want scanners kept when listing fails", got)
+	}
+}
+
+func TestConsumeEventsStable(t *testing.T) {
+	m := newTestManager(t, &fakeDocker{})
+
+	t.Run("stream fails right away", func(t *testing.T) {
+		eventsCh, errsCh := make(chan events.Message), make(chan error, 1)
+		errsCh <- errors.New("connection reset")
+
+		stable := 0
+		if err := m.consumeEvents(eventsCh, errsCh, func() { stable++ }); err == nil {
+			t.Fatal("consumeEvents returned no error")
+		}
+		if stable != 0 {
+			t.Errorf("stream that failed before delivering anything was reported stable")
+		}
+	})
+
+	t.Run("first event", func(t *testing.T) {
+		eventsCh, errsCh := make(chan events.Message, 2), make(chan error, 1)
+		eventsCh <- events.Message{Action: "die", Actor: events.Actor{ID: "unknown"}}
+		eventsCh <- events.Message{Action: "die", Actor: events.Actor{ID: "unknown"}}
+
+		stable := 0
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			_ = m.consumeEvents(eventsCh, errsCh, func() { stable++ })
+		}()
+
+		// Let both events be handled before failing the stream
+		for len(eventsCh) > 0 {
+			time.Sleep(time.Millisecond)
+		}
+		time.Sleep(10 * time.Millisecond)
+		errsCh <- errors.New("connection reset")
+		<-done
+
+		if stable != 1 {
+			t.Errorf("stream reported stable %d times, want once", stable)
+		}
+	})
+}
+
+func TestStopType(t *testing.T) {
+	api := &fakeDocker{exited: map[string]int{"ok": 0, "crashed": 137}}
+	m := newTestManager(t, api)
+
+	tests := map[string]notify.NotificationType{
+		"ok":      notify.NotificationContainerStop,
+		"crashed": notify.NotificationContainerStopWithError,
+		"removed": notify.NotificationContainerStop, // Can't be inspected anymore
+	}
+	for id, want := range tests {
+		if got := m.stopType(id); got != want {
+			t.Errorf("stopType(%s) = %s, want %s", id, got, want)
+		}
+	}
+}
@@ -0,0 +1,254 @@
+package scanner
+
+import (
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/ilyxenc/rattle/internal/config"
+	"github.com/ilyxenc/rattle/internal/logger"
+)
+
+// MultilinePreset describes how a multi-line event (like a stack trace) of some runtime looks
+type MultilinePreset struct {
+	Name     string           // Preset name used in config (go, python, java, node)
+	Start    []*regexp.Regexp // Lines that always open a new event owned by this preset
+	Continue []*regexp.Regexp // Lines that are appended to an event owned by this preset
+}
+
+// multilinePresets holds built-in presets by name
+var multilinePresets = map[string]MultilinePreset{
+	"go": {
+		Name: "go",
+		Start: []*regexp.Regexp{
+			regexp.MustCompile(`^panic: `),
+			regexp.MustCompile(`^fatal error: `),
+		},
+		Continue: []*regexp.Regexp{
+			regexp.MustCompile(`^\s*$`),                           // Empty line between panic message and goroutines
+			regexp.MustCompile(`^goroutine \d+ \[.*\]:?$`),        // goroutine 1 [running]:
+			regexp.MustCompile(`^[\w./*()\[\]{}-]+\(.*\)$`),       // main.main() or pkg.(*T).Method(0x1, ...)
+			regexp.MustCompile(`^\t`),                             // Tab-indented file of a frame: /app/main.go:12 +0x1d
+			regexp.MustCompile(`^created by `),                    // created by main.main in goroutine 1
+			regexp.MustCompile(`^\[(signal|recovered)`),           // [signal SIGSEGV: segmentation violation ...]
+			regexp.MustCompile(`^exit status \d+$`),               // Trailing exit status
+			regexp.MustCompile(`^\.\.\.additional frames elided`), // Truncated stack
+		},
+	},
+	"python": {
+		Name: "python",
+		Start: []*regexp.Regexp{
+			regexp.MustCompile(`^Traceback \(most recent call last\):$`),
+		},
+		Continue: []*regexp.Regexp{
+			regexp.MustCompile(`^\s*$`),
+			regexp.MustCompile(`^\s+`), // File "...", line N and source lines
+			regexp.MustCompile(`^[A-Za-z_][\w.]*(Error|Exception|Exit|Interrupt|Warning)\b`), // Final exception line
+			regexp.MustCompile(`^During handling of the above exception, another exception occurred:$`),
+			regexp.MustCompile(`^The above exception was the direct cause of the following exception:$`),
+			regexp.MustCompile(`^Traceback \(most recent call last\):$`),
+		},
+	},
+	"java": {
+		Name: "java",
+		Start: []*regexp.Regexp{
+			regexp.MustCompile(`^Exception in thread "[^"]*" `),
+			regexp.MustCompile(`^([a-zA-Z_$][\w$]*\.)+[\w$]*(Exception|Error|Throwable)(: .*)?$`), // java.lang.IllegalStateException: boom
+		},
+		Continue: []*regexp.Regexp{
+			regexp.MustCompile(`^\s+at `),
+			regexp.MustCompile(`^\s*\.\.\. \d+ (more|common frames omitted)$`),
+			regexp.MustCompile(`^\s*(Caused by|Suppressed): `),
+		},
+	},
+	"node": {
+		Name: "node",
+		Start: []*regexp.Regexp{
+			regexp.MustCompile(`^(Uncaught )?[A-Z]\w*Error(: .*)?$`), // TypeError: x is not a function
+			regexp.MustCompile(`^\(node:\d+\) UnhandledPromiseRejection`),
+		},
+		Continue: []*regexp.Regexp{
+			regexp.MustCompile(`^\s+at `),
+			regexp.MustCompile(`^\s+\w+: `), // Error properties like code: 'ERR_X'
+			regexp.MustCompile(`^\}$`),      // End of error properties object
+		},
+	},
+}
+
+// MultilineAggregator groups consecutive log lines into a single event.
+//
+// Lines are appended to the pending event while they match a `Continue` pattern of the preset
+// owning the event. Otherwise a line matching a preset `Start` pattern opens a new event, and
+// indented lines (if enabled) are appended to the pending event. Any other line emits the
+// pending event and opens a new one. An event never has more than `maxLines` lines: a line
+// that doesn't fit emits it and opens the next one. The pending event is also emitted when no
+// new line arrives within the flush timeout.
+//
+// MultilineAggregator is not safe for concurrent use
+type MultilineAggregator struct {
+	enabled      bool
+	presets      []MultilinePreset
+	indent       bool
+	maxLines     int
+	flushTimeout time.Duration
+
+	lines    []string         // Lines of the pending event
+	first    logLine          // First line of the pending event
+	last     logPosition      // Position of the last line of the pending event
+	owner    *MultilinePreset // Preset that opened the pending event, nil for a plain line
+	deadline time.Time        // When the pending event must be emitted if no more lines arrive
+}
+
+// NewMultilineAggregator creates an aggregator from the multiline config
+func NewMultilineAggregator(cfg config.Multiline) *MultilineAggregator {
+	presets := make([]MultilinePreset, 0, len(cfg.Presets))
+	for _, name := range cfg.Presets {
+		p, ok := multilinePresets[strings.ToLower(strings.TrimSpace(name))]
+		if !ok {
+			logger.Log.Warnf("Unknown multiline preset: %s", name)
+			continue
+		}
+		presets = append(presets, p)
+	}
+
+	maxLines := cfg.MaxLines
+	if maxLines <= 0 {
+		maxLines = 1
+	}
+
+	return &MultilineAggregator{
+		enabled:      cfg.Enabled,
+		presets:      presets,
+		indent:       cfg.Indent,
+		maxLines:     maxLines,
+		flushTimeout: cfg.FlushTimeout,
+	}
+}
+
+// Deadline returns when the pending event must be emitted if no more lines arrive
+func (a *MultilineAggregator) Deadline() time.Time {
+	return a.deadline
+}
+
+// Start returns the position of the first line of the pending event, if any
+func (a *MultilineAggregator) Start() (logPosition, bool) {
+	return a.first.Pos, a.Pending()
+}
+
+// Pending reports whether there is an event waiting to be emitted
+func (a *MultilineAggregator) Pending() bool {
+	return len(a.lines) > 0
+}
+
+// Add feeds a raw log line into the aggregator.
+// It returns a completed event and true if the line finished the previous one
+func (a *MultilineAggregator) Add(l logLine) (logEvent, bool) {
+	line := l.Text
+
+	if !a.enabled {
+		return logEvent{Text: line, Stream: l.Stream, Time: l.Pos.Time, Pos: l.Pos}, true
+	}
+
+	// Line belongs to the event opened by a preset
+	if a.Pending() && a.ownerContinues(line) {
+		return a.appendLine(l)
+	}
+
+	// Line explicitly opens a new event
+	if p := a.matchStart(line); p != nil {
+		event, ok := a.Flush()
+		a.open(l, p)
+		return event, ok
+	}
+
+	// Indented line continues any event
+	if a.Pending() && a.isIndented(line) {
+		return a.appendLine(l)
+	}
+
+	event, ok := a.Flush()
+
+	// Empty line only finishes the pending event
+	if strings.TrimSpace(line) == "" {
+		return event, ok
+	}
+
+	a.open(l, nil)
+	return event, ok
+}
+
+// Flush emits the pending event, if any
+func (a *MultilineAggregator) Flush() (logEvent, bool) {
+	if !a.Pending() {
+		return logEvent{}, false
+	}
+
+	event := logEvent{
+		Text:   strings.Join(a.lines, "\n"),
+		Stream: a.first.Stream,
+		Time:   a.first.Pos.Time,
+		Pos:    a.last,
+	}
+	a.lines = a.lines[:0]
+	a.owner = nil
+	return event, true
+}
+
+// open starts a new pending event with the line
+func (a *MultilineAggregator) open(l logLine, owner *MultilinePreset) {
+	a.lines = append(a.lines, l.Text)
+	a.first = l
+	a.last = l.Pos
+	a.owner = owner
+	a.deadline = time.Now().Add(a.flushTimeout)
+}
+
+// appendLine adds the line to the pending event. If the event is full, it's emitted and the line opens the next one
+func (a *MultilineAggregator) appendLine(l logLine) (logEvent, bool) {
+	if len(a.lines) >= a.maxLines {
+		owner := a.owner
+		event, ok := a.Flush()
+		a.open(l, owner) // The rest of a long stack trace still belongs to its preset
+		return event, ok
+	}
+
+	a.lines = append(a.lines, l.Text)
+	a.last = l.Pos
+	a.deadline = time.Now().Add(a.flushTimeout)
+	return logEvent{}, false
+}
+
+// matchStart returns the preset whose start pattern matches the line
+func (a *MultilineAggregator) matchStart(line string) *MultilinePreset {
+	for i := range a.presets {
+		for _, re := range a.presets[i].Start {
+			if re.MatchString(line) {
+				return &a.presets[i]
+			}
+		}
+	}
+	return nil
+}
+
+// ownerContinues checks if the line matches a continuation pattern of the preset owning the pending event
+func (a *MultilineAggregator) ownerContinues(line string) bool {
+	if a.owner == nil {
+		return false
+	}
+
+	for _, re := range a.owner.Continue {
+		if re.MatchString(line) {
+			return true
+		}
+	}
+	return false
+}
+
+// isIndented checks if the line is a non-empty line starting with whitespace
+func (a *MultilineAggregator) isIndented(line string) bool {
+	if !a.indent || strings.TrimSpace(line) == "" {
+		return false
+	}
+
+	return strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")
+}
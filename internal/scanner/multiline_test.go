@@ -0,0 +1,207 @@
+package scanner
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/client"
+	"github.com/ilyxenc/rattle/internal/config"
+	"github.com/ilyxenc/rattle/internal/docker"
+	"github.com/ilyxenc/rattle/internal/loganalyzer"
+	"github.com/ilyxenc/rattle/internal/models"
+)
+
+// aggregate feeds the lines into a new aggregator and returns the emitted events, including the one flushed at the end
+func aggregate(cfg config.Multiline, lines []string) []string {
+	agg := NewMultilineAggregator(cfg)
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	var events []string
+	for i, text := range lines {
+		l := logLine{Text: text, Stream: models.StreamStdout, Pos: logPosition{Time: base.Add(time.Duration(i) * time.Second)}}
+		if event, ok := agg.Add(l); ok {
+			events = append(events, event.Text)
+		}
+	}
+	if event, ok := agg.Flush(); ok {
+		events = append(events, event.Text)
+	}
+	return events
+}
+
+func TestMultilinePresets(t *testing.T) {
+	goPanic := []string{
+		"panic: runtime error: invalid memory address or nil pointer dereference",
+		"[signal SIGSEGV: segmentation violation code=0x1 addr=0x0 pc=0x47b2a6]",
+		"",
+		"goroutine 1 [running]:",
+		"main.(*Server).handle(0x0, {0x4c5e20, 0xc000012345})",
+		"\t/app/server.go:42 +0x26",
+		"main.main()",
+		"\t/app/main.go:12 +0x1d",
+		"exit status 2",
+	}
+	pythonTraceback := []string{
+		"Traceback (most recent call last):",
+		`  File "/app/main.py", line 10, in <module>`,
+		"    main()",
+		`  File "/app/main.py", line 6, in main`,
+		"    raise ValueError('boom')",
+		"ValueError: boom",
+	}
+	javaException := []string{
+		`Exception in thread "main" java.lang.IllegalStateException: boom`,
+		"\tat com.example.App.run(App.java:20)",
+		"\tat com.example.App.main(App.java:8)",
+		"Caused by: java.io.IOException: disk full",
+		"\tat com.example.Store.write(Store.java:55)",
+		"\t... 2 more",
+	}
+	nodeError := []string{
+		"TypeError: Cannot read properties of undefined (reading 'id')",
+		"    at handler (/app/index.js:14:22)",
+		"    at process.processTicksAndRejections (node:internal/process/task_queues:95:5)",
+	}
+
+	tests := []struct {
+		preset string
+		lines  []string
+		want   []string
+	}{
+		{
+			preset: "go",
+			lines:  append(append([]string{"starting"}, goPanic...), "restarting"),
+			want:   []string{"starting", strings.Join(goPanic, "\n"), "restarting"},
+		},
+		{
+			preset: "python",
+			lines:  append(append([]string{"INFO request"}, pythonTraceback...), "INFO next request"),
+			want:   []string{"INFO request", strings.Join(pythonTraceback, "\n"), "INFO next request"},
+		},
+		{
+			preset: "java",
+			lines:  append(append([]string{"INFO started"}, javaException...), "INFO shutting down"),
+			want:   []string{"INFO started", strings.Join(javaException, "\n"), "INFO shutting down"},
+		},
+		{
+			preset: "node",
+			lines:  append(append([]string{"listening on 3000"}, nodeError...), "request done"),
+			want:   []string{"listening on 3000", strings.Join(nodeError, "\n"), "request done"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.preset, func(t *testing.T) {
+			// Presets must group traces on their own, without the indentation rule
+			cfg := config.Multiline{Enabled: true, Presets: []string{tt.preset}, MaxLines: 100}
+			if got := aggregate(cfg, tt.lines); !slices.Equal(got, tt.want) {
+				t.Errorf("events = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMultilineDisabledAndIndent(t *testing.T) {
+	lines := []string{"request failed", "  retrying in 1s", "done"}
+
+	if got := aggregate(config.Multiline{Enabled: false, MaxLines: 100}, lines); !slices.Equal(got, lines) {
+		t.Errorf("disabled: events = %q, want every line", got)
+	}
+
+	want := []string{"request failed\n  retrying in 1s", "done"}
+	if got := aggregate(config.Multiline{Enabled: true, Indent: true, MaxLines: 100}, lines); !slices.Equal(got, want) {
+		t.Errorf("indent: events = %q, want %q", got, want)
+	}
+}
+
+func TestMultilineMaxLines(t *testing.T) {
+	trace := []string{"panic: boom", "", "goroutine 1 [running]:", "main.main()", "\t/app/main.go:12 +0x1d"}
+
+	tests := []struct {
+		maxLines int
+		want     []string
+	}{
+		{1, trace},
+		{2, []string{"panic: boom\n", "goroutine 1 [running]:\nmain.main()", "\t/app/main.go:12 +0x1d"}},
+		{5, []string{strings.Join(trace, "\n")}},
+	}
+
+	for _, tt := range tests {
+		got := aggregate(config.Multiline{Enabled: true, Presets: []string{"go"}, MaxLines: tt.maxLines}, trace)
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("maxLines %d: events = %q, want %q", tt.maxLines, got, tt.want)
+		}
+		for _, event := range got {
+			if n := strings.Count(event, "\n") + 1; n > tt.maxLines {
+				t.Errorf("maxLines %d: event %q has %d lines", tt.maxLines, event, n)
+			}
+		}
+	}
+}
+
+// openLogsAPI is a Docker API stand-in writing the log stream and keeping it open
+type openLogsAPI struct {
+	data []byte
+}
+
+func (api *openLogsAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !strings.HasSuffix(r.URL.Path, "/logs") {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/vnd.docker.multiplexed-stream")
+	_, _ = w.Write(api.data)
+	w.(http.Flusher).Flush()
+	<-r.Context().Done()
+}
+
+func TestMultilineFlushTimeout(t *testing.T) {
+	prev := config.Cfg
+	config.Cfg = &config.Config{Multiline: config.Multiline{Enabled: true, Presets: []string{"go"}, MaxLines: 100, FlushTimeout: 50 * time.Millisecond}}
+	t.Cleanup(func() { config.Cfg = prev })
+
+	start := time.Now().UTC()
+	ts := start.Format(time.RFC3339Nano) + " "
+	var data []byte
+	for _, line := range []string{"panic: boom", "", "goroutine 1 [running]:", "main.main()"} {
+		data = append(data, frame(frameStderr, ts+line+"\n")...)
+	}
+
+	srv := httptest.NewServer(&openLogsAPI{data: data})
+	defer srv.Close()
+	cli, err := client.NewClientWithOpts(client.WithHost("tcp://"+srv.Listener.Addr().String()), client.WithVersion("1.45"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cli.Close()
+
+	events := make(chan string, 10)
+	s := &LogScanner{
+		Client:    cli,
+		Container: docker.ContainerInfo{ID: "flush", Name: "flush"},
+		OnLog: func(_ docker.ContainerInfo, e loganalyzer.LogEntry) {
+			events <- e.Text
+		},
+		cursor: logPosition{Time: start.Add(-time.Second), Seq: -1},
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() { _ = s.streamOnce(ctx) }()
+
+	// The stream stays open, so only the flush timeout emits the pending stack trace
+	select {
+	case got := <-events:
+		if want := "panic: boom\n\ngoroutine 1 [running]:\nmain.main()"; got != want {
+			t.Errorf("event = %q, want %q", got, want)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("pending event wasn't flushed after the timeout")
+	}
+}
@@ -1,26 +1,43 @@
 package scanner
 
 import (
-	"bufio"
 	"context"
 	"fmt"
-	"io"
+	"strings"
 	"time"
 
 	"github.com/docker/docker/api/types/container"
-	"github.com/docker/docker/pkg/stdcopy"
+	"github.com/ilyxenc/rattle/internal/config"
+	"github.com/ilyxenc/rattle/internal/loganalyzer"
 	"github.com/ilyxenc/rattle/internal/logger"
+	"github.com/ilyxenc/rattle/internal/managers"
+	"github.com/ilyxenc/rattle/internal/models"
 )
 
 // Start begins streaming logs from the container.
 // It automatically reconnects on failure unless MaxRetry is reached
 func (s *LogScanner) Start(ctx context.Context) error {
-	since := s.Since
+	// Resume from the saved cursor, if any
+	if c, ok := managers.Cursors.Get(s.Container.ID); ok {
+		s.cursor = logPosition{Time: c.Time(), Seq: c.Seq}
+		logger.Log.Debugf("Resuming logs of %s from %s", s.Container.Name, c.Time().Format(time.RFC3339Nano))
+	} else {
+		s.cursor = logPosition{Time: s.Since, Seq: -1}
+		managers.Cursors.Set(s.Container.ID, s.cursor.Time, s.cursor.Seq) // Resume from here even if no lines are processed
+	}
+
+	// Silence is counted from the moment scanning starts
+	s.lastLine = time.Now()
+	s.parseIdleLabel()
+
+	// TTY can't be changed for a running container, so check it once
+	s.TTY = s.detectTTY(ctx)
+
 	retries := 0
 
 	for {
 		// Attempt a single log stream session
-		err := s.streamOnce(ctx, since)
+		err := s.streamOnce(ctx)
 		if err == nil || ctx.Err() != nil {
 			return err // Exit on success or if context was cancelled
 		}
@@ -36,52 +53,167 @@ func (s *LogScanner) Start(ctx context.Context) error {
 			return fmt.Errorf("max retry reached for %s: %w", s.Container.Name, err)
 		}
 
-		time.Sleep(s.ReconnectDelay)
-		since = time.Now() // Update `since` to avoid getting duplicates
+		time.Sleep(s.ReconnectDelay) // Next session continues from `s.cursor`, so no lines are lost
 	}
 }
 
-// streamOnce connects to the container logs and reads them line by line
-func (s *LogScanner) streamOnce(ctx context.Context, since time.Time) error {
+// streamOnce connects to the container logs starting from the cursor, reads them line by line and groups lines into events
+func (s *LogScanner) streamOnce(ctx context.Context) error {
+	resume := s.cursor
+
 	reader, err := s.Client.ContainerLogs(ctx, s.Container.ID, container.LogsOptions{
 		ShowStdout: true,
 		ShowStderr: true,
 		Follow:     true,
-		Since:      fmt.Sprintf("%d", since.Unix()), // Start from given timestamp
-		Timestamps: false,
+		Since:      fmt.Sprintf("%d.%09d", resume.Time.Unix(), resume.Time.Nanosecond()), // Start from cursor timestamp (inclusive)
+		Timestamps: true,
 	})
 	if err != nil {
 		return err
 	}
 	defer reader.Close()
 
-	// Pipe to capture clean stdout/stderr from stdcopy
-	pr, pw := io.Pipe()
-
-	// Strip Docker's multiplexed headers and write to pipe
+	// Read lines in background so pending multi-line events can be flushed on timeout.
+	// A single reader keeps lines of both streams in order, so their positions are stable across reconnects
+	lines := make(chan streamLine)
+	errCh := make(chan error, 1)
 	go func() {
-		defer pw.Close()
-		_, _ = stdcopy.StdCopy(pw, pw, reader)
+		errCh <- readStream(ctx, reader, s.TTY, lines)
+		close(lines)
 	}()
 
-	scanner := bufio.NewScanner(pr)
+	// Lines of different streams are grouped into events separately
+	aggs := map[string]*MultilineAggregator{
+		models.StreamStdout: NewMultilineAggregator(config.Cfg.Multiline),
+		models.StreamStderr: NewMultilineAggregator(config.Cfg.Multiline),
+	}
+
+	var last logPosition  // Position of the previous line in this session
+	processed := s.cursor // Position of the last line fed into an aggregator
+
+	flushTimer := time.NewTimer(time.Hour)
+	flushTimer.Stop()
+	defer flushTimer.Stop()
 
-	for scanner.Scan() {
+	idleTicker := time.NewTicker(idleCheckInterval)
+	defer idleTicker.Stop()
+
+	for {
 		select {
 		case <-ctx.Done():
 			return nil // Exit gracefully if cancelled
-		default:
-			line := cleanLine(scanner.Text())
-			if line != "" && s.OnLog != nil {
-				s.OnLog(s.Container, line) // Forward log line to callback
+		case sl, ok := <-lines:
+			if !ok {
+				// Stream is over, emit what is left
+				for _, agg := range aggs {
+					if event, ok := agg.Flush(); ok {
+						s.emit(event)
+					}
+				}
+				s.advanceCursor(aggs, processed)
+
+				// Return any unexpected stream error
+				return <-errCh
+			}
+
+			l := parseLogLine(sl, last)
+			last = l.Pos
+
+			// Skip lines processed before reconnect or restart
+			if !l.Pos.after(resume) {
+				continue
 			}
+
+			s.markActive(time.Now())
+
+			if event, ok := aggs[l.Stream].Add(l); ok {
+				s.emit(event)
+			}
+			processed = l.Pos
+			s.advanceCursor(aggs, processed)
+		case <-flushTimer.C:
+			now := time.Now()
+			for _, agg := range aggs {
+				if agg.Pending() && !now.Before(agg.Deadline()) {
+					if event, ok := agg.Flush(); ok {
+						s.emit(event)
+					}
+				}
+			}
+			s.advanceCursor(aggs, processed)
+		case now := <-idleTicker.C:
+			s.checkIdle(now)
+		}
+
+		// Wake up when the earliest pending event must be emitted
+		if d, ok := nextFlush(aggs); ok {
+			resetTimer(flushTimer, d)
 		}
 	}
+}
 
-	// Return any unexpected scanner error (excluding EOF)
-	if err := scanner.Err(); err != nil && err != io.EOF {
-		return err
+// detectTTY checks if the container was started with a TTY. Falls back to multiplexed logs if it can't be inspected
+func (s *LogScanner) detectTTY(ctx context.Context) bool {
+	info, err := s.Client.ContainerInspect(ctx, s.Container.ID)
+	if err != nil {
+		logger.Log.Warnf("Failed to inspect container %s, assuming no TTY: %v", s.Container.Name, err)
+		return false
+	}
+
+	return info.Config != nil && info.Config.Tty
+}
+
+// emit cleans the event and forwards it to the callback
+func (s *LogScanner) emit(event logEvent) {
+	text := cleanLine(event.Text)
+	if text != "" && s.OnLog != nil {
+		// Forward log event to callback
+		s.OnLog(s.Container, loganalyzer.LogEntry{
+			Text:   text,
+			Stream: event.Stream,
+			Time:   event.Time,
+		})
+	}
+}
+
+// advanceCursor moves the cursor to the last processed line, but not past the first line of an event still pending
+// in an aggregator: the cursor is saved, and the lines of a pending event must be read again after a restart
+func (s *LogScanner) advanceCursor(aggs map[string]*MultilineAggregator, processed logPosition) {
+	pos := processed
+	for _, agg := range aggs {
+		if first, ok := agg.Start(); ok {
+			if before := first.prev(); pos.after(before) {
+				pos = before
+			}
+		}
+	}
+
+	if pos.after(s.cursor) {
+		s.cursor = pos
+		managers.Cursors.Set(s.Container.ID, s.cursor.Time, s.cursor.Seq)
+	}
+}
+
+// parseLogLine splits the Docker timestamp from the line, strips terminal control sequences and computes its position in the stream.
+// `prev` is the position of the previous line, used to number lines with the same timestamp
+func parseLogLine(sl streamLine, prev logPosition) logLine {
+	ts := time.Now()
+	line := sl.Text
+	text := line
+
+	if i := strings.IndexByte(line, ' '); i > 0 {
+		if t, err := time.Parse(time.RFC3339Nano, line[:i]); err == nil {
+			ts = t
+			text = line[i+1:]
+		}
+	}
+
+	text = stripTerminalControls(text)
+
+	pos := logPosition{Time: ts}
+	if ts.Equal(prev.Time) {
+		pos.Seq = prev.Seq + 1
 	}
 
-	return nil
+	return logLine{Text: text, Stream: sl.Stream, Pos: pos}
 }
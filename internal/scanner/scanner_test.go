@@ -0,0 +1,224 @@
+package scanner
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/client"
+	"github.com/ilyxenc/rattle/internal/config"
+	"github.com/ilyxenc/rattle/internal/docker"
+	"github.com/ilyxenc/rattle/internal/loganalyzer"
+	"github.com/ilyxenc/rattle/internal/logger"
+	"github.com/ilyxenc/rattle/internal/managers"
+	"github.com/ilyxenc/rattle/internal/models"
+	"go.uber.org/zap"
+)
+
+func TestMain(m *testing.M) {
+	logger.Log = zap.NewNop().Sugar()
+	config.Cfg = &config.Config{}
+	os.Exit(m.Run())
+}
+
+// readFixture reads a recorded log stream from testdata and parses its lines like streamOnce does
+func readFixture(t *testing.T, name string, tty bool) []logLine {
+	t.Helper()
+
+	data, err := os.ReadFile("testdata/" + name)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var parsed []logLine
+	var last logPosition
+	for _, sl := range collect(t, data, tty) {
+		l := parseLogLine(sl, last)
+		last = l.Pos
+		parsed = append(parsed, l)
+	}
+	return parsed
+}
+
+func TestRecordedStreams(t *testing.T) {
+	at := func(sec, seq int) logPosition {
+		return logPosition{Time: time.Date(2024, 5, 1, 10, 0, sec, 0, time.UTC), Seq: seq}
+	}
+
+	tests := []struct {
+		fixture string
+		tty     bool
+		want    []logLine
+	}{
+		{
+			// Recorded with stdcopy: stdout and stderr frames, one frame with several lines, a line split across frames
+			fixture: "multiplexed.log",
+			want: []logLine{
+				{Stream: models.StreamStdout, Text: "INFO server started", Pos: at(0, 0)},
+				{Stream: models.StreamStderr, Text: "ERROR connection refused", Pos: at(1, 0)},
+				{Stream: models.StreamStdout, Text: "retrying", Pos: at(1, 1)},
+				{Stream: models.StreamStderr, Text: "panic: boom", Pos: at(2, 0)},
+				{Stream: models.StreamStderr, Text: "", Pos: at(2, 1)},
+				{Stream: models.StreamStderr, Text: "goroutine 1 [running]:", Pos: at(2, 2)},
+				{Stream: models.StreamStdout, Text: "long line split across frames", Pos: at(3, 0)},
+			},
+		},
+		{
+			// Raw TTY output: colors, CRLF line endings, a progress bar redrawn with CR, a window title
+			fixture: "tty.log",
+			tty:     true,
+			want: []logLine{
+				{Stream: models.StreamStdout, Text: "error: disk full", Pos: at(0, 0)},
+				{Stream: models.StreamStdout, Text: "Downloading 100%", Pos: at(1, 0)},
+				{Stream: models.StreamStdout, Text: "done", Pos: at(1, 1)},
+				{Stream: models.StreamStdout, Text: "plain line", Pos: at(2, 0)},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.fixture, func(t *testing.T) {
+			got := readFixture(t, tt.fixture, tt.tty)
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %d lines %+v, want %d", len(got), got, len(tt.want))
+			}
+			for i, want := range tt.want {
+				if got[i].Stream != want.Stream || got[i].Text != want.Text || !got[i].Pos.Time.Equal(want.Pos.Time) || got[i].Pos.Seq != want.Pos.Seq {
+					t.Errorf("line %d = %+v, want %+v", i, got[i], want)
+				}
+			}
+		})
+	}
+}
+
+func TestStripTerminalControls(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"plain", "hello", "hello"},
+		{"colors", "\x1b[31merror\x1b[0m: \x1b[1;33mboom\x1b[m", "error: boom"},
+		{"cursor movement", "\x1b[2K\x1b[1Gdone", "done"},
+		{"window title", "\x1b]0;title\x07ready", "ready"},
+		{"link", "\x1b]8;;https://example.com\x1b\\link\x1b]8;;\x1b\\", "link"},
+		{"trailing CR", "line\r", "line"},
+		{"progress bar", "10%\r50%\r100%", "100%"},
+		{"colored progress", "\x1b[32m10%\x1b[0m\r\x1b[32m100%\x1b[0m\r", "100%"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := stripTerminalControls(tt.in); got != tt.want {
+				t.Errorf("stripTerminalControls(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDetectTTY(t *testing.T) {
+	// Docker API stand-in answering container inspect requests
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/containers/tty/json"):
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{"Id": "tty", "Config": {"Tty": true}}`))
+		case strings.HasSuffix(r.URL.Path, "/containers/pipes/json"):
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{"Id": "pipes", "Config": {"Tty": false}}`))
+		default:
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"message": "No such container"}`))
+		}
+	}))
+	defer srv.Close()
+
+	cli, err := client.NewClientWithOpts(client.WithHost("tcp://"+srv.Listener.Addr().String()), client.WithVersion("1.45"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cli.Close()
+
+	tests := []struct {
+		id   string
+		want bool
+	}{
+		{"tty", true},
+		{"pipes", false},
+		{"gone", false}, // Can't be inspected, multiplexed logs are assumed
+	}
+
+	for _, tt := range tests {
+		s := &LogScanner{Client: cli, Container: docker.ContainerInfo{ID: tt.id, Name: tt.id}}
+		if got := s.detectTTY(context.Background()); got != tt.want {
+			t.Errorf("detectTTY(%s) = %v, want %v", tt.id, got, tt.want)
+		}
+	}
+}
+
+// logsAPI is a Docker API stand-in serving a fixed multiplexed log stream that ends
+type logsAPI struct {
+	data []byte
+}
+
+func (api *logsAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if strings.HasSuffix(r.URL.Path, "/logs") {
+		w.Header().Set("Content-Type", "application/vnd.docker.multiplexed-stream")
+		_, _ = w.Write(api.data)
+		return
+	}
+	w.WriteHeader(http.StatusNotFound)
+}
+
+func TestResumeAtBoundary(t *testing.T) {
+	boundary := time.Date(2025, 6, 1, 10, 0, 0, 123456789, time.UTC)
+	ts := func(t time.Time) string { return t.Format(time.RFC3339Nano) + " " }
+
+	var data []byte
+	for _, line := range []string{
+		ts(boundary.Truncate(time.Microsecond)) + "before", // Where a cursor truncated to microseconds would resume
+		ts(boundary) + "processed",
+		ts(boundary) + "next at the same time",
+		ts(boundary.Add(time.Second)) + "later",
+	} {
+		data = append(data, frame(frameStdout, line+"\n")...)
+	}
+
+	srv := httptest.NewServer(&logsAPI{data: data})
+	defer srv.Close()
+	cli, err := client.NewClientWithOpts(client.WithHost("tcp://"+srv.Listener.Addr().String()), client.WithVersion("1.45"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cli.Close()
+
+	// The cursor as it's stored: the line "processed" is the first one at the boundary
+	saved := models.LogCursor{ContainerID: "resume", UnixNano: boundary.UnixNano(), Seq: 0}
+	managers.Cursors.Set(saved.ContainerID, saved.Time(), saved.Seq)
+
+	var got []string
+	s := &LogScanner{
+		Client:    cli,
+		Container: docker.ContainerInfo{ID: "resume", Name: "resume"},
+		OnLog: func(_ docker.ContainerInfo, e loganalyzer.LogEntry) {
+			got = append(got, e.Text)
+		},
+	}
+	if err := s.Start(context.Background()); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+
+	want := []string{"next at the same time", "later"}
+	if !slices.Equal(got, want) {
+		t.Errorf("emitted %q, want %q", got, want)
+	}
+	if c, _ := managers.Cursors.Get("resume"); !c.Time().Equal(boundary.Add(time.Second)) || c.Seq != 0 {
+		t.Errorf("cursor = %s #%d, want the last line", c.Time().Format(time.RFC3339Nano), c.Seq)
+	}
+}
@@ -6,22 +6,70 @@ import (
 
 	"github.com/docker/docker/client"
 	"github.com/ilyxenc/rattle/internal/docker"
+	"github.com/ilyxenc/rattle/internal/loganalyzer"
 )
 
-// OnLogFunc is the type for a log line analyzer callback
-type OnLogFunc func(container docker.ContainerInfo, line string)
+// OnLogFunc is the type for a log event analyzer callback. The entry is a single line or a group of lines (e.g. stack trace)
+type OnLogFunc func(container docker.ContainerInfo, entry loganalyzer.LogEntry)
 
 // LogScanner streams logs from a specific Docker container
 //
-// It connects to the container's stdout and stderr streams starting from a given timestamp (`Since`).
-// For each log line, it calls the `OnLog` callback. If the log stream is interrupted,
+// It connects to the container's stdout and stderr streams (kept apart unless the container uses a TTY) starting from the saved cursor or a given timestamp (`Since`).
+// Lines are grouped into events by the multiline aggregator, and for each event it calls the `OnLog` callback. If the log stream is interrupted,
 // it automatically retries connecting with a specified delay and retry limit
 type LogScanner struct {
 	Client         *client.Client       // Docker client used to access container logs
 	Container      docker.ContainerInfo // Container info like id, name etc
-	OnLog          OnLogFunc            // Callback function invoked for each log line received
-	Since          time.Time            // Since specifies the starting point for reading container logs. It helps avoid processing old logs or duplicating entries after reconnects
+	OnLog          OnLogFunc            // Callback function invoked for each log event received
+	Since          time.Time            // Since specifies the starting point for reading container logs if there is no saved cursor for the container
 	ReconnectDelay time.Duration        // Delay between reconnection attempts when the log stream fails
 	MaxRetry       int                  // Number of times to retry connecting to the log stream before giving up. A value of 0 means unlimited retries
+	TTY            bool                 // Container was started with a TTY, so its logs are raw instead of multiplexed stdout/stderr. Detected on Start
 	Cancel         context.CancelFunc   // Cancel function to stop log streaming
+
+	cursor    logPosition    // Position of the last processed line, used to resume after reconnects and restarts
+	lastLine  time.Time      // When the last line was received, used to detect idle containers
+	idle      bool           // Container is quiet for longer than its idle threshold and was reported
+	idleLabel *time.Duration // Idle threshold from the container label, parsed on Start. Nil if the label is missing or invalid
+}
+
+// logPosition identifies a line in the container log stream by its Docker timestamp.
+// Lines with the same timestamp are told apart by their index `Seq`
+type logPosition struct {
+	Time time.Time
+	Seq  int
+}
+
+// after reports whether the position p comes after o in the log stream
+func (p logPosition) after(o logPosition) bool {
+	if p.Time.Equal(o.Time) {
+		return p.Seq > o.Seq
+	}
+	return p.Time.After(o.Time)
+}
+
+// prev returns a position right before p: lines at p and after it come after it
+func (p logPosition) prev() logPosition {
+	return logPosition{Time: p.Time, Seq: p.Seq - 1}
+}
+
+// streamLine is a raw line as read from one of the container streams
+type streamLine struct {
+	Text   string
+	Stream string // models.StreamStdout / StreamStderr
+}
+
+// logLine is a single line read from the container log stream
+type logLine struct {
+	Text   string
+	Stream string // models.StreamStdout / StreamStderr
+	Pos    logPosition
+}
+
+// logEvent is a single line or a group of lines (e.g. stack trace) from one stream to be analyzed
+type logEvent struct {
+	Text   string
+	Stream string      // models.StreamStdout / StreamStderr
+	Time   time.Time   // Timestamp of the first line in the event
+	Pos    logPosition // Position of the last line in the event
 }
@@ -1,7 +1,10 @@
 package scanner
 
 import (
+	"context"
+	"regexp"
 	"strings"
+	"time"
 
 	"github.com/ilyxenc/rattle/internal/docker"
 	"github.com/ilyxenc/rattle/internal/managers"
@@ -14,6 +17,63 @@ func cleanLine(line string) string {
 	return strings.TrimLeft(line, " \t\u00A0\u200B\u202F")      // Leading junk
 }
 
+// ansiEscape matches terminal escape sequences: CSI (colors, cursor movement), OSC (window title, links) and other two-byte sequences
+var ansiEscape = regexp.MustCompile(`\x1b\[[0-?]*[ -/]*[@-~]|\x1b\][^\x07\x1b]*(\x07|\x1b\\)|\x1b[@-Z\\-_]`)
+
+// stripTerminalControls removes ANSI escape sequences and collapses carriage-return progress bars
+// to their final state (e.g. "10%\r50%\r100%" becomes "100%")
+func stripTerminalControls(line string) string {
+	if strings.ContainsRune(line, '\x1b') {
+		line = ansiEscape.ReplaceAllString(line, "")
+	}
+
+	line = strings.TrimRight(line, "\r")
+	if i := strings.LastIndexByte(line, '\r'); i >= 0 {
+		line = line[i+1:]
+	}
+
+	return line
+}
+
+// resetTimer safely resets the timer to fire after d, draining it if needed
+func resetTimer(t *time.Timer, d time.Duration) {
+	if !t.Stop() {
+		select {
+		case <-t.C:
+		default:
+		}
+	}
+	t.Reset(d)
+}
+
+// sleepCtx waits for the duration or until the context is cancelled. Returns false if the context was cancelled
+func sleepCtx(ctx context.Context, d time.Duration) bool {
+	t := time.NewTimer(d)
+	defer t.Stop()
+
+	select {
+	case <-ctx.Done():
+		return false
+	case <-t.C:
+		return true
+	}
+}
+
+// nextFlush returns the time left until the earliest pending event of the aggregators must be emitted
+func nextFlush(aggs map[string]*MultilineAggregator) (time.Duration, bool) {
+	var earliest time.Time
+	for _, agg := range aggs {
+		if agg.Pending() && (earliest.IsZero() || agg.Deadline().Before(earliest)) {
+			earliest = agg.Deadline()
+		}
+	}
+
+	if earliest.IsZero() {
+		return 0, false
+	}
+	return max(time.Until(earliest), 0), true
+}
+
 // shouldIgnoreContainer determines whether a container should be excluded from scanning, based on the current filtering mode (whitelist or blacklist) and matching rules
 //
 // Matching logic:
@@ -0,0 +1,223 @@
+package telegram
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ilyxenc/rattle/internal/config"
+	"github.com/ilyxenc/rattle/internal/docker"
+	"github.com/ilyxenc/rattle/internal/models"
+	"github.com/ilyxenc/rattle/internal/notify"
+)
+
+// Actions of buttons under alerts, sent as "<action>:<token>" in callback data
+const (
+	ActionMute = "mute"
+	ActionLogs = "logs"
+	ActionAck  = "ack"
+)
+
+const (
+	alertTTL  = 24 * time.Hour // How long buttons of an alert keep working
+	maxAlerts = 2000           // Alerts kept at most, the oldest are dropped first
+	LogLines  = 50             // Lines shown by the logs button
+	MuteFor   = time.Hour      // How long the mute button mutes the alert
+)
+
+// Alert is a sent alert with buttons, kept to answer presses of its buttons
+type Alert struct {
+	Container   docker.ContainerInfo
+	Fingerprint string // Empty if the alert can't be muted by fingerprint
+	Text        string // MarkdownV2 text of the message with the buttons
+	Caption     bool   // Text is the caption of a document
+	AckedBy     string // Who acknowledged the alert, empty if nobody yet
+	created     time.Time
+	acking      bool // The message is being edited to show an acknowledgement
+}
+
+// alertStore keeps alerts by the token in callback data of their buttons.
+// Callback data is limited to 64 bytes, so alerts are kept in memory and expire after alertTTL
+type alertStore struct {
+	mu     sync.Mutex
+	alerts map[string]*Alert
+}
+
+var alerts = &alertStore{alerts: make(map[string]*Alert)}
+
+// add stores the alert and returns its token
+func (s *alertStore) add(a Alert) string {
+	buf := make([]byte, 8)
+	_, _ = rand.Read(buf)
+	token := hex.EncodeToString(buf)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	a.created = time.Now()
+	s.prune(a.created)
+	s.alerts[token] = &a
+	return token
+}
+
+// prune removes expired alerts and the oldest ones over maxAlerts. Must be called with the lock held
+func (s *alertStore) prune(now time.Time) {
+	for token, a := range s.alerts {
+		if now.Sub(a.created) > alertTTL {
+			delete(s.alerts, token)
+		}
+	}
+
+	for len(s.alerts) >= maxAlerts {
+		oldest := ""
+		for token, a := range s.alerts {
+			if oldest == "" || a.created.Before(s.alerts[oldest].created) {
+				oldest = token
+			}
+		}
+		delete(s.alerts, oldest)
+	}
+}
+
+// LookupAlert returns the alert of a button. False if it's unknown or expired, e.g. after a restart
+func LookupAlert(token string) (Alert, bool) {
+	alerts.mu.Lock()
+	defer alerts.mu.Unlock()
+
+	a, ok := alerts.alerts[token]
+	if !ok || time.Since(a.created) > alertTTL {
+		return Alert{}, false
+	}
+	return *a, true
+}
+
+// ParseCallback splits callback data of an alert button into the action and the alert token
+func ParseCallback(data string) (action, token string, ok bool) {
+	action, token, ok = strings.Cut(data, ":")
+	return action, token, ok && token != ""
+}
+
+// hasActions reports whether the notification gets buttons: error alerts about a container,
+// sent by the bot from config, which is the one receiving button presses
+func (tn *Notifier) hasActions(n notify.Notification) bool {
+	if !config.Cfg.Bot.Enabled || tn.baseURL != baseURL || !notify.HasContainer(n) {
+		return false
+	}
+
+	severity := notify.Severity(n)
+	return severity == models.EventTypeError || severity == models.EventTypeCritical
+}
+
+// actions stores the alert and returns the keyboard for the message with `text`, or empty if it gets no buttons
+func (tn *Notifier) actions(n notify.Notification, text string, caption bool) string {
+	if !tn.hasActions(n) {
+		return ""
+	}
+
+	a := Alert{
+		Container:   n.Container,
+		Fingerprint: n.Fingerprint,
+		Text:        text,
+		Caption:     caption,
+	}
+	return alertKeyboard(alerts.add(a), a)
+}
+
+// alertKeyboard returns the inline keyboard of the alert as JSON for reply_markup
+func alertKeyboard(token string, a Alert) string {
+	type button struct {
+		Text         string `json:"text"`
+		CallbackData string `json:"callback_data"`
+	}
+
+	var row []button
+	if a.Fingerprint != "" {
+		row = append(row, button{Text: "🔇 Mute " + notify.FormatDuration(MuteFor), CallbackData: ActionMute + ":" + token})
+	}
+	row = append(row, button{Text: fmt.Sprintf("📜 Last %d lines", LogLines), CallbackData: ActionLogs + ":" + token})
+
+	ack := button{Text: "✅ Acknowledge", CallbackData: ActionAck + ":" + token}
+	if a.AckedBy != "" {
+		ack.Text = "✅ Acknowledged by " + a.AckedBy
+	}
+
+	markup, _ := json.Marshal(map[string][][]button{"inline_keyboard": {row, {ack}}})
+	return string(markup)
+}
+
+// Acknowledge marks the alert as acknowledged by `by` and edits its message to show it.
+// The alert counts as acknowledged only once the message is edited, so a failed edit can be retried.
+// Returns false if somebody acknowledged it already or is acknowledging it right now
+func Acknowledge(ctx context.Context, chatID int64, messageID int, token, by string) (bool, error) {
+	alerts.mu.Lock()
+	a, ok := alerts.alerts[token]
+	if !ok || a.AckedBy != "" || a.acking {
+		alerts.mu.Unlock()
+		return false, nil
+	}
+	a.acking = true
+	acked := *a
+	alerts.mu.Unlock()
+	acked.AckedBy = by
+
+	method, field, limit := "editMessageText", "text", maxMessageLen
+	if acked.Caption {
+		method, field, limit = "editMessageCaption", "caption", maxCaptionLen
+	}
+
+	// The button shows who acknowledged the alert if the note doesn't fit into the message
+	text := acked.Text
+	note := fmt.Sprintf("\n\n✅ Acknowledged by %s at %s", escapeMarkdownV2(by), escapeMarkdownV2(time.Now().Format("15:04")))
+	if textLen(text+note) <= limit {
+		text += note
+	}
+
+	err := Call(ctx, method, map[string]string{
+		"chat_id":      strconv.FormatInt(chatID, 10),
+		"message_id":   strconv.Itoa(messageID),
+		field:          text,
+		"parse_mode":   "MarkdownV2",
+		"reply_markup": alertKeyboard(token, acked),
+	}, nil)
+
+	alerts.mu.Lock()
+	if a, ok := alerts.alerts[token]; ok {
+		a.acking = false
+		if err == nil {
+			a.AckedBy = by
+		}
+	}
+	alerts.mu.Unlock()
+
+	return true, err
+}
+
+// ReplyLogs sends recent output of the container to the chat and forum topic a button was pressed in.
+// Output that doesn't fit into a message is sent as a file
+func ReplyLogs(chatID string, threadID int, ci docker.ContainerInfo, logs string) error {
+	title := fmt.Sprintf("📜 *Last %d lines:* `%s`", LogLines, escapeMarkdownV2(ci.Name))
+	if strings.TrimSpace(logs) == "" {
+		return Reply(chatID, threadID, title+"\n\n_No output_")
+	}
+
+	tn := NewNotifier(chatID, "")
+	logs = cleanUTF8(logs)
+
+	msg := title + formatMessage("log", logs)
+	if textLen(msg) <= maxMessageLen {
+		return tn.send(msg, threadID, "")
+	}
+
+	name := attachmentName(notify.Notification{Container: ci})
+	return scheduler.Submit(chatID, func() error {
+		return tn.withThreadFallback(threadID, func(threadID int) error {
+			return tn.sendDocument(name, logs, title, threadID, "")
+		})
+	})
+}
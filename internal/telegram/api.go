@@ -0,0 +1,49 @@
+package telegram
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// result is the envelope of a successful Bot API response
+type result struct {
+	OK     bool            `json:"ok"`
+	Result json.RawMessage `json:"result"`
+}
+
+// Call calls a Bot API method of the bot from config and decodes its result into `out`, if not nil
+func Call(ctx context.Context, method string, params map[string]string, out any) error {
+	resp, err := client.R().
+		SetContext(ctx).
+		SetFormData(params).
+		Post(baseURL + "/" + method)
+
+	if err := checkResponse(resp, err); err != nil {
+		return err
+	}
+
+	var res result
+	if err := json.Unmarshal(resp.Body(), &res); err != nil {
+		return fmt.Errorf("failed to decode %s response: %w", method, err)
+	}
+	if !res.OK {
+		return errors.New(method + " failed: " + resp.String())
+	}
+
+	if out == nil {
+		return nil
+	}
+	return json.Unmarshal(res.Result, out)
+}
+
+// Reply queues a MarkdownV2 message for the chat and forum topic a command came from and waits until it's sent
+func Reply(chatID string, threadID int, msg string) error {
+	return NewNotifier(chatID, "").send(msg, threadID, "")
+}
+
+// Escape escapes special MarkdownV2 characters
+func Escape(text string) string {
+	return escapeMarkdownV2(text)
+}
@@ -0,0 +1,159 @@
+package telegram
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/ilyxenc/rattle/internal/config"
+	"github.com/ilyxenc/rattle/internal/notify"
+)
+
+// Bot API limits of message text
+const (
+	maxMessageLen = 4096 // Characters in a message
+	maxCaptionLen = 1024 // Characters in a document caption
+	maxParts      = 10   // Messages a long notification is split into at most
+)
+
+// overflowTruncate cuts log text that doesn't fit into a single message, see config.Messages
+const overflowTruncate = "truncate"
+
+// ellipsis marks log text that was cut
+const ellipsis = "…"
+
+// markdownV2Special are characters escaped by escapeMarkdownV2
+const markdownV2Special = "\\_*[]()~`>#+-=|{}.!"
+
+// unsafeFileChars are replaced in names of attached log files
+var unsafeFileChars = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// renderMessages formats the notification as one or more MarkdownV2 messages within the message limit.
+// Long log text is split or truncated, see config.Messages
+func renderMessages(n notify.Notification) []string {
+	msg := RenderNotification(n)
+	if textLen(msg) <= maxMessageLen || n.Details == "" {
+		return []string{msg}
+	}
+
+	if config.Cfg.Messages.Overflow == overflowTruncate {
+		return []string{renderTruncated(n, maxMessageLen)}
+	}
+	return renderParts(n)
+}
+
+// needsAttachment reports whether the log text of the notification is long enough to be sent as a file
+func needsAttachment(n notify.Notification) bool {
+	threshold := config.Cfg.Messages.AttachThreshold
+	return threshold > 0 && utf8.RuneCountInString(n.Details) > threshold
+}
+
+// renderTruncated formats the notification with its log text cut to fit into `limit`
+func renderTruncated(n notify.Notification, limit int) string {
+	budget := limit - textLen(renderWithDetails(n, "")) - textLen(ellipsis)
+
+	head, rest := cutEscaped(cleanUTF8(n.Details), budget)
+	if rest != "" {
+		head += ellipsis
+	}
+	return renderWithDetails(n, head)
+}
+
+// renderParts formats the notification as a message with the beginning of the log text
+// followed by messages with the rest of it
+func renderParts(n notify.Notification) []string {
+	details := cleanUTF8(n.Details)
+
+	// Leave room for the marker and two-digit part numbers
+	firstBudget := maxMessageLen - textLen(renderWithDetails(n, "")) - textLen(ellipsis)
+	nextBudget := maxMessageLen - textLen(formatContinued(n, 99, 99, "")) - textLen(ellipsis)
+
+	chunks := make([]string, 0, 2)
+	head, rest := cutEscaped(details, firstBudget)
+	chunks = append(chunks, head)
+	for rest != "" && len(chunks) < maxParts {
+		head, rest = cutEscaped(rest, nextBudget)
+		chunks = append(chunks, head)
+	}
+
+	// Mark every cut, including the end of text that didn't fit into maxParts
+	for i := range chunks {
+		if i < len(chunks)-1 || rest != "" {
+			chunks[i] += ellipsis
+		}
+	}
+
+	msgs := make([]string, 0, len(chunks))
+	msgs = append(msgs, renderWithDetails(n, chunks[0]))
+	for i, chunk := range chunks[1:] {
+		msgs = append(msgs, formatContinued(n, i+2, len(chunks), chunk))
+	}
+	return msgs
+}
+
+// formatContinued returns a message with a part of the log text that didn't fit into the first message
+func formatContinued(n notify.Notification, part, total int, chunk string) string {
+	title := fmt.Sprintf("➕ *Continued %d/%d:* `%s`", part, total, escapeMarkdownV2(n.Container.Name))
+	return title + formatMessage(n.EventType, chunk)
+}
+
+// renderWithDetails formats the notification with `details` as its log text
+func renderWithDetails(n notify.Notification, details string) string {
+	n.Details = details
+	return RenderNotification(n)
+}
+
+// attachmentName returns the file name of the attached log text
+func attachmentName(n notify.Notification) string {
+	name := unsafeFileChars.ReplaceAllString(n.Container.Name, "_")
+	if name == "" {
+		name = "rattle"
+	}
+	return fmt.Sprintf("%s-%s.log", name, notify.EventTime(n).UTC().Format("20060102-150405"))
+}
+
+// cutEscaped splits the text so that the head takes at most `budget` characters after MarkdownV2 escaping.
+// Prefers to cut after a line break in the second half of the head
+func cutEscaped(text string, budget int) (head, rest string) {
+	if budget <= 0 {
+		return "", text
+	}
+
+	used, lastBreak := 0, -1
+	for i, r := range text {
+		cost := runeLen(r)
+		if strings.ContainsRune(markdownV2Special, r) {
+			cost++
+		}
+		if used+cost > budget {
+			if lastBreak > i/2 {
+				return text[:lastBreak], text[lastBreak:]
+			}
+			return text[:i], text[i:]
+		}
+		used += cost
+		if r == '\n' {
+			lastBreak = i + 1
+		}
+	}
+	return text, ""
+}
+
+// textLen returns the length of a message as counted by Telegram limits (UTF-16 code units).
+// Markup and escapes are counted too, so the result is an upper bound
+func textLen(s string) int {
+	n := 0
+	for _, r := range s {
+		n += runeLen(r)
+	}
+	return n
+}
+
+// runeLen returns the number of UTF-16 code units of the rune
+func runeLen(r rune) int {
+	if r > 0xFFFF {
+		return 2
+	}
+	return 1
+}
@@ -4,58 +4,49 @@ import (
 	"fmt"
 
 	"github.com/ilyxenc/rattle/internal/config"
-	"github.com/ilyxenc/rattle/internal/docker"
+	"github.com/ilyxenc/rattle/internal/notify"
 )
 
-// NotificationType defines the type of event being reported to Telegram
-type NotificationType string
-
-const (
-	NotificationContainerStart         NotificationType = "container_start"           // Sent when a container starts
-	NotificationContainerStop          NotificationType = "container_stop"            // Sent when a container stops normally
-	NotificationLogEvent               NotificationType = "log_event"                 // Sent when an event is detected in logs
-	NotificationContainerStopWithError NotificationType = "container_stop_with_error" // Sent when a container stops unexpectedly with error
-	NotificationShutDownRattle         NotificationType = "shut_down_rattle"          // Sent when Rattle is shutting down
-	NotificationStartedRattle          NotificationType = "started_rattle"            // Sent when Rattle starts
-	NotificationContainersSummary      NotificationType = "containers_summary"        // Sent when Rattle starts and find containers
-)
-
-// Notification represents the structure of a message to be sent to Telegram
-type Notification struct {
-	Type       NotificationType       // The type of event
-	EventType  string                 // error, info, success, warning, critical
-	Title      string                 // Notification template title
-	Details    string                 // Optional details (e.g., error message or log content)
-	Container  docker.ContainerInfo   // Metadata about the container related to the event
-	Containers []docker.ContainerInfo // For summary events like containers list
-}
-
-// Notify sends a formatted notification to the configured Telegram chat
-func Notify(n Notification) {
-	msg := RenderNotification(n)
-	SendPlainText(msg)
-}
-
 // RenderNotification formats a notification message based on its type
-func RenderNotification(n Notification) string {
+func RenderNotification(n notify.Notification) string {
 	c := n.Container
 
 	switch n.Type {
-	case NotificationLogEvent:
+	case notify.NotificationLogEvent:
 		title := FormatEventTitle(n.EventType, escapeMarkdownV2(n.Container.Name))
-		return title + formatMessage(n.EventType, n.Details) + formatMeta(c)
-	case NotificationContainerStart:
-		return fmt.Sprintf("✅ *Container started:* `%s`", c.Name) + formatMeta(c)
-	case NotificationContainerStop:
-		return fmt.Sprintf("🛑 *Container stopped:* `%s`", c.Name) + formatMeta(c)
-	case NotificationContainerStopWithError:
-		return fmt.Sprintf("🛑 *Container stopped with error:* `%s`", c.Name) + formatMeta(c)
-	case NotificationShutDownRattle:
+		return title + formatMessage(n.EventType, n.Details) + formatFields(n.Fields) + formatStream(n.Stream) + formatMeta(c, n.Time)
+	case notify.NotificationLogRepeated:
+		title := fmt.Sprintf("🔁 *Repeated %d times in %s:* `%s`", n.Count, escapeMarkdownV2(notify.FormatDuration(n.Window)), escapeMarkdownV2(n.Container.Name))
+		return title + formatMessage(n.EventType, n.Details) + formatMeta(c, n.Time)
+	case notify.NotificationThreshold:
+		title := fmt.Sprintf("%s *%d matches in %s:* `%s`", notify.EventEmoji(n.EventType), n.Count, escapeMarkdownV2(notify.FormatDuration(n.Window)), escapeMarkdownV2(n.Container.Name))
+		return title + formatRule(n.Rule) + formatMessage(n.EventType, n.Details) + formatStream(n.Stream) + formatMeta(c, n.Time)
+	case notify.NotificationAbsence:
+		title := fmt.Sprintf("%s *No expected log line for %s:* `%s`", notify.EventEmoji(n.EventType), escapeMarkdownV2(notify.FormatDuration(n.Window)), escapeMarkdownV2(n.Container.Name))
+		return title + formatRule(n.Rule) + formatMeta(c, n.Time)
+	case notify.NotificationAbsenceRecovered:
+		title := fmt.Sprintf("✅ *Expected log line is back after %s:* `%s`", escapeMarkdownV2(notify.FormatDuration(n.Window)), escapeMarkdownV2(n.Container.Name))
+		return title + formatRule(n.Rule) + formatMessage("success", n.Details) + formatMeta(c, n.Time)
+	case notify.NotificationContainerIdle:
+		return fmt.Sprintf("💤 *No output for %s:* `%s`", escapeMarkdownV2(notify.FormatDuration(n.Window)), escapeMarkdownV2(c.Name)) + formatMeta(c, n.Time)
+	case notify.NotificationContainerResumed:
+		return fmt.Sprintf("🔊 *Output resumed after %s:* `%s`", escapeMarkdownV2(notify.FormatDuration(n.Window)), escapeMarkdownV2(c.Name)) + formatMeta(c, n.Time)
+	case notify.NotificationContainerStart:
+		return fmt.Sprintf("✅ *Container started:* `%s`", c.Name) + formatMeta(c, n.Time)
+	case notify.NotificationContainerStop:
+		return fmt.Sprintf("🛑 *Container stopped:* `%s`", c.Name) + formatMeta(c, n.Time)
+	case notify.NotificationContainerStopWithError:
+		return fmt.Sprintf("🛑 *Container stopped with error:* `%s`", c.Name) + formatMeta(c, n.Time)
+	case notify.NotificationShutDownRattle:
 		return fmt.Sprintf("🛑 *Rattle is shutting down%s*", escapeMarkdownV2("..."))
-	case NotificationStartedRattle:
+	case notify.NotificationStartedRattle:
 		return fmt.Sprintf("🚀 Rattle started in *%s* mode", config.Cfg.Env)
-	case NotificationContainersSummary:
+	case notify.NotificationContainersSummary:
 		return formatContainersSummary(n.Containers)
+	case notify.NotificationEventsLost:
+		return "⚠️ *Docker events stream lost*\n\nNew containers won't be scanned until it's restored" + formatMessage("error", n.Details)
+	case notify.NotificationEventsRestored:
+		return "🔄 *Docker events stream restored*\n\n" + escapeMarkdownV2(n.Details)
 	default:
 		return "📦 Unknown notification type"
 	}
@@ -0,0 +1,232 @@
+package telegram
+
+import (
+	"errors"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ilyxenc/rattle/internal/logger"
+	"github.com/ilyxenc/rattle/internal/notify"
+)
+
+// Telegram Bot API limits, see https://core.telegram.org/bots/faq#my-bot-is-hitting-limits-how-do-i-avoid-this
+const (
+	chatInterval  = time.Second // At most one message per second to a single chat
+	groupLimit    = 20          // At most 20 messages...
+	groupWindow   = time.Minute // ...per minute to a group
+	globalLimit   = 30          // At most 30 messages...
+	globalWindow  = time.Second // ...per second across all chats
+	maxInlineWait = time.Minute // Longer waits are returned to the caller as notify.RetryAfterError
+	maxRateLimits = 5           // 429 responses tolerated per message before giving up
+	statsInterval = time.Minute // How often a non-empty queue is reported
+)
+
+// chatQueue holds messages waiting to be sent to a single chat
+type chatQueue struct {
+	jobs    []*sendJob
+	nextAt  time.Time   // Earliest time of the next message by the per-chat limit
+	blocked time.Time   // Set by retry_after of a 429 response
+	recent  []time.Time // Send times within the group window, only for groups
+	running bool        // A worker goroutine is draining the queue
+}
+
+// sendJob is a message queued for a chat
+type sendJob struct {
+	send func() error
+	done chan error
+}
+
+// Scheduler sends messages to Telegram chats in order, within per-chat, per-group and global limits.
+// Each chat with queued messages is served by its own goroutine
+type Scheduler struct {
+	mu     sync.Mutex
+	chats  map[string]*chatQueue // Key = chat ID
+	recent []time.Time           // Send times within the global window
+
+	now   func() time.Time    // Clock, replaced in tests
+	sleep func(time.Duration) // Waits for a free slot, replaced in tests
+}
+
+// scheduler is the global send scheduler for all bots
+var scheduler = newScheduler()
+
+// newScheduler creates a scheduler using the system clock
+func newScheduler() *Scheduler {
+	return &Scheduler{
+		chats: make(map[string]*chatQueue),
+		now:   time.Now,
+		sleep: time.Sleep,
+	}
+}
+
+// QueueStats describes messages waiting to be sent
+type QueueStats struct {
+	Total int            // Messages queued or being sent
+	Chats map[string]int // Key = chat ID
+}
+
+// Stats returns the current queue depth of the Telegram scheduler
+func Stats() QueueStats {
+	return scheduler.Stats()
+}
+
+// Stats returns the current queue depth per chat
+func (s *Scheduler) Stats() QueueStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stats := QueueStats{Chats: make(map[string]int)}
+	for chatID, q := range s.chats {
+		if len(q.jobs) == 0 {
+			continue
+		}
+		stats.Chats[chatID] = len(q.jobs)
+		stats.Total += len(q.jobs)
+	}
+	return stats
+}
+
+// Submit queues `send` for the chat and waits until it's done.
+// `send` returns *rateLimitError on 429 to be retried after the given delay.
+// Callers waiting on Submit must not be on the scanning path: notifications reach it through the outbox worker
+func (s *Scheduler) Submit(chatID string, send func() error) error {
+	job := &sendJob{send: send, done: make(chan error, 1)}
+
+	s.mu.Lock()
+	q, ok := s.chats[chatID]
+	if !ok {
+		q = &chatQueue{}
+		s.chats[chatID] = q
+	}
+	q.jobs = append(q.jobs, job)
+	if !q.running {
+		q.running = true
+		go s.drain(chatID, q)
+	}
+	s.mu.Unlock()
+
+	return <-job.done
+}
+
+// drain sends queued messages of the chat one by one until the queue is empty
+func (s *Scheduler) drain(chatID string, q *chatQueue) {
+	for {
+		s.mu.Lock()
+		if len(q.jobs) == 0 {
+			q.running = false
+			s.mu.Unlock()
+			return
+		}
+		job := q.jobs[0]
+		s.mu.Unlock()
+
+		job.done <- s.run(chatID, q, job)
+
+		s.mu.Lock()
+		q.jobs = q.jobs[1:]
+		s.mu.Unlock()
+	}
+}
+
+// run sends a single message, waiting for free slots and retrying on 429
+func (s *Scheduler) run(chatID string, q *chatQueue, job *sendJob) error {
+	for rateLimits := 0; ; {
+		if err := s.reserve(chatID, q); err != nil {
+			return err
+		}
+
+		err := job.send()
+
+		var rle *rateLimitError
+		if !errors.As(err, &rle) {
+			return err
+		}
+
+		rateLimits++
+		s.mu.Lock()
+		q.blocked = s.now().Add(rle.After)
+		s.mu.Unlock()
+
+		logger.Log.Warnf("Telegram rate limit for chat %s, retrying after %s", chatID, rle.After)
+		if rateLimits == maxRateLimits {
+			return &notify.RetryAfterError{After: rle.After, Err: err}
+		}
+	}
+}
+
+// reserve waits until the message may be sent to the chat and records the send.
+// Returns notify.RetryAfterError if the wait is longer than maxInlineWait
+func (s *Scheduler) reserve(chatID string, q *chatQueue) error {
+	group := strings.HasPrefix(chatID, "-")
+
+	for {
+		s.mu.Lock()
+		now := s.now()
+		s.recent = prune(s.recent, now.Add(-globalWindow))
+		q.recent = prune(q.recent, now.Add(-groupWindow))
+
+		at := maxTime(q.nextAt, q.blocked)
+		if len(s.recent) >= globalLimit {
+			at = maxTime(at, s.recent[len(s.recent)-globalLimit].Add(globalWindow))
+		}
+		if group && len(q.recent) >= groupLimit {
+			at = maxTime(at, q.recent[len(q.recent)-groupLimit].Add(groupWindow))
+		}
+
+		wait := at.Sub(now)
+		if wait <= 0 {
+			s.recent = append(s.recent, now)
+			if group {
+				q.recent = append(q.recent, now)
+			}
+			q.nextAt = now.Add(chatInterval)
+			s.mu.Unlock()
+			return nil
+		}
+		s.mu.Unlock()
+
+		if wait > maxInlineWait {
+			return &notify.RetryAfterError{After: wait, Err: errors.New("telegram chat is rate limited")}
+		}
+		s.sleep(wait)
+	}
+}
+
+// startStatsReporter periodically logs the queue depth while messages are waiting
+func (s *Scheduler) startStatsReporter(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			stats := s.Stats()
+			if stats.Total == 0 {
+				continue
+			}
+
+			chats := make([]string, 0, len(stats.Chats))
+			for chatID, n := range stats.Chats {
+				chats = append(chats, chatID+"="+strconv.Itoa(n))
+			}
+			sort.Strings(chats)
+			logger.Log.Infof("Telegram send queue: %d messages (%s)", stats.Total, strings.Join(chats, ", "))
+		}
+	}()
+}
+
+// prune drops times before `since` from the sorted slice
+func prune(times []time.Time, since time.Time) []time.Time {
+	i := sort.Search(len(times), func(i int) bool { return times[i].After(since) })
+	return times[i:]
+}
+
+// maxTime returns the later of two times
+func maxTime(a, b time.Time) time.Time {
+	if a.After(b) {
+		return a
+	}
+	return b
+}
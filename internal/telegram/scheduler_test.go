@@ -0,0 +1,166 @@
+package telegram
+
+import (
+	"errors"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ilyxenc/rattle/internal/notify"
+)
+
+// fakeClock is a clock that only moves when the scheduler sleeps
+type fakeClock struct {
+	mu    sync.Mutex
+	t     time.Time
+	slept []time.Duration
+}
+
+func (c *fakeClock) now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.t
+}
+
+func (c *fakeClock) sleep(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.t = c.t.Add(d)
+	c.slept = append(c.slept, d)
+}
+
+var start = time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+
+// newTestScheduler returns a scheduler on a fake clock
+func newTestScheduler() (*Scheduler, *fakeClock) {
+	clock := &fakeClock{t: start}
+	s := newScheduler()
+	s.now = clock.now
+	s.sleep = clock.sleep
+	return s, clock
+}
+
+// submit sends a message to the chat and returns the offset from start at which it was sent
+func submit(t *testing.T, s *Scheduler, clock *fakeClock, chatID string) time.Duration {
+	t.Helper()
+
+	var at time.Duration
+	err := s.Submit(chatID, func() error {
+		at = clock.now().Sub(start)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Submit(%s) error = %v", chatID, err)
+	}
+	return at
+}
+
+func TestChatInterval(t *testing.T) {
+	s, clock := newTestScheduler()
+
+	for i, want := range []time.Duration{0, time.Second, 2 * time.Second} {
+		if at := submit(t, s, clock, "42"); at != want {
+			t.Errorf("message %d sent at +%s, want +%s", i, at, want)
+		}
+	}
+
+	// Other chats aren't held back by the chat interval
+	if at := submit(t, s, clock, "43"); at != 2*time.Second {
+		t.Errorf("message to another chat sent at +%s, want +2s", at)
+	}
+}
+
+func TestGroupLimit(t *testing.T) {
+	s, clock := newTestScheduler()
+
+	var at time.Duration
+	for i := range groupLimit + 1 {
+		at = submit(t, s, clock, "-100")
+		if i < groupLimit && at != time.Duration(i)*chatInterval {
+			t.Fatalf("message %d sent at +%s, want +%s", i, at, time.Duration(i)*chatInterval)
+		}
+	}
+	if at != groupWindow {
+		t.Errorf("message %d to the group sent at +%s, want +%s", groupLimit+1, at, groupWindow)
+	}
+
+	// Private chats have no per-minute limit
+	s, clock = newTestScheduler()
+	for range groupLimit + 1 {
+		at = submit(t, s, clock, "100")
+	}
+	if want := groupLimit * chatInterval; at != want {
+		t.Errorf("message %d to a private chat sent at +%s, want +%s", groupLimit+1, at, want)
+	}
+}
+
+func TestGlobalLimit(t *testing.T) {
+	s, clock := newTestScheduler()
+
+	for i := range globalLimit + 1 {
+		at := submit(t, s, clock, strconv.Itoa(i))
+		want := time.Duration(0)
+		if i == globalLimit {
+			want = globalWindow
+		}
+		if at != want {
+			t.Errorf("message to chat %d sent at +%s, want +%s", i, at, want)
+		}
+	}
+}
+
+func TestRateLimitRetried(t *testing.T) {
+	s, clock := newTestScheduler()
+
+	var sent []time.Duration
+	err := s.Submit("42", func() error {
+		sent = append(sent, clock.now().Sub(start))
+		if len(sent) == 1 {
+			return &rateLimitError{After: 3 * time.Second, Description: "Too Many Requests"}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sent) != 2 || sent[1] != 3*time.Second {
+		t.Errorf("sent at %v, want a retry after retry_after", sent)
+	}
+}
+
+func TestLongWaitReturnsRetryAfter(t *testing.T) {
+	s, clock := newTestScheduler()
+
+	calls := 0
+	err := s.Submit("42", func() error {
+		calls++
+		return &rateLimitError{After: 2 * maxInlineWait, Description: "Too Many Requests"}
+	})
+
+	var rae *notify.RetryAfterError
+	if !errors.As(err, &rae) || rae.After != 2*maxInlineWait {
+		t.Fatalf("Submit() error = %v, want RetryAfterError after %s", err, 2*maxInlineWait)
+	}
+	if calls != 1 || len(clock.slept) != 0 {
+		t.Errorf("sent %d times and slept %v, want the wait returned to the caller", calls, clock.slept)
+	}
+}
+
+func TestRateLimitsExhausted(t *testing.T) {
+	s, _ := newTestScheduler()
+
+	calls := 0
+	err := s.Submit("42", func() error {
+		calls++
+		return &rateLimitError{After: time.Second, Description: "Too Many Requests"}
+	})
+
+	var rae *notify.RetryAfterError
+	if !errors.As(err, &rae) {
+		t.Fatalf("Submit() error = %v, want RetryAfterError", err)
+	}
+	if calls != maxRateLimits {
+		t.Errorf("sent %d times, want %d", calls, maxRateLimits)
+	}
+}
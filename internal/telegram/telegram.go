@@ -1,14 +1,20 @@
 package telegram
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/go-resty/resty/v2"
 	"github.com/ilyxenc/rattle/internal/config"
 	"github.com/ilyxenc/rattle/internal/logger"
 	"github.com/ilyxenc/rattle/internal/managers"
+	"github.com/ilyxenc/rattle/internal/models"
+	"github.com/ilyxenc/rattle/internal/notify"
 )
 
 var client *resty.Client
@@ -23,40 +29,279 @@ func Init() {
 		SetRetryCount(5).                      // Number of retry attempts
 		SetRetryWaitTime(4 * time.Second).     // Minimum wait between retries
 		SetRetryMaxWaitTime(10 * time.Second). // Maximum wait time between retries
+		SetTimeout(30 * time.Second).          // Keep a hanging request from blocking the chat queue
+		SetRetryResetReaders(true).            // Rewind attached files before retries
 		AddRetryCondition(func(r *resty.Response, err error) bool {
-			// Retry on network errors or 5xx HTTP status codes
+			// Retry on network errors or 5xx HTTP status codes. 429 is handled by the scheduler
 			if err != nil {
 				return true
 			}
 			return r.StatusCode() >= 500
 		})
 
-	baseURL = fmt.Sprintf("https://api.telegram.org/bot%s", config.Cfg.BotToken)
+	baseURL = botURL(config.Cfg.BotToken)
+
+	scheduler.startStatsReporter(statsInterval)
 
 	logger.Log.Debugf("Telegram initialized for %d chats", len(managers.Chats.All()))
 }
 
-// SendPlainText sends a MarkdownV2-formatted text message to the configured Telegram chats
-func SendPlainText(msg string) {
-	msg = cleanUTF8(msg) // Sanitize message to ensure it's valid UTF-8
+// Notifier sends notifications to a single Telegram chat
+type Notifier struct {
+	name     string // Chat ID for chats, channel name for notification channels
+	chatID   string
+	baseURL  string         // Bot API URL of the bot sending to the chat
+	threadID int            // Default forum topic, 0 for none
+	topics   map[string]int // Forum topics by notification type or event type
+}
+
+// NewNotifier creates a notifier for the chat. Empty `botToken` means the bot from config
+func NewNotifier(chatID, botToken string) *Notifier {
+	url := baseURL
+	if botToken != "" {
+		url = botURL(botToken)
+	}
+
+	return &Notifier{
+		name:    chatID,
+		chatID:  chatID,
+		baseURL: url,
+	}
+}
+
+// botURL returns the Bot API URL of the bot with the token
+func botURL(token string) string {
+	return fmt.Sprintf("%s/bot%s", config.Cfg.BotAPIURL, token)
+}
+
+// NewChatNotifier creates a notifier for a chat stored in DB, with its forum topics
+func NewChatNotifier(chat models.Chat) *Notifier {
+	tn := NewNotifier(chat.ChatID, "")
+	tn.threadID = chat.MessageThreadID
+	tn.topics = chat.Topics
+	return tn
+}
+
+// channelConfig is the config of a Telegram notification channel
+type channelConfig struct {
+	ChatID          string         `json:"chat_id"`
+	BotToken        string         `json:"bot_token"`         // Optional, the bot from config is used if empty
+	MessageThreadID int            `json:"message_thread_id"` // Optional default forum topic
+	Topics          map[string]int `json:"topics"`            // Optional forum topics by notification type or event type
+}
+
+// NewChannelNotifier creates a notifier from a notification channel stored in DB
+func NewChannelNotifier(ch models.Channel) (notify.Notifier, error) {
+	var cfg channelConfig
+	if err := ch.Config.Decode(&cfg); err != nil {
+		return nil, err
+	}
+	if cfg.ChatID == "" {
+		return nil, errors.New("chat_id is required")
+	}
+	if cfg.MessageThreadID < 0 {
+		return nil, errors.New("message_thread_id must not be negative")
+	}
+	if err := ValidateTopics(cfg.Topics); err != nil {
+		return nil, err
+	}
+
+	tn := NewNotifier(cfg.ChatID, cfg.BotToken)
+	tn.name = ch.Name
+	tn.threadID = cfg.MessageThreadID
+	tn.topics = cfg.Topics
+	return tn, nil
+}
 
-	for _, chatID := range managers.Chats.All() {
-		resp, err := client.R().
-			SetQueryParams(map[string]string{
-				"chat_id":    chatID,
-				"text":       msg,
-				"parse_mode": "MarkdownV2", // Enables MarkdownV2 formatting
-			}).
-			SetHeader("Content-Type", "application/json").
-			Get(baseURL + "/sendMessage")
-
-		if err != nil {
-			logger.Log.Errorf("Failed to send Telegram message: %v", err)
-			return
+// Name identifies the chat in logs and the outbox
+func (tn *Notifier) Name() string {
+	return "telegram:" + tn.name
+}
+
+// Send formats the notification with MarkdownV2 and sends it to the chat, into the forum topic of the notification.
+// Long log text is split, truncated or attached as a file, see config.Messages
+func (tn *Notifier) Send(n notify.Notification) error {
+	_, err := tn.SendParts(n, 0)
+	return err
+}
+
+// SendParts sends the messages of the notification starting with part `from`,
+// so a retry doesn't repeat the parts already in the chat. Returns the number of parts sent so far
+func (tn *Notifier) SendParts(n notify.Notification, from int) (int, error) {
+	parts := tn.parts(n)
+	for i := from; i < len(parts); i++ {
+		if err := parts[i](); err != nil {
+			return i, err
 		}
+	}
+	return max(from, len(parts)), nil
+}
+
+// parts returns the sends of the messages the notification consists of, in order
+func (tn *Notifier) parts(n notify.Notification) []func() error {
+	threadID := tn.threadFor(n)
+
+	if needsAttachment(n) {
+		return tn.attachmentParts(n, threadID)
+	}
+
+	// Buttons go under the first message, the one with the summary
+	msgs := renderMessages(n)
+	parts := make([]func() error, 0, len(msgs))
+	for i, msg := range msgs {
+		parts = append(parts, func() error {
+			markup := ""
+			if i == 0 {
+				markup = tn.actions(n, msg, false)
+			}
+			return tn.send(msg, threadID, markup)
+		})
+	}
+	return parts
+}
+
+// attachmentParts returns the send of the log text as a .log file with a short summary as its caption.
+// If the summary doesn't fit into a caption, it's sent as a separate message first
+func (tn *Notifier) attachmentParts(n notify.Notification, threadID int) []func() error {
+	parts := make([]func() error, 0, 2)
 
-		if resp.StatusCode() != http.StatusOK {
-			logger.Log.Errorf("Telegram responded with status %d: %s", resp.StatusCode(), resp.String())
+	caption := renderTruncated(n, maxCaptionLen)
+	if textLen(caption) > maxCaptionLen {
+		msg := renderTruncated(n, maxMessageLen)
+		parts = append(parts, func() error {
+			return tn.send(msg, threadID, tn.actions(n, msg, false))
+		})
+		caption = ""
+	}
+
+	name := attachmentName(n)
+	content := cleanUTF8(n.Details)
+
+	return append(parts, func() error {
+		markup := ""
+		if caption != "" {
+			markup = tn.actions(n, caption, true)
+		}
+		return scheduler.Submit(tn.chatID, func() error {
+			return tn.withThreadFallback(threadID, func(threadID int) error {
+				return tn.sendDocument(name, content, caption, threadID, markup)
+			})
+		})
+	})
+}
+
+// apiResponse is the error part of a Bot API response
+type apiResponse struct {
+	Description string `json:"description"`
+	Parameters  struct {
+		RetryAfter int `json:"retry_after"` // Seconds to wait after 429
+	} `json:"parameters"`
+}
+
+// rateLimitError is returned for 429 responses. The scheduler waits `After` and sends again
+type rateLimitError struct {
+	After       time.Duration
+	Description string
+}
+
+func (e *rateLimitError) Error() string {
+	return fmt.Sprintf("telegram rate limit: %s", e.Description)
+}
+
+// SendPlainText queues a MarkdownV2-formatted text message for the default topic of the chat and waits until it's sent
+func (tn *Notifier) SendPlainText(msg string) error {
+	return tn.send(msg, tn.threadID, "")
+}
+
+// send queues a MarkdownV2-formatted text message for the forum topic and waits until it's sent.
+// `markup` is an optional inline keyboard
+func (tn *Notifier) send(msg string, threadID int, markup string) error {
+	msg = cleanUTF8(msg) // Sanitize message to ensure it's valid UTF-8
+
+	return scheduler.Submit(tn.chatID, func() error {
+		return tn.withThreadFallback(threadID, func(threadID int) error {
+			return tn.sendMessage(msg, threadID, markup)
+		})
+	})
+}
+
+// withThreadFallback calls `send` with the forum topic. If the topic was deleted, the message goes to the General topic
+func (tn *Notifier) withThreadFallback(threadID int, send func(threadID int) error) error {
+	err := send(threadID)
+	if threadID != 0 && err != nil && strings.Contains(err.Error(), "message thread not found") {
+		logger.Log.Warnf("Forum topic %d not found in chat %s, sending to General", threadID, tn.chatID)
+		return send(0)
+	}
+	return err
+}
+
+// sendMessage calls sendMessage of the Bot API
+func (tn *Notifier) sendMessage(msg string, threadID int, markup string) error {
+	resp, err := client.R().
+		SetFormData(markupParams(markup, threadParams(threadID, map[string]string{
+			"chat_id":    tn.chatID,
+			"text":       msg,
+			"parse_mode": "MarkdownV2", // Enables MarkdownV2 formatting
+		}))).
+		Post(tn.baseURL + "/sendMessage")
+
+	return checkResponse(resp, err)
+}
+
+// sendDocument calls sendDocument of the Bot API with `content` uploaded as a text file
+func (tn *Notifier) sendDocument(name, content, caption string, threadID int, markup string) error {
+	req := client.R().
+		SetFormData(markupParams(markup, threadParams(threadID, map[string]string{
+			"chat_id": tn.chatID,
+		}))).
+		SetFileReader("document", name, strings.NewReader(content))
+
+	if caption != "" {
+		req.SetFormData(map[string]string{
+			"caption":    caption,
+			"parse_mode": "MarkdownV2",
+		})
+	}
+
+	resp, err := req.Post(tn.baseURL + "/sendDocument")
+
+	return checkResponse(resp, err)
+}
+
+// threadParams adds the forum topic to request parameters, if any
+func threadParams(threadID int, params map[string]string) map[string]string {
+	if threadID != 0 {
+		params["message_thread_id"] = strconv.Itoa(threadID)
+	}
+	return params
+}
+
+// markupParams adds the inline keyboard to request parameters, if any
+func markupParams(markup string, params map[string]string) map[string]string {
+	if markup != "" {
+		params["reply_markup"] = markup
+	}
+	return params
+}
+
+// checkResponse converts a failed Bot API call into an error. 429 is returned as *rateLimitError
+func checkResponse(resp *resty.Response, err error) error {
+	if err != nil {
+		return fmt.Errorf("failed to send Telegram message: %w", err)
+	}
+
+	if resp.StatusCode() == http.StatusTooManyRequests {
+		var body apiResponse
+		_ = json.Unmarshal(resp.Body(), &body)
+		return &rateLimitError{
+			After:       time.Duration(max(body.Parameters.RetryAfter, 1)) * time.Second,
+			Description: body.Description,
 		}
 	}
+
+	if resp.StatusCode() != http.StatusOK {
+		return fmt.Errorf("telegram responded with status %d: %s", resp.StatusCode(), resp.String())
+	}
+
+	return nil
 }
@@ -0,0 +1,157 @@
+package telegram
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/ilyxenc/rattle/internal/config"
+	"github.com/ilyxenc/rattle/internal/docker"
+	"github.com/ilyxenc/rattle/internal/logger"
+	"github.com/ilyxenc/rattle/internal/models"
+	"github.com/ilyxenc/rattle/internal/notify"
+	"go.uber.org/zap"
+)
+
+// botAPI is a Bot API stand-in recording successful calls. Fails the call number `failAt` (1-based)
+type botAPI struct {
+	mu     sync.Mutex
+	calls  int
+	failAt int
+	sent   []map[string]string // Parameters of successful calls
+}
+
+var api = &botAPI{}
+
+func TestMain(m *testing.M) {
+	srv := httptest.NewServer(api)
+
+	logger.Log = zap.NewNop().Sugar()
+	config.Cfg = &config.Config{
+		BotToken:  "test",
+		BotAPIURL: srv.URL,
+		Messages:  config.Messages{Overflow: "split"},
+		Bot:       config.Bot{Enabled: true},
+	}
+	Init()
+
+	code := m.Run()
+	srv.Close()
+	os.Exit(code)
+}
+
+func (api *botAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	_ = r.ParseForm()
+
+	api.mu.Lock()
+	defer api.mu.Unlock()
+
+	api.calls++
+	if api.calls == api.failAt {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`{"ok": false, "description": "Bad Request: can't parse entities"}`))
+		return
+	}
+
+	params := map[string]string{}
+	for key := range r.PostForm {
+		params[key] = r.PostForm.Get(key)
+	}
+	api.sent = append(api.sent, params)
+	_ = json.NewEncoder(w).Encode(map[string]any{"ok": true, "result": map[string]any{"message_id": api.calls}})
+}
+
+// reset clears recorded calls and makes call number `failAt` fail
+func (api *botAPI) reset(failAt int) {
+	api.mu.Lock()
+	defer api.mu.Unlock()
+	api.calls, api.failAt, api.sent = 0, failAt, nil
+}
+
+func TestSendPartsResumes(t *testing.T) {
+	api.reset(2) // The second part fails
+
+	tn := NewNotifier("-100", "")
+	n := notify.Notification{
+		Type:        notify.NotificationLogEvent,
+		EventType:   models.EventTypeError,
+		Details:     strings.Repeat("a", 3000) + "\n" + strings.Repeat("b", 3000),
+		Fingerprint: "f",
+		Container:   docker.ContainerInfo{ID: "c1", Name: "api"},
+	}
+
+	sent, err := tn.SendParts(n, 0)
+	if err == nil || sent != 1 {
+		t.Fatalf("first attempt = %d, %v, want the first part sent and an error", sent, err)
+	}
+
+	sent, err = tn.SendParts(n, sent)
+	if err != nil || sent != 2 {
+		t.Fatalf("retry = %d, %v, want both parts sent", sent, err)
+	}
+
+	api.mu.Lock()
+	defer api.mu.Unlock()
+
+	if len(api.sent) != 2 {
+		t.Fatalf("chat got %d messages, want 2", len(api.sent))
+	}
+	first, second := api.sent[0], api.sent[1]
+	if !strings.Contains(first["text"], "aaa") || first["reply_markup"] == "" {
+		t.Errorf("first message = %v, want the beginning of the log text with buttons", first)
+	}
+	if !strings.Contains(second["text"], "Continued 2/2") || !strings.Contains(second["text"], "bbb") || second["reply_markup"] != "" {
+		t.Errorf("second message = %v, want the rest of the log text without buttons", second)
+	}
+}
+
+func TestSendPartsDone(t *testing.T) {
+	api.reset(0)
+
+	// A retry of a notification whose parts were all sent sends nothing
+	n := notify.Notification{Type: notify.NotificationContainerStop, Container: docker.ContainerInfo{ID: "c1", Name: "api"}}
+	sent, err := NewNotifier("-100", "").SendParts(n, 1)
+	if err != nil || sent != 1 {
+		t.Fatalf("SendParts = %d, %v, want 1 and no error", sent, err)
+	}
+
+	api.mu.Lock()
+	defer api.mu.Unlock()
+	if len(api.sent) != 0 {
+		t.Errorf("chat got %v, want nothing", api.sent)
+	}
+}
+
+func TestAcknowledgeAfterEdit(t *testing.T) {
+	token := alerts.add(Alert{Text: "alert"})
+
+	// A failed edit leaves the alert unacknowledged, so the press can be retried
+	api.reset(1)
+	acked, err := Acknowledge(context.Background(), 1, 2, token, "alice")
+	if !acked || err == nil {
+		t.Fatalf("Acknowledge() = %v, %v, want true and an error", acked, err)
+	}
+	if a, _ := LookupAlert(token); a.AckedBy != "" {
+		t.Fatalf("AckedBy = %q after a failed edit, want empty", a.AckedBy)
+	}
+
+	api.reset(0)
+	if acked, err := Acknowledge(context.Background(), 1, 2, token, "bob"); !acked || err != nil {
+		t.Fatalf("Acknowledge() = %v, %v, want true and no error", acked, err)
+	}
+	if a, _ := LookupAlert(token); a.AckedBy != "bob" {
+		t.Fatalf("AckedBy = %q, want bob", a.AckedBy)
+	}
+	if len(api.sent) != 1 || !strings.Contains(api.sent[0]["text"], "Acknowledged by bob") {
+		t.Fatalf("edit = %v, want the acknowledgement note", api.sent)
+	}
+
+	if acked, _ := Acknowledge(context.Background(), 1, 2, token, "carol"); acked {
+		t.Fatal("Acknowledge() = true for an acknowledged alert")
+	}
+}
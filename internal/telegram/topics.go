@@ -0,0 +1,35 @@
+package telegram
+
+import (
+	"fmt"
+
+	"github.com/ilyxenc/rattle/internal/models"
+	"github.com/ilyxenc/rattle/internal/notify"
+)
+
+// ValidateTopics checks that every key of the mapping is a notification type or an event type
+// and every topic ID is positive
+func ValidateTopics(topics map[string]int) error {
+	for key, threadID := range topics {
+		_, isEventType := models.EventTypeSeverity[key]
+		if !isEventType && !notify.NotificationType(key).Valid() {
+			return fmt.Errorf("unknown notification or event type in topics: %s", key)
+		}
+		if threadID <= 0 {
+			return fmt.Errorf("invalid topic ID for %s: %d", key, threadID)
+		}
+	}
+	return nil
+}
+
+// threadFor returns the forum topic for the notification: by notification type, then by event type,
+// then the default topic of the chat
+func (tn *Notifier) threadFor(n notify.Notification) int {
+	if id, ok := tn.topics[string(n.Type)]; ok {
+		return id
+	}
+	if id, ok := tn.topics[notify.Severity(n)]; ok {
+		return id
+	}
+	return tn.threadID
+}
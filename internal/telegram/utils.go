@@ -7,6 +7,7 @@ import (
 	"unicode/utf8"
 
 	"github.com/ilyxenc/rattle/internal/docker"
+	"github.com/ilyxenc/rattle/internal/notify"
 )
 
 // formatMessage formats an error message for Telegram using MarkdownV2 code block
@@ -15,14 +16,48 @@ func formatMessage(eventType, errText string) string {
 	return fmt.Sprintf("\n\n```%s\n%s\n```", escapeMarkdownV2(eventType), escapeMarkdownV2(cleaned))
 }
 
-// formatMeta returns formatted container metadata with timestamp, used as part of notifications
-func formatMeta(ci docker.ContainerInfo) string {
+// formatMeta returns formatted container metadata with timestamp, used as part of notifications.
+// Zero `t` is replaced with the current time
+func formatMeta(ci docker.ContainerInfo, t time.Time) string {
+	if t.IsZero() {
+		t = time.Now()
+	}
+
 	return fmt.Sprintf(
 		"\n\n📦 ID: `%s`\nName: `%s`\nImage: `%s`\n\n|| %s ||",
-		ci.ShortID, ci.Name, ci.Image, escapeMarkdownV2(time.Now().Format("2006-01-02T15:04:05.000Z07:00")), // With milliseconds
+		ci.ShortID, ci.Name, ci.Image, escapeMarkdownV2(notify.FormatTime(t)), // With milliseconds
 	)
 }
 
+// formatFields returns fields of a structured log line, one per line
+func formatFields(fields []notify.Field) string {
+	if len(fields) == 0 {
+		return ""
+	}
+
+	msg := "\n"
+	for _, f := range fields {
+		msg += fmt.Sprintf("\n*%s:* `%s`", escapeMarkdownV2(f.Key), escapeMarkdownV2(cleanUTF8(f.Value)))
+	}
+	return msg
+}
+
+// formatRule returns the pattern of the rule that triggered the notification
+func formatRule(rule string) string {
+	if rule == "" {
+		return ""
+	}
+	return fmt.Sprintf("\n\n🔎 Pattern: `%s`", escapeMarkdownV2(rule))
+}
+
+// formatStream returns the log stream the event came from, used as part of log notifications
+func formatStream(stream string) string {
+	if stream == "" {
+		return ""
+	}
+	return fmt.Sprintf("\n\n📤 Stream: `%s`", stream)
+}
+
 // formatContainersSummary returns formatted information about active containers
 func formatContainersSummary(containers []docker.ContainerInfo) string {
 	if len(containers) == 0 {
@@ -59,6 +94,7 @@ func cleanUTF8(input string) string {
 // escapeMarkdownV2 escapes all special MarkdownV2 characters in a string to prevent formatting issues or Telegram API errors
 func escapeMarkdownV2(text string) string {
 	replacer := strings.NewReplacer(
+		`\`, `\\`,
 		`_`, `\_`,
 		`*`, `\*`,
 		`[`, `\[`,
@@ -81,37 +117,7 @@ func escapeMarkdownV2(text string) string {
 	return replacer.Replace(text)
 }
 
-// EventEmoji returns emoji for title based on event type
-func EventEmoji(eventType string) string {
-	switch eventType {
-	case "error":
-		return "❌"
-	case "warning":
-		return "⚠️"
-	case "success":
-		return "✅"
-	case "info":
-		return "ℹ️"
-	case "critical":
-		return "🚨"
-	default:
-		return "📦"
-	}
-}
-
+// FormatEventTitle returns the MarkdownV2 title of a log event based on event type
 func FormatEventTitle(eventType, containerName string) string {
-	switch eventType {
-	case "error":
-		return fmt.Sprintf("❌ *Error in container:* `%s`", containerName)
-	case "warning":
-		return fmt.Sprintf("⚠️ *Warning in container:* `%s`", containerName)
-	case "success":
-		return fmt.Sprintf("✅ *Success in container:* `%s`", containerName)
-	case "info":
-		return fmt.Sprintf("ℹ️ *Info from container:* `%s`", containerName)
-	case "critical":
-		return fmt.Sprintf("🚨 *Critical event in container:* `%s`", containerName)
-	default:
-		return fmt.Sprintf("📦 *Log from container:* `%s`", containerName)
-	}
+	return fmt.Sprintf("%s *%s:* `%s`", notify.EventEmoji(eventType), notify.EventTitle(eventType), containerName)
 }